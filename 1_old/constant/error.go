@@ -6,4 +6,8 @@ var (
 	NullPointerError = errors.New("null pointer error")
 	NotFoundError    = errors.New("not found error")
 	UnknownError     = errors.New("unknown error")
+	CircuitOpenError = errors.New("circuit open error")
+	RateLimitedError = errors.New("rate limited error")
+	// 命中缓存的错误结果, 原始错误的信息在 error 文本中
+	NegativeCacheError = errors.New("negative cache error")
 )
@@ -14,5 +14,16 @@ const (
 	NodeRunCount         = "node.%s.run.count"     // node_name
 	NodeRunSpeed         = "node.%s.run.speed"     // node_name
 	NodeRunError         = "node.%s.run.error"     // node_name
+	NodeRunRetry         = "node.%s.run.retry"     // node_name
 	NodeFieldSupplyError = "field.%s.supply.error" // field_name
 )
+
+// plugin 指标
+const (
+	PluginCallCount            = "plugin.%s.call.count"             // supplier_name.plugin_name
+	PluginCallSpeed            = "plugin.%s.call.speed"             // supplier_name.plugin_name
+	PluginCallError            = "plugin.%s.call.error"             // supplier_name.plugin_name
+	PluginHedgeCount           = "plugin.%s.hedge.count"            // plugin_name
+	PluginHedgeWin             = "plugin.%s.hedge.win"              // plugin_name
+	PluginHedgeBudgetExhausted = "plugin.%s.hedge.budget_exhausted" // plugin_name
+)
@@ -17,23 +17,26 @@ func newDagBuilder(logger log.ILog) *dagBuilder {
 	}
 }
 
-func (builder dagBuilder) build(root node.INode, nodes []node.INode) (node.INode, []node.INode) {
+// roots 为所有入口的 root 节点, 节点由所有入口共享.
+func (builder dagBuilder) build(roots []node.INode, nodes []node.INode) []node.INode {
 	// 节点集优化
 	nodes = builder.mergeNodes(nodes)
 
 	// 构建节点依赖关系
-	builder.analyseNodeDep(root, nodes)
+	builder.analyseNodeDep(roots, nodes)
 
 	// 孤儿节点检查和移除
 	orphanNodeMap := builder.analyseOrphanNode(nodes)
 	builder.removeOrphanNode(orphanNodeMap)
 
 	// 重新设置节点补数阶段
-	builder.resetSupplyMode(root)
+	for _, root := range roots {
+		builder.resetSupplyMode(root)
+	}
 
 	// 计算节点权重
 	builder.calculateNodePriority(nodes)
-	return root, nodes
+	return nodes
 }
 
 // 合并有相同函数调用的节点
@@ -55,33 +58,47 @@ func (builder dagBuilder) mergeNodes(nodes []node.INode) []node.INode {
 	return newNodes
 }
 
-func (builder dagBuilder) analyseNodeDep(root node.INode, nodes []node.INode) {
-	// dag 内节点可以获取的参数集合, 包括外界输入的 inputs, 节点产生的 fields
-	fieldMap := make(map[string]node.INode)
-	for _, cnode := range append(nodes, root) {
+func (builder dagBuilder) analyseNodeDep(roots []node.INode, nodes []node.INode) {
+	// dag 内节点可以获取的参数集合, 包括外界输入的 inputs, 节点产生的 fields.
+	// 不同入口的 inputs 可以重名, 此时节点依赖所有产生该字段的 root.
+	fieldMap := make(map[string][]node.INode)
+	for _, cnode := range nodes {
 		for _, fieldCode := range cnode.GetFieldCodes() {
-			fieldMap[fieldCode] = cnode
+			fieldMap[fieldCode] = []node.INode{cnode}
+		}
+	}
+	rootFieldMap := make(map[string][]node.INode)
+	for _, root := range roots {
+		for _, fieldCode := range root.GetFieldCodes() {
+			rootFieldMap[fieldCode] = append(rootFieldMap[fieldCode], root)
 		}
 	}
+	for fieldCode, parentNodes := range rootFieldMap {
+		fieldMap[fieldCode] = parentNodes
+	}
 	for _, cnode := range nodes {
 		paramVars := cnode.GetParamVariables()
 		if len(paramVars) == 0 {
 			builder.logger.Infof(context.Background(),
 				"node [%s] have zero var_params, add to root's child", cnode.GetID())
-			root.AddNexts(cnode)
-			cnode.AddPrevs(root)
+			for _, root := range roots {
+				root.AddNexts(cnode)
+				cnode.AddPrevs(root)
+			}
 			continue
 		}
 		for _, param := range paramVars {
-			parentNode, ok := fieldMap[param.FieldName]
+			parentNodes, ok := fieldMap[param.FieldName]
 			if !ok {
 				// param_var 不是其他节点产生的 && 不是外界输入的, 跳过执行
 				builder.logger.Warnf(context.Background(),
 					"node [%s] param [%s] not found in dag", cnode.GetID(), param.FieldName)
 				continue
 			}
-			parentNode.AddNexts(cnode)
-			cnode.AddPrevs(parentNode)
+			for _, parentNode := range parentNodes {
+				parentNode.AddNexts(cnode)
+				cnode.AddPrevs(parentNode)
+			}
 		}
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -22,6 +23,11 @@ type IDAG interface {
 	Run(ctx context.Context, runtimeID string, paramMap map[string]interface{}) IRuntime
 	// 补充所有字段
 	Supply(ctx context.Context, runtimeID string, paramMap map[string]interface{}) *Result
+	// 从指定入口运行 DAG, 只调度该入口可以执行的节点. Run 等同于从默认入口运行, entry 为空时使用默认入口.
+	// 入口不存在时不执行任何节点, Result.FailReason 为 entry_not_found.
+	RunEntry(ctx context.Context, entry, runtimeID string, paramMap map[string]interface{}) IRuntime
+	// 从指定入口补充字段
+	SupplyEntry(ctx context.Context, entry, runtimeID string, paramMap map[string]interface{}) *Result
 
 	// 获取当前字段依赖的字段
 	GetFieldRelys(ctx context.Context, field string) ([]string, error)
@@ -29,7 +35,11 @@ type IDAG interface {
 	SupplyField(ctx context.Context, data map[string]interface{}, field string) *node.FieldResult
 	// SupplyFields(ctx context.Context, data map[string]interface{}, fields []string) *Result
 
+	// 默认入口的 root
 	GetRoot() node.INode
+	// 全部入口名称, 按名称排序
+	GetEntries() []string
+	GetEntryRoot(entry string) (node.INode, bool)
 	Use(...Middleware)
 	Update(nodes []node.INode)
 
@@ -46,8 +56,9 @@ type IDAG interface {
 // DAG is 根据节点的依赖关系构建的图.
 type DAG struct {
 	id             string
-	root           node.INode
-	nodeConcurrent int // 字段并发执行数
+	root           node.INode // 默认入口的 root
+	defaultEntry   string     // DefaultEntry, 不存在时为名称最小的入口
+	nodeConcurrent int        // 字段并发执行数
 	logger         log.ILog
 
 	mwchain     Handler // middleware chain
@@ -72,18 +83,36 @@ func New(request *CreateDAGRequest, _options ...Option) (*DAG, error) {
 		option(options)
 	}
 
-	root := request.Root
+	entries := sortedEntries(request.Entries)
+	roots := make([]node.INode, len(entries))
+	for i, entry := range entries {
+		roots[i] = request.Entries[entry]
+	}
 	nodes := request.Nodes
 	{
 		dagBuilder := newDagBuilder(request.Logger)
-		root, _ = dagBuilder.build(root, nodes)
+		nodes = dagBuilder.build(roots, nodes)
+	}
+	// 节点合并后字段会发生变化, 重新校验
+	for _, cnode := range append(nodes, roots...) {
+		if err := node.ValidateSchema(cnode); err != nil {
+			return &DAG{}, err
+		}
+	}
+	if err := validateParamPaths(append(nodes, roots...)); err != nil {
+		return &DAG{}, err
 	}
 
-	preComputeData := preCompute(root)
+	preComputeData := preCompute(request.Entries)
 
+	defaultEntry := entries[0]
+	if _, ok := request.Entries[DefaultEntry]; ok {
+		defaultEntry = DefaultEntry
+	}
 	dag := &DAG{
 		id:             request.ID,
-		root:           root,
+		root:           request.Entries[defaultEntry],
+		defaultEntry:   defaultEntry,
 		nodeConcurrent: request.NodeConcurrent,
 		logger:         request.Logger,
 		preComputeData: preComputeData,
@@ -95,6 +124,38 @@ func New(request *CreateDAGRequest, _options ...Option) (*DAG, error) {
 	return dag, nil
 }
 
+// 校验变量参数的子字段路径: 上游字段需要是对象类型, 且 schema 中存在该路径.
+// 上游字段不在 dag 中时按照孤儿节点处理, 这里不校验.
+func validateParamPaths(nodes []node.INode) error {
+	fields := map[string]*node.Field{}
+	for _, cnode := range nodes {
+		for _, field := range cnode.GetFields() {
+			fields[field.Code] = field
+		}
+	}
+	for _, cnode := range nodes {
+		for _, param := range cnode.GetParamVariables() {
+			if param.SubPath == "" {
+				continue
+			}
+			field, ok := fields[param.FieldName]
+			if !ok {
+				continue
+			}
+			schema, ok := dtype.GetObjectSchema(field.FieldType)
+			if !ok {
+				return fmt.Errorf("node [%s] param [%s] sub_path [%s]: field type %s is not object",
+					cnode.GetID(), param.ID, param.SubPath, field.FieldType)
+			}
+			if _, ok := schema.PathType(param.SubPath); !ok {
+				return fmt.Errorf("node [%s] param [%s] sub_path [%s] not found in %s",
+					cnode.GetID(), param.ID, param.SubPath, field.FieldType)
+			}
+		}
+	}
+	return nil
+}
+
 func (dag *DAG) GetID() string {
 	return dag.id
 }
@@ -131,15 +192,18 @@ func (dag *DAG) getFieldInfo(ctx context.Context, fieldCode string) (node.INode,
 	if ok && ok2 {
 		return cnode, field, nil
 	}
-	for _, nodeField := range dag.GetRoot().GetFields() {
-		if fieldCode == nodeField.Code {
-			return dag.GetRoot(), nodeField, nil
+	for _, entry := range dag.GetEntries() {
+		root := dag.entries[entry].root
+		for _, nodeField := range root.GetFields() {
+			if fieldCode == nodeField.Code {
+				return root, nodeField, nil
+			}
 		}
-	}
-	for _, cnode := range dag.GetRoot().Prune() {
-		for _, nodeField := range cnode.GetFields() {
-			if nodeField.Code == fieldCode {
-				return cnode, nodeField, nil
+		for _, cnode := range root.Prune() {
+			for _, nodeField := range cnode.GetFields() {
+				if nodeField.Code == fieldCode {
+					return cnode, nodeField, nil
+				}
 			}
 		}
 	}
@@ -155,22 +219,44 @@ func (dag *DAG) Run(ctx context.Context, runtimeID string, paramMap map[string]i
 	return dag.mwchain(ctx, runtimeID, paramMap)
 }
 
+func (dag *DAG) SupplyEntry(ctx context.Context, entry, runtimeID string, paramMap map[string]interface{}) *Result {
+	result := dag.RunEntry(ctx, entry, runtimeID, paramMap).Wait(ctx).GetResultCopy()
+	return result
+}
+
+// 入口名称通过 context 传递, 中间件的签名保持不变.
+func (dag *DAG) RunEntry(ctx context.Context, entry, runtimeID string, paramMap map[string]interface{}) IRuntime {
+	return dag.mwchain(WithEntry(ctx, entry), runtimeID, paramMap)
+}
+
 // 如果需要设置 traceid 等信息, 可以从改造 context 入手.
 // todo [optimize] 这里其实有很多的扩展空间, 目前是同步结束就返回结果, 其实 dag 已经支持任意阶段判断
 func (dag *DAG) handler(ctx context.Context, runtimeID string, paramMap map[string]interface{}) IRuntime {
-	runtime := dag.createRuntime(runtimeID)
+	entryName := EntryFromContext(ctx)
+	if entryName == "" {
+		entryName = dag.defaultEntry
+	}
+	entry, ok := dag.entries[entryName]
+	if !ok {
+		// 入口不存在时不执行任何节点, 返回的结果带有失败原因
+		dag.logger.Errorf(ctx, "dag [%s] entry [%s] not found", dag.id, entryName)
+		runtime := dag.createRuntime(runtimeID, &entryData{})
+		runtime.failReason = FailReasonEntryNotFound + ": " + entryName
+		return runtime.Run(ctx, paramMap)
+	}
+	runtime := dag.createRuntime(runtimeID, entry)
 	return runtime.Run(ctx, paramMap)
 }
 
-func (dag *DAG) createRuntime(runtimeID string) *runtime {
+func (dag *DAG) createRuntime(runtimeID string, entry *entryData) *runtime {
 	// 每次运行都需要重新生成
-	nsKeeper := newNodeStateKeeper(int(dag.allNodeCnt))
-	stageKeeper := NewDefaultStageKeeper(dag.stageNodeCntMap)
+	nsKeeper := newNodeStateKeeper(int(entry.allNodeCnt), entry.nodeIDs)
+	stageKeeper := NewDefaultStageKeeper(entry.stageNodeCntMap)
 	resultKeeper := NewDefaultResultKeeper()
 	return &runtime{
 		id:           runtimeID,
-		root:         dag.root,
-		allNodeCnt:   dag.allNodeCnt,
+		root:         entry.root,
+		allNodeCnt:   entry.allNodeCnt,
 		concurrent:   dag.nodeConcurrent,
 		logger:       dag.logger,
 		allNodeDone:  make(chan struct{}),
@@ -185,6 +271,32 @@ func (dag *DAG) GetRoot() node.INode {
 	return dag.root
 }
 
+func (dag *DAG) GetEntries() []string {
+	entries := make([]string, 0, len(dag.entries))
+	for entry := range dag.entries {
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+func (dag *DAG) GetEntryRoot(entry string) (node.INode, bool) {
+	data, ok := dag.entries[entry]
+	if !ok {
+		return nil, false
+	}
+	return data.root, true
+}
+
+func (dag *DAG) isRoot(cnode node.INode) bool {
+	for _, entry := range dag.entries {
+		if entry.root.GetID() == cnode.GetID() {
+			return true
+		}
+	}
+	return false
+}
+
 // 中间件调用链, 按照 Use 的顺序执行. 每次添加 middleware 需要重新构建.
 // 可以添加指针指向 chain.last_handler, 这样每次新增 middleware 时, 替换这个指针.
 // 考虑到该操作十分低频, 性能提升获取的收益远小于复杂度提升带来的缺点, 故放弃.
@@ -217,7 +329,7 @@ func (dag *DAG) GetFieldRelys(ctx context.Context, fieldCode string) ([]string,
 func (dag *DAG) getFieldRelys(ctx context.Context, cnode node.INode) map[string]struct{} {
 	// todo [next] 不允许执行 root 节点, 因为 root 的参数是外部输入的, 存在外部输入和内部字段相同的情况, 会导致无限循环.
 	// 后续可以考虑兼容这个问题, 要求外部输入与内部字段不同.
-	if cnode.GetID() == dag.GetRoot().GetID() {
+	if dag.isRoot(cnode) {
 		return map[string]struct{}{}
 	}
 	fieldSet := map[string]struct{}{}
@@ -254,7 +366,7 @@ func (dag *DAG) runSpecNode(ctx context.Context, cnode node.INode, event map[str
 			}
 			// todo [next] 不允许执行 root 节点, 因为 root 的参数是外部输入的, 存在外部输入和内部字段相同的情况, 会导致无限循环.
 			// 后续可以考虑兼容这个问题, 要求外部输入与内部字段不同.
-			if cnodeParent.GetID() == dag.GetRoot().GetID() {
+			if dag.isRoot(cnodeParent) {
 				return cnode.ValueOnError("can not run root node")
 			}
 			// 执行该节点以获取值
@@ -271,6 +383,11 @@ func (dag *DAG) runSpecNode(ctx context.Context, cnode node.INode, event map[str
 	if !rt.IsReady() {
 		return cnode.ValueOnError("params lost")
 	}
+	// 备用数据源的参数只从 event 中取值, 不执行上游节点
+	ctx = node.WithParamResolver(ctx, func(fieldName string) (interface{}, bool) {
+		value, ok := event[fieldName]
+		return value, ok
+	})
 	nodeResult := rt.Run(ctx)
 	return nodeResult
 }
@@ -2,6 +2,7 @@ package dag
 
 import (
 	"errors"
+	"fmt"
 
 	"git.in.zhihu.com/antispam/datasupply/log"
 	"git.in.zhihu.com/antispam/datasupply/node"
@@ -9,8 +10,9 @@ import (
 
 type CreateDAGRequest struct {
 	// 依赖构建
-	Root  node.INode
-	Nodes []node.INode
+	Root    node.INode
+	Entries map[string]node.INode // 命名的入口, key 为入口名称. Root 不为空时作为 DefaultEntry 入口
+	Nodes   []node.INode
 
 	ID             string
 	NodeConcurrent int
@@ -24,15 +26,39 @@ func (request *CreateDAGRequest) LoadDefault() {
 	if request.Logger == nil {
 		request.Logger = log.NewDefaultLog()
 	}
+	if request.Root != nil {
+		entries := make(map[string]node.INode, len(request.Entries)+1)
+		for name, root := range request.Entries {
+			entries[name] = root
+		}
+		if _, ok := entries[DefaultEntry]; !ok {
+			entries[DefaultEntry] = request.Root
+		}
+		request.Entries = entries
+	}
 }
 
 func (request *CreateDAGRequest) Validate() error {
 	if request.ID == "" {
 		return errors.New("create_dag_request.dag must have id")
 	}
-	if request.Root == nil {
+	if len(request.Entries) == 0 {
 		return errors.New("create_dag_request.root can not be nil")
 	}
+	if request.Root != nil && request.Entries[DefaultEntry] != request.Root {
+		return fmt.Errorf("create_dag_request.entries [%s] conflict with root", DefaultEntry)
+	}
+	rootIDSet := make(map[string]string, len(request.Entries))
+	for name, root := range request.Entries {
+		if root == nil {
+			return fmt.Errorf("create_dag_request.entries [%s] root can not be nil", name)
+		}
+		if entry, ok := rootIDSet[root.GetID()]; ok {
+			return fmt.Errorf("create_dag_request.entries [%s] and [%s] have the same root [%s]",
+				entry, name, root.GetID())
+		}
+		rootIDSet[root.GetID()] = name
+	}
 	if request.Nodes == nil {
 		return errors.New("create_dag_request.nodes can not be nil")
 	}
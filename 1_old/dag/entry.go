@@ -0,0 +1,87 @@
+package dag
+
+import (
+	"context"
+	"sort"
+
+	"git.in.zhihu.com/antispam/datasupply/node"
+)
+
+// CreateDAGRequest.Root 对应的入口名称, 也是 Run/Supply 使用的入口.
+const DefaultEntry = "default"
+
+// 入口不存在时 Result.FailReason 的前缀.
+const FailReasonEntryNotFound = "entry_not_found"
+
+type entryCtxKey struct{}
+
+// WithEntry 设置本次运行的入口, 中间件可以通过 EntryFromContext 获取.
+func WithEntry(ctx context.Context, entry string) context.Context {
+	return context.WithValue(ctx, entryCtxKey{}, entry)
+}
+
+// EntryFromContext 返回本次运行的入口, 未设置时返回空字符串.
+func EntryFromContext(ctx context.Context) string {
+	entry, _ := ctx.Value(entryCtxKey{}).(string)
+	return entry
+}
+
+// 入口的预计算数据. 节点定义和字段索引由所有入口共享, 每个入口只记录自己可以执行的节点.
+type entryData struct {
+	root            node.INode
+	nodeIDs         map[string]struct{} // 入口可以执行的节点, 包括 root
+	allNodeCnt      int32               // 全部待补数字段数量
+	stageNodeCntMap map[node.SupplyStage]int32
+	stageNodeIDMap  map[node.SupplyStage][]string
+}
+
+// 入口可以执行的节点: root 可达, 且所有变量参数都可以由入口内的上游节点提供.
+// 依赖其他入口输入字段的节点不会在当前入口执行.
+func entryNodes(root node.INode) []node.INode {
+	nodeSet := map[string]struct{}{root.GetID(): {}}
+	nodes := []node.INode{root}
+	candidates := root.Prune()
+	for changed := true; changed; {
+		changed = false
+		for _, cnode := range candidates {
+			if _, ok := nodeSet[cnode.GetID()]; ok {
+				continue
+			}
+			if !paramsProvided(cnode, nodeSet) {
+				continue
+			}
+			nodeSet[cnode.GetID()] = struct{}{}
+			nodes = append(nodes, cnode)
+			changed = true
+		}
+	}
+	return nodes
+}
+
+func paramsProvided(cnode node.INode, nodeSet map[string]struct{}) bool {
+	fieldSet := map[string]struct{}{}
+	for _, prev := range cnode.GetPrevs() {
+		if _, ok := nodeSet[prev.GetID()]; !ok {
+			continue
+		}
+		for _, fieldCode := range prev.GetFieldCodes() {
+			fieldSet[fieldCode] = struct{}{}
+		}
+	}
+	for _, param := range cnode.GetParamVariables() {
+		if _, ok := fieldSet[param.FieldName]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// 按名称排序的入口, 保证遍历顺序稳定.
+func sortedEntries(entries map[string]node.INode) []string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
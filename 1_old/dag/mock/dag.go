@@ -63,6 +63,35 @@ func (mr *MockIDAGMockRecorder) ConvertField(fieldCode, value interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertField", reflect.TypeOf((*MockIDAG)(nil).ConvertField), fieldCode, value)
 }
 
+// GetEntries mocks base method.
+func (m *MockIDAG) GetEntries() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEntries")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// GetEntries indicates an expected call of GetEntries.
+func (mr *MockIDAGMockRecorder) GetEntries() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntries", reflect.TypeOf((*MockIDAG)(nil).GetEntries))
+}
+
+// GetEntryRoot mocks base method.
+func (m *MockIDAG) GetEntryRoot(entry string) (node.INode, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEntryRoot", entry)
+	ret0, _ := ret[0].(node.INode)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetEntryRoot indicates an expected call of GetEntryRoot.
+func (mr *MockIDAGMockRecorder) GetEntryRoot(entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntryRoot", reflect.TypeOf((*MockIDAG)(nil).GetEntryRoot), entry)
+}
+
 // GetField mocks base method.
 func (m *MockIDAG) GetField(ctx context.Context, fieldCode string) (*node.Field, error) {
 	m.ctrl.T.Helper()
@@ -150,6 +179,20 @@ func (mr *MockIDAGMockRecorder) Run(ctx, runtimeID, paramMap interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockIDAG)(nil).Run), ctx, runtimeID, paramMap)
 }
 
+// RunEntry mocks base method.
+func (m *MockIDAG) RunEntry(ctx context.Context, entry, runtimeID string, paramMap map[string]interface{}) dag.IRuntime {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunEntry", ctx, entry, runtimeID, paramMap)
+	ret0, _ := ret[0].(dag.IRuntime)
+	return ret0
+}
+
+// RunEntry indicates an expected call of RunEntry.
+func (mr *MockIDAGMockRecorder) RunEntry(ctx, entry, runtimeID, paramMap interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunEntry", reflect.TypeOf((*MockIDAG)(nil).RunEntry), ctx, entry, runtimeID, paramMap)
+}
+
 // Supply mocks base method.
 func (m *MockIDAG) Supply(ctx context.Context, runtimeID string, paramMap map[string]interface{}) *dag.Result {
 	m.ctrl.T.Helper()
@@ -164,6 +207,20 @@ func (mr *MockIDAGMockRecorder) Supply(ctx, runtimeID, paramMap interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Supply", reflect.TypeOf((*MockIDAG)(nil).Supply), ctx, runtimeID, paramMap)
 }
 
+// SupplyEntry mocks base method.
+func (m *MockIDAG) SupplyEntry(ctx context.Context, entry, runtimeID string, paramMap map[string]interface{}) *dag.Result {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SupplyEntry", ctx, entry, runtimeID, paramMap)
+	ret0, _ := ret[0].(*dag.Result)
+	return ret0
+}
+
+// SupplyEntry indicates an expected call of SupplyEntry.
+func (mr *MockIDAGMockRecorder) SupplyEntry(ctx, entry, runtimeID, paramMap interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SupplyEntry", reflect.TypeOf((*MockIDAG)(nil).SupplyEntry), ctx, entry, runtimeID, paramMap)
+}
+
 // SupplyField mocks base method.
 func (m *MockIDAG) SupplyField(ctx context.Context, data map[string]interface{}, field string) *node.FieldResult {
 	m.ctrl.T.Helper()
@@ -19,9 +19,11 @@ type nodeStateKeeper struct {
 	wait   sync.Map // 等待态, 即依赖部分就绪就绪
 	prune  sync.Map // 被剪枝节点. 无需再运行
 	closed chan struct{}
+
+	nodeIDs map[string]struct{} // 本次运行的入口可以执行的节点, 其他节点不会被调度
 }
 
-func newNodeStateKeeper(size int) *nodeStateKeeper {
+func newNodeStateKeeper(size int, nodeIDs map[string]struct{}) *nodeStateKeeper {
 	if size == 0 {
 		size = 500
 	}
@@ -32,9 +34,10 @@ func newNodeStateKeeper(size int) *nodeStateKeeper {
 			node.PriorityLow:  make(chan node.IRuntime, size),
 			node.PriorityMin:  make(chan node.IRuntime, size),
 		},
-		wait:   sync.Map{},
-		prune:  sync.Map{},
-		closed: make(chan struct{}),
+		wait:    sync.Map{},
+		prune:   sync.Map{},
+		closed:  make(chan struct{}),
+		nodeIDs: nodeIDs,
 	}
 }
 
@@ -104,6 +107,9 @@ func (nodeStateKeeper *nodeStateKeeper) Pop(ctx context.Context) node.IRuntime {
 // todo [optimize] 状态转换规则可以尝试用 状态机 等方案优化下.
 func (nodeStateKeeper *nodeStateKeeper) Detection(cnode node.INode, nodeResult node.Result) {
 	for _, childNode := range cnode.GetNexts() {
+		if _, ok := nodeStateKeeper.nodeIDs[childNode.GetID()]; !ok {
+			continue
+		}
 		if _, hasPrune := nodeStateKeeper.prune.Load(childNode.GetID()); hasPrune {
 			continue
 		}
@@ -120,9 +126,12 @@ func (nodeStateKeeper *nodeStateKeeper) Detection(cnode node.INode, nodeResult n
 			if !fieldResult.IsSupplySuccess() {
 				// TODO [optimize] (同 param.go) 可以看下这个 onerror 有无更好的处理方式.
 				var paramValue interface{}
-				isPrune, paramValue = param.HandleError()
+				isPrune, paramValue = param.HandleError(fieldResult.Meta.GetFailReason())
 				if isPrune {
 					for _, cnode := range append(childNode.Prune(), childNode) {
+						if _, ok := nodeStateKeeper.nodeIDs[cnode.GetID()]; !ok {
+							continue
+						}
 						_, loaded := nodeStateKeeper.prune.LoadOrStore(cnode.GetID(), struct{}{})
 						if loaded {
 							continue
@@ -0,0 +1,137 @@
+package dag
+
+import (
+	"context"
+	"fmt"
+
+	"git.in.zhihu.com/antispam/datasupply/node"
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+)
+
+// Plugin 将 DAG 包装为插件, 用于在其他 DAG 的节点中复用一组字段(如用户风险画像), 不需要展开配置.
+// 插件的参数按顺序对应内部 DAG root 的输入字段, 返回值的 key 为内部 DAG 导出的字段, value 为内部字段的值,
+// 补数失败且没有默认值的字段不返回. 内部字段的 FieldMeta 通过 supplier.CallInfo 的 OutputMeta 返回,
+// 节点取值时使用内部字段的失败原因, 调用次数, 缓存命中和数据源.
+// 内部 DAG 使用节点的 ctx 运行, 节点超时或取消时内部的运行也会被取消.
+// 内部 DAG 总是从插件指定的入口运行, 不使用外部 DAG 通过 ctx 传递的入口.
+type Plugin struct {
+	name   string
+	dag    IDAG
+	entry  string // 内部 DAG 的入口, 为空时使用内部 DAG 的默认入口
+	params []string
+	fields []string
+}
+
+var _ supplier.IDescribedPlugin = new(Plugin)
+
+// NewPlugin 从内部 DAG 的默认入口运行, 参数见 NewEntryPlugin.
+func NewPlugin(name string, dag IDAG, params, fields []string) *Plugin {
+	return NewEntryPlugin(name, dag, "", params, fields)
+}
+
+// NewEntryPlugin entry 为内部 DAG 的入口, 为空时使用默认入口.
+// params 为入口 root 的输入字段, 为空时使用 root 的全部变量参数.
+// fields 为返回的内部字段, 为空时返回内部 DAG 导出的全部字段.
+func NewEntryPlugin(name string, dag IDAG, entry string, params, fields []string) *Plugin {
+	p := &Plugin{
+		name:   name,
+		dag:    dag,
+		entry:  entry,
+		params: params,
+		fields: fields,
+	}
+	if len(p.params) == 0 {
+		if root := p.root(); root != nil {
+			for _, param := range root.GetParamVariables() {
+				p.params = append(p.params, param.FieldName)
+			}
+		}
+	}
+	return p
+}
+
+// 入口的 root, 入口不存在时为 nil.
+func (p *Plugin) root() node.INode {
+	if p.entry == "" {
+		return p.dag.GetRoot()
+	}
+	root, _ := p.dag.GetEntryRoot(p.entry)
+	return root
+}
+
+func (p *Plugin) GetName() string {
+	return p.name
+}
+
+func (p *Plugin) Call(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+	if len(args) != len(p.params) {
+		return nil, fmt.Errorf("dag plugin [%s] expects %d params, got %d", p.name, len(p.params), len(args))
+	}
+	paramMap := make(map[string]interface{}, len(args))
+	for i, arg := range args {
+		paramMap[p.params[i]] = arg
+	}
+	// 显式指定入口, 覆盖外部 DAG 在 ctx 中的入口
+	result := p.dag.SupplyEntry(ctx, p.entry, p.name, paramMap)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("dag plugin [%s] canceled: %w", p.name, err)
+	}
+	if failReason := result.GetFailReason(); failReason != "" {
+		return nil, fmt.Errorf("dag plugin [%s] error: %s", p.name, failReason)
+	}
+
+	fields := p.fields
+	if len(fields) == 0 {
+		fields = make([]string, 0, len(result.Fields))
+		for field := range result.Fields {
+			fields = append(fields, field)
+		}
+	}
+	info, hasInfo := supplier.GetCallInfo(ctx)
+	output := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		fieldResult, ok := result.Fields[field]
+		if !ok {
+			continue
+		}
+		if fieldResult.IsSupplySuccess() {
+			output[field] = fieldResult.Value
+		}
+		if hasInfo {
+			info.SetOutputMeta(field, supplier.OutputMeta{
+				FailReason: fieldResult.Meta.GetFailReason(),
+				Attempts:   fieldResult.Meta.GetAttempts(),
+				CacheHit:   fieldResult.Meta.IsCacheHit(),
+				Source:     fieldResult.Meta.GetSource(),
+			})
+		}
+	}
+	return output, nil
+}
+
+// Describe 参数类型为 root 中对应变量参数的类型, 指定 fields 时输出类型为内部字段的类型.
+func (p *Plugin) Describe() *supplier.PluginSchema {
+	paramTypes := map[string]supplier.ParamSchema{}
+	if root := p.root(); root != nil {
+		for _, param := range root.GetParamVariables() {
+			paramTypes[param.FieldName] = supplier.ParamSchema{Type: param.ValueType}
+		}
+	}
+	schema := &supplier.PluginSchema{
+		Name:   p.name,
+		Params: make([]supplier.ParamSchema, len(p.params)),
+	}
+	for i, name := range p.params {
+		schema.Params[i] = paramTypes[name]
+		schema.Params[i].Name = name
+		schema.Params[i].Required = true
+	}
+	for _, name := range p.fields {
+		output := supplier.OutputSchema{Name: name}
+		if field, err := p.dag.GetField(context.Background(), name); err == nil {
+			output.Type = field.FieldType
+		}
+		schema.Outputs = append(schema.Outputs, output)
+	}
+	return schema
+}
@@ -3,21 +3,48 @@ package dag
 import "git.in.zhihu.com/antispam/datasupply/node"
 
 type preComputeData struct {
-	allNodeCnt      int32 // 全部待补数字段数量
-	stageNodeCntMap map[node.SupplyStage]int32
-	stageNodeIDMap  map[node.SupplyStage][]string
-	field2FieldMap  map[string]*node.Field
-	field2NodeMap   map[string]node.INode // fieldCode:node
+	entries        map[string]*entryData // key: 入口名称
+	field2FieldMap map[string]*node.Field
+	field2NodeMap  map[string]node.INode // fieldCode:node
 }
 
-func preCompute(root node.INode) preComputeData {
+func preCompute(roots map[string]node.INode) preComputeData {
+	entries := make(map[string]*entryData, len(roots))
+	allNodeMap := map[string]node.INode{}
+	for _, name := range sortedEntries(roots) {
+		nodes := entryNodes(roots[name])
+		entries[name] = preComputeEntry(roots[name], nodes)
+		for _, cnode := range nodes {
+			allNodeMap[cnode.GetID()] = cnode
+		}
+	}
 
-	allNodes := append(root.Prune(), root)
+	field2NodeMap := make(map[string]node.INode, len(allNodeMap))
+	field2FieldMap := make(map[string]*node.Field, len(allNodeMap))
+	{
+		for _, cnode := range allNodeMap {
+			for _, field := range cnode.GetFields() {
+				field2NodeMap[field.Code] = cnode
+				field2FieldMap[field.Code] = field
+			}
+		}
+	}
+
+	return preComputeData{
+		entries:        entries,
+		field2NodeMap:  field2NodeMap,
+		field2FieldMap: field2FieldMap,
+	}
+}
+
+func preComputeEntry(root node.INode, allNodes []node.INode) *entryData {
 	allNodeCnt := len(allNodes)
 
+	nodeIDs := make(map[string]struct{}, allNodeCnt)
 	stageNodeIDMap := make(map[node.SupplyStage][]string, len(node.SupplyStageNames))
 	{
 		for _, cnode := range allNodes {
+			nodeIDs[cnode.GetID()] = struct{}{}
 			nodeids, ok := stageNodeIDMap[cnode.GetSupplyStage()]
 			if !ok {
 				stageNodeIDMap[cnode.GetSupplyStage()] = []string{cnode.GetID()}
@@ -34,22 +61,11 @@ func preCompute(root node.INode) preComputeData {
 		}
 	}
 
-	field2NodeMap := make(map[string]node.INode, len(allNodes))
-	field2FieldMap := make(map[string]*node.Field, len(allNodes))
-	{
-		for i, cnode := range allNodes {
-			for _, field := range cnode.GetFields() {
-				field2NodeMap[field.Code] = allNodes[i]
-				field2FieldMap[field.Code] = field
-			}
-		}
-	}
-
-	return preComputeData{
+	return &entryData{
+		root:            root,
+		nodeIDs:         nodeIDs,
 		allNodeCnt:      int32(allNodeCnt),
 		stageNodeCntMap: stageNodeCntMap,
 		stageNodeIDMap:  stageNodeIDMap,
-		field2NodeMap:   field2NodeMap,
-		field2FieldMap:  field2FieldMap,
 	}
 }
@@ -10,7 +10,9 @@ import (
 // dag 对外输出的 Result
 //go:generate msgp
 type Result struct {
-	Fields map[string]*node.FieldResult `json:"fields"`
+	Fields       map[string]*node.FieldResult `json:"fields"`
+	ShedFieldCnt int                          `json:"shed_field_cnt"` // 因限流被丢弃的字段数量, 包含不导出的字段
+	FailReason   string                       `json:"fail_reason"`    // 整体运行失败的原因(如入口不存在), 为空时表示正常运行
 }
 
 func NewResult() *Result {
@@ -44,6 +46,20 @@ func (result *Result) GetFieldValue(fieldName string) (interface{}, error) {
 	return field.Value, nil
 }
 
+func (result *Result) GetShedFieldCnt() int {
+	if result == nil {
+		return 0
+	}
+	return result.ShedFieldCnt
+}
+
+func (result *Result) GetFailReason() string {
+	if result == nil {
+		return ""
+	}
+	return result.FailReason
+}
+
 func (result *Result) GetFieldValues() map[string]interface{} {
 	if result == nil || result.Fields == nil {
 		return map[string]interface{}{}
@@ -68,6 +84,8 @@ func (result *Result) Clone() *Result {
 		fields[field] = value.Clone()
 	}
 	return &Result{
-		Fields: fields,
+		Fields:       fields,
+		ShedFieldCnt: result.ShedFieldCnt,
+		FailReason:   result.FailReason,
 	}
 }
@@ -67,6 +67,18 @@ func (z *Result) DecodeMsg(dc *msgp.Reader) (err error) {
 				}
 				z.Fields[za0001] = za0002
 			}
+		case "ShedFieldCnt":
+			z.ShedFieldCnt, err = dc.ReadInt()
+			if err != nil {
+				err = msgp.WrapError(err, "ShedFieldCnt")
+				return
+			}
+		case "FailReason":
+			z.FailReason, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "FailReason")
+				return
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -80,9 +92,9 @@ func (z *Result) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *Result) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 1
+	// map header, size 3
 	// write "Fields"
-	err = en.Append(0x81, 0xa6, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73)
+	err = en.Append(0x83, 0xa6, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73)
 	if err != nil {
 		return
 	}
@@ -110,15 +122,35 @@ func (z *Result) EncodeMsg(en *msgp.Writer) (err error) {
 			}
 		}
 	}
+	// write "ShedFieldCnt"
+	err = en.Append(0xac, 0x53, 0x68, 0x65, 0x64, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x43, 0x6e, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt(z.ShedFieldCnt)
+	if err != nil {
+		err = msgp.WrapError(err, "ShedFieldCnt")
+		return
+	}
+	// write "FailReason"
+	err = en.Append(0xaa, 0x46, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.FailReason)
+	if err != nil {
+		err = msgp.WrapError(err, "FailReason")
+		return
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *Result) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 1
+	// map header, size 3
 	// string "Fields"
-	o = append(o, 0x81, 0xa6, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73)
+	o = append(o, 0x83, 0xa6, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73)
 	o = msgp.AppendMapHeader(o, uint32(len(z.Fields)))
 	for za0001, za0002 := range z.Fields {
 		o = msgp.AppendString(o, za0001)
@@ -132,6 +164,12 @@ func (z *Result) MarshalMsg(b []byte) (o []byte, err error) {
 			}
 		}
 	}
+	// string "ShedFieldCnt"
+	o = append(o, 0xac, 0x53, 0x68, 0x65, 0x64, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x43, 0x6e, 0x74)
+	o = msgp.AppendInt(o, z.ShedFieldCnt)
+	// string "FailReason"
+	o = append(o, 0xaa, 0x46, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e)
+	o = msgp.AppendString(o, z.FailReason)
 	return
 }
 
@@ -194,6 +232,18 @@ func (z *Result) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				}
 				z.Fields[za0001] = za0002
 			}
+		case "ShedFieldCnt":
+			z.ShedFieldCnt, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ShedFieldCnt")
+				return
+			}
+		case "FailReason":
+			z.FailReason, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "FailReason")
+				return
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -220,5 +270,6 @@ func (z *Result) Msgsize() (s int) {
 			}
 		}
 	}
+	s += 13 + msgp.IntSize + 11 + msgp.StringPrefixSize + len(z.FailReason)
 	return
 }
@@ -3,6 +3,7 @@ package dag
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 
 	"git.in.zhihu.com/antispam/datasupply/log"
 	"git.in.zhihu.com/antispam/datasupply/node"
@@ -35,6 +36,9 @@ type runtime struct {
 	resultKeeper IResultKeeper    // 补数数据管理
 
 	nodeResultMonitors []func(node.INode, node.Result)
+	shedFieldCnt       int32    // 因限流被丢弃的字段数量
+	fieldValues        sync.Map // 已补数成功的字段值(包括不导出字段), 供可选参数按需取值
+	failReason         string   // 整体运行失败的原因, 如入口不存在
 
 	finishLocker   sync.Locker
 	supplyFinished bool
@@ -44,11 +48,14 @@ var _ IRuntime = new(runtime)
 
 // Run 对所有字段进行补数, 直到全部字段补充完毕才会返回. 正常退出指 runtime 把所有字段补完.
 func (rt *runtime) Run(ctx context.Context, paramMap map[string]interface{}) IRuntime {
-	rootRuntime := rt.root.CreateRuntime()
-	for fieldCode, fieldValue := range paramMap {
-		rootRuntime.AddParam(fieldCode, fieldValue)
+	// 入口不存在时 root 为空, 没有需要执行的节点
+	if rt.root != nil {
+		rootRuntime := rt.root.CreateRuntime()
+		for fieldCode, fieldValue := range paramMap {
+			rootRuntime.AddParam(fieldCode, fieldValue)
+		}
+		rt.nsKeeper.Push(rootRuntime, rt.root.GetPriority())
 	}
-	rt.nsKeeper.Push(rootRuntime, rt.root.GetPriority())
 	utils.SafelyGo(
 		func() {
 			rt.run(ctx)
@@ -69,6 +76,7 @@ func (rt *runtime) run(ctx context.Context) {
 
 	ctx, cancel := context.WithTimeout(ctx, DAGTimtout)
 	defer cancel()
+	ctx = node.WithParamResolver(ctx, rt.resolveField)
 	errGroup := errgroup.Group{}
 	errGroup.SetLimit(rt.concurrent)
 	for ; rt.allNodeCnt > 0; rt.allNodeCnt-- {
@@ -101,6 +109,15 @@ func (rt *runtime) run(ctx context.Context) {
 						rt.nsKeeper.Detection(cnodeRuntime.GetNode(), nodeResult)
 					}
 
+					// 统计被限流的字段, 记录成功的字段值, 需要在移除不导出字段之前
+					for fieldCode, fieldResult := range nodeResult {
+						if fieldResult.Meta.GetFailReason() == node.FieldFailReson_RateLimited {
+							atomic.AddInt32(&rt.shedFieldCnt, 1)
+						}
+						if fieldResult.IsSupplySuccess() {
+							rt.fieldValues.Store(fieldCode, fieldResult.Value)
+						}
+					}
 					// 检查是否导出字段
 					for _, field := range cnodeRuntime.GetNode().GetFields() {
 						if field.NotExport {
@@ -146,7 +163,15 @@ func (rt *runtime) WaitStage(ctx context.Context, stageName node.SupplyStage) IR
 }
 
 func (rt *runtime) GetResultCopy() *Result {
-	return rt.resultKeeper.Read()
+	result := rt.resultKeeper.Read()
+	result.ShedFieldCnt = int(atomic.LoadInt32(&rt.shedFieldCnt))
+	result.FailReason = rt.failReason
+	return result
+}
+
+// 返回已经补数成功的字段值, 不等待未完成的节点.
+func (rt *runtime) resolveField(fieldCode string) (interface{}, bool) {
+	return rt.fieldValues.Load(fieldCode)
 }
 
 func (rt *runtime) AddNodeResultMonitor(fn func(node.INode, node.Result)) {
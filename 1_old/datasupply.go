@@ -10,15 +10,17 @@ import (
 
 type IDatasupply interface {
 	BuildRoot(cfg *NodeConfig, options ...node.Option) (node.INode, error)
+	BuildEntry(entry string, cfg *NodeConfig, options ...node.Option) (node.INode, error)
 	BuildNode(cfg *NodeConfig, options ...node.Option) (node.INode, error)
 	BuildDAG(cfg *DAGConfig, options ...dag.Option) (dag.IDAG, error)
 	GetDAG() dag.IDAG
 }
 
 type Datasupply struct {
-	root  node.INode
-	nodes []node.INode
-	dag   dag.IDAG
+	root    node.INode
+	entries map[string]node.INode
+	nodes   []node.INode
+	dag     dag.IDAG
 }
 
 var _ IDatasupply = new(Datasupply)
@@ -29,20 +31,35 @@ func New() *Datasupply {
 
 func (ds *Datasupply) BuildRoot(cfg *NodeConfig, options ...node.Option) (node.INode, error) {
 	var err error
-	ds.root, err = ds.buildNode(cfg, options...)
+	ds.root, err = buildNode(cfg, options...)
 	return ds.root, err
 }
 
+// BuildEntry 构建命名入口的 root, 一个 DAG 可以有多个入口, 入口之间共享节点. BuildRoot 构建的 root 为 dag.DefaultEntry 入口.
+func (ds *Datasupply) BuildEntry(entry string, cfg *NodeConfig, options ...node.Option) (node.INode, error) {
+	root, err := buildNode(cfg, options...)
+	if err != nil {
+		return nil, err
+	}
+	if ds.entries == nil {
+		ds.entries = map[string]node.INode{}
+	}
+	ds.entries[entry] = root
+	return root, nil
+}
+
 type NodeConfig struct {
 	Supplier supplier.ISupplier
 	FuncName string       `json:"func_name"`
 	Params   []node.Param `json:"params"`
 	Fields   []*node.Field
+	When     *node.WhenCondition `json:"when"`
+	Map      *node.MapPolicy     `json:"map"`
 	Logger   log.ILog
 }
 
 func (ds *Datasupply) BuildNode(cfg *NodeConfig, options ...node.Option) (node.INode, error) {
-	newNode, err := ds.buildNode(cfg, options...)
+	newNode, err := buildNode(cfg, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -50,12 +67,14 @@ func (ds *Datasupply) BuildNode(cfg *NodeConfig, options ...node.Option) (node.I
 	return newNode, nil
 }
 
-func (ds *Datasupply) buildNode(cfg *NodeConfig, options ...node.Option) (node.INode, error) {
+func buildNode(cfg *NodeConfig, options ...node.Option) (node.INode, error) {
 	request := &node.CreateNodeRequest{
 		FuncName: cfg.FuncName,
 		Params:   cfg.Params,
 		Fields:   cfg.Fields,
 		Supplier: cfg.Supplier,
+		When:     cfg.When,
+		Map:      cfg.Map,
 		Logger:   cfg.Logger,
 	}
 	newNode, err := node.New(request, options...)
@@ -75,6 +94,7 @@ type DAGConfig struct {
 func (ds *Datasupply) BuildDAG(cfg *DAGConfig, options ...dag.Option) (dag.IDAG, error) {
 	dag, err := dag.New(&dag.CreateDAGRequest{
 		Root:           ds.root,
+		Entries:        ds.entries,
 		Nodes:          ds.nodes,
 		ID:             cfg.ID,
 		NodeConcurrent: cfg.NodeConcurrent,
@@ -2,8 +2,13 @@ package datasupply
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"git.in.zhihu.com/antispam/datasupply/dag"
 	"git.in.zhihu.com/antispam/datasupply/dtype"
 	"git.in.zhihu.com/antispam/datasupply/node"
 	"git.in.zhihu.com/antispam/datasupply/supplier"
@@ -100,6 +105,401 @@ func TestDAG(t *testing.T) {
 	assert.NoError(t, errGroup.Wait())
 }
 
+func TestDAGShedFieldCnt(t *testing.T) {
+	ds := New()
+
+	limitedSupplier := supplier.NewDefaultSupplier("supplier_limited", []supplier.IPlugin{},
+		supplier.SetRateLimit(&supplier.RateLimitPolicy{QPS: 0.001, Mode: supplier.RateLimitShed}, "limited_func"))
+	limitedSupplier.RegisterPlugin(tests.NewTestPlugin("root_func", []string{"root_in"}, []string{"root_out"}))
+	limitedSupplier.RegisterPlugin(tests.NewTestPlugin("limited_func", []string{"root_out"},
+		[]string{"limited_out_1", "limited_out_2"}))
+	_, err := ds.BuildRoot(genNodeCfg("root_func", []string{"root_in"}, []string{"root_out"}, limitedSupplier)[0])
+	assert.NoError(t, err)
+	for _, cfg := range genNodeCfg("limited_func", []string{"root_out"},
+		[]string{"limited_out_1", "limited_out_2"}, limitedSupplier) {
+		_, err := ds.BuildNode(cfg)
+		assert.NoError(t, err)
+	}
+	dag, err := ds.BuildDAG(&DAGConfig{ID: "tests_shed"})
+	assert.NoError(t, err)
+
+	// 第一次消耗掉唯一的令牌, 第二次被限流
+	for _, expectShed := range []int{0, 2} {
+		result := dag.Supply(context.TODO(), "test", map[string]interface{}{"root_in": "x"})
+		assert.Equal(t, expectShed, result.GetShedFieldCnt())
+	}
+	meta, err := dag.Supply(context.TODO(), "test", map[string]interface{}{"root_in": "x"}).
+		GetFieldMeta("limited_out_1")
+	assert.NoError(t, err)
+	assert.Equal(t, node.FieldFailReson_RateLimited, meta.GetFailReason())
+}
+
+// root -> guarded(when) -> after, after_prune
+// guarded 被跳过时, after 正常执行, after_prune 按照 on_fail_reason 剪枝.
+func TestDAGWhen(t *testing.T) {
+	for _, tcase := range []struct {
+		flag         string
+		expectReason map[string]string
+	}{
+		{"x", map[string]string{"guarded_out": "", "after_out": "", "after_prune_out": ""}},
+		{"y", map[string]string{
+			"guarded_out":     node.FieldFailReson_Skipped,
+			"after_out":       "",
+			"after_prune_out": "prune",
+		}},
+	} {
+		ds := New()
+		whenSupplier := supplier.NewDefaultSupplier("supplier_when", []supplier.IPlugin{
+			tests.NewTestPlugin("root_func", []string{"root_in"}, []string{"root_out"}),
+			tests.NewTestPlugin("guarded_func", []string{}, []string{"guarded_out"}),
+			tests.NewTestPlugin("after_func", []string{"guarded_out"}, []string{"after_out"}),
+			tests.NewTestPlugin("after_prune_func", []string{"guarded_out"}, []string{"after_prune_out"}),
+		})
+		_, err := ds.BuildRoot(genNodeCfg("root_func", []string{"root_in"}, []string{"root_out"}, whenSupplier)[0])
+		assert.NoError(t, err)
+
+		guarded := genNodeCfg("guarded_func", []string{}, []string{"guarded_out"}, whenSupplier)[0]
+		guarded.When = &node.WhenCondition{
+			Expr: "flag == '" + tcase.flag + "'",
+			Vars: []*node.CreateVarParamRequest{
+				{ParamName: "flag", DagFieldName: "root_out", ParamType: dtype.String},
+			},
+		}
+		afterPrune := genNodeCfg("after_prune_func", []string{"guarded_out"}, []string{"after_prune_out"}, whenSupplier)[0]
+		afterPrune.Params[0].OnFailReason = map[string]node.ParamOnErrorHandler{
+			node.FieldFailReson_Skipped: node.ParamOnErrorPrune,
+		}
+		cfgs := []*NodeConfig{
+			guarded,
+			genNodeCfg("after_func", []string{"guarded_out"}, []string{"after_out"}, whenSupplier)[0],
+			afterPrune,
+		}
+		for _, cfg := range cfgs {
+			_, err := ds.BuildNode(cfg)
+			assert.NoError(t, err)
+		}
+		dag, err := ds.BuildDAG(&DAGConfig{ID: "tests_when"})
+		assert.NoError(t, err)
+
+		result := dag.Supply(context.TODO(), "test", map[string]interface{}{"root_in": "x"})
+		for field, expectReason := range tcase.expectReason {
+			meta, err := result.GetFieldMeta(field)
+			assert.NoError(t, err, field)
+			assert.Equal(t, expectReason, meta.GetFailReason(), field)
+		}
+	}
+}
+
+// 外层 dag 的 risk 节点通过 dag.Plugin 调用内层 dag: inner_root -> risk_score, broken_out, slow_out
+func TestDAGPlugin(t *testing.T) {
+	var canceled int32
+	innerSupplier := supplier.NewDefaultSupplier("supplier_inner", []supplier.IPlugin{
+		tests.NewTestPlugin("inner_root_func", []string{"uid"}, []string{"uid_out"}),
+		tests.NewTestPlugin("risk_func", []string{"uid_out"}, []string{"risk_score"}),
+		supplier.NewDefaultPlugin("broken_func", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			return nil, errors.New("broken")
+		}),
+		supplier.NewDefaultPlugin("slow_func", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			select {
+			case <-ctx.Done():
+				atomic.StoreInt32(&canceled, 1)
+				return nil, ctx.Err()
+			case <-time.After(200 * time.Millisecond):
+				return map[string]interface{}{"slow_out": "x"}, nil
+			}
+		}),
+	}, supplier.SetCachePolicy(nil, "risk_func"))
+	inner := New()
+	_, err := inner.BuildRoot(genNodeCfg("inner_root_func", []string{"uid"}, []string{"uid_out"}, innerSupplier)[0])
+	assert.NoError(t, err)
+	for _, cfg := range [][]*NodeConfig{
+		genNodeCfg("risk_func", []string{"uid_out"}, []string{"risk_score"}, innerSupplier),
+		genNodeCfg("broken_func", []string{"uid_out"}, []string{"broken_out"}, innerSupplier),
+		genNodeCfg("slow_func", []string{"uid_out"}, []string{"slow_out"}, innerSupplier),
+	} {
+		_, err := inner.BuildNode(cfg[0])
+		assert.NoError(t, err)
+	}
+	innerDAG, err := inner.BuildDAG(&DAGConfig{ID: "tests_inner", NodeConcurrent: 3})
+	assert.NoError(t, err)
+
+	outerSupplier := supplier.NewDefaultSupplier("supplier_outer", []supplier.IPlugin{
+		tests.NewTestPlugin("root_func", []string{"root_in"}, []string{"root_out"}),
+		dag.NewPlugin("risk_profile", innerDAG, nil, []string{"risk_score", "broken_out"}),
+		dag.NewPlugin("slow_profile", innerDAG, []string{"uid"}, []string{"slow_out"}),
+	})
+	outer := New()
+	_, err = outer.BuildRoot(genNodeCfg("root_func", []string{"root_in"}, []string{"root_out"}, outerSupplier)[0])
+	assert.NoError(t, err)
+	risk := genNodeCfg("risk_profile", []string{"root_out"}, []string{"risk_score", "broken_out"}, outerSupplier)
+	risk[0].Fields = append(risk[0].Fields, risk[1].Fields...)
+	slow := genNodeCfg("slow_profile", []string{"root_out"}, []string{"slow_out"}, outerSupplier)[0]
+	slow.Fields[0].Timeout = 20 * time.Millisecond
+	for _, cfg := range []*NodeConfig{risk[0], slow} {
+		_, err := outer.BuildNode(cfg)
+		assert.NoError(t, err)
+	}
+	outerDAG, err := outer.BuildDAG(&DAGConfig{ID: "tests_outer", NodeConcurrent: 2})
+	assert.NoError(t, err)
+
+	result := outerDAG.Supply(context.TODO(), "test", map[string]interface{}{"root_in": "x"})
+	value, err := result.GetFieldValue("risk_score")
+	assert.NoError(t, err)
+	assert.Equal(t, "x", value)
+	meta, err := result.GetFieldMeta("broken_out")
+	assert.NoError(t, err)
+	assert.Equal(t, "supplier_error: broken", meta.GetFailReason())
+	// 使用内部字段的调用信息, 第二次调用时内部字段命中缓存
+	result = outerDAG.Supply(context.TODO(), "test", map[string]interface{}{"root_in": "x"})
+	meta, err = result.GetFieldMeta("risk_score")
+	assert.NoError(t, err)
+	assert.True(t, meta.IsCacheHit())
+	assert.Equal(t, 1, meta.GetAttempts())
+
+	// 插件返回字段值, 字段的元数据通过 CallInfo 返回
+	ctx, info := supplier.WithCallInfo(context.TODO())
+	out, err := dag.NewPlugin("risk_profile", innerDAG, nil, []string{"risk_score", "broken_out"}).Call(ctx, "x")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"risk_score": "x"}, out)
+	assert.Equal(t, "supplier_error: broken", info.GetOutputMetas()["broken_out"].FailReason)
+	meta, err = result.GetFieldMeta("slow_out")
+	assert.NoError(t, err)
+	assert.Equal(t, "timeout", meta.GetFailReason())
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&canceled) == 1 }, time.Second, 5*time.Millisecond)
+
+	// 参数类型和输出字段按照内层 dag 校验
+	_, err = New().BuildNode(genNodeCfg("risk_profile", []string{"root_out"}, []string{"unknown"}, outerSupplier)[0])
+	assert.Error(t, err)
+}
+
+// root -> region(fallback: backup_out), root -> backup
+// 备用数据源的参数不是节点的依赖: backup 失败时 region 不会被剪枝, region 失败时按需取 backup_out.
+func TestDAGFallback(t *testing.T) {
+	fallbackSupplier := supplier.NewDefaultSupplier("supplier_fallback", []supplier.IPlugin{
+		supplier.NewDefaultPlugin("root_func", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"root_out": args[0]}, nil
+		}),
+		supplier.NewDefaultPlugin("region_func", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			if args[0] == "fail" {
+				time.Sleep(20 * time.Millisecond)
+				return nil, errors.New("primary error")
+			}
+			return map[string]interface{}{"region": "primary"}, nil
+		}),
+		supplier.NewDefaultPlugin("backup_func", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			if args[0] == "broken" {
+				return nil, errors.New("broken")
+			}
+			return map[string]interface{}{"backup_out": "backup"}, nil
+		}),
+		supplier.NewDefaultPlugin("cache_func", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"region": "cache_" + args[0].(string)}, nil
+		}),
+	})
+	ds := New()
+	_, err := ds.BuildRoot(genNodeCfg("root_func", []string{"root_in"}, []string{"root_out"}, fallbackSupplier)[0])
+	assert.NoError(t, err)
+	backup := genNodeCfg("backup_func", []string{"root_out"}, []string{"backup_out"}, fallbackSupplier)[0]
+	region := genNodeCfg("region_func", []string{"root_out"}, []string{"region"}, fallbackSupplier)[0]
+	region.Fields[0].Fallbacks = []*node.FieldSource{{Supplier: fallbackSupplier, FuncName: "cache_func",
+		Params: genNodeCfg("cache_func", []string{"backup_out"}, []string{"region"}, fallbackSupplier)[0].Params}}
+	for _, cfg := range []*NodeConfig{backup, region} {
+		_, err := ds.BuildNode(cfg)
+		assert.NoError(t, err)
+	}
+	d, err := ds.BuildDAG(&DAGConfig{ID: "tests_fallback", NodeConcurrent: 2})
+	assert.NoError(t, err)
+
+	for _, tcase := range []struct {
+		rootIn       string
+		expectRegion interface{}
+	}{
+		{"broken", "primary"},
+		{"fail", "cache_backup"},
+	} {
+		result := d.Supply(context.TODO(), "test", map[string]interface{}{"root_in": tcase.rootIn})
+		value, err := result.GetFieldValue("region")
+		assert.NoError(t, err, tcase.rootIn)
+		assert.Equal(t, tcase.expectRegion, value, tcase.rootIn)
+	}
+}
+
+var testTypeSeq int64
+
+// 类型注册是全局的, 测试使用每次运行唯一的名称, 保证 -count=N 时可以重复运行.
+func testTypeName(name string) string {
+	return fmt.Sprintf("%s_%d", name, atomic.AddInt64(&testTypeSeq, 1))
+}
+
+func TestDAGObjectField(t *testing.T) {
+	profileType := dtype.MustRegisterObject(testTypeName("tests_profile"), &dtype.ObjectSchema{Fields: []dtype.ObjectField{
+		{Name: "level", Type: dtype.Int64, Required: true},
+	}})
+	userType := dtype.MustRegisterObject(testTypeName("tests_user"), &dtype.ObjectSchema{Fields: []dtype.ObjectField{
+		{Name: "name", Type: dtype.String},
+		{Name: "profile", Type: profileType},
+	}})
+	var levels []interface{}
+	sup := supplier.NewDefaultSupplier("supplier_object", []supplier.IPlugin{
+		supplier.NewDefaultPlugin("root_func", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"uid_out": args[0]}, nil
+		}),
+		supplier.NewDefaultPlugin("user_func", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			if args[0] == "bad" {
+				return map[string]interface{}{"user": map[string]interface{}{"profile": map[string]interface{}{}}}, nil
+			}
+			return map[string]interface{}{"user": `{"name":"n","profile":{"level":"3"}}`}, nil
+		}),
+		supplier.NewDefaultPlugin("level_func", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			levels = append(levels, args[0])
+			return map[string]interface{}{"level_out": args[0]}, nil
+		}),
+	})
+
+	ds := New()
+	_, err := ds.BuildRoot(genNodeCfg("root_func", []string{"uid"}, []string{"uid_out"}, sup)[0])
+	assert.NoError(t, err)
+	user := genNodeCfg("user_func", []string{"uid_out"}, []string{"user"}, sup)[0]
+	user.Fields[0].FieldType = userType
+	_, err = ds.BuildNode(user)
+	assert.NoError(t, err)
+	param, err := node.NewVariableParam(&node.CreateVarParamRequest{
+		ParamName:    "level",
+		DagFieldName: "user",
+		SubPath:      "profile.level",
+		ParamType:    dtype.Int64,
+	})
+	assert.NoError(t, err)
+	level := genNodeCfg("level_func", nil, []string{"level_out"}, sup)[0]
+	level.Params = []node.Param{*param}
+	level.Fields[0].FieldType = dtype.Int64
+	_, err = ds.BuildNode(level)
+	assert.NoError(t, err)
+	d, err := ds.BuildDAG(&DAGConfig{ID: "tests_object"})
+	assert.NoError(t, err)
+
+	result := d.Supply(context.TODO(), "test", map[string]interface{}{"uid": "1"})
+	value, err := result.GetFieldValue("user")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "n", "profile": map[string]interface{}{"level": int64(3)}}, value)
+	value, err = result.GetFieldValue("level_out")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), value)
+	assert.Equal(t, []interface{}{int64(3)}, levels)
+
+	// 不符合 schema 时字段失败, 下游按照参数的 OnError 剪枝
+	result = d.Supply(context.TODO(), "test", map[string]interface{}{"uid": "bad"})
+	meta, err := result.GetFieldMeta("user")
+	assert.NoError(t, err)
+	assert.Equal(t, node.FieldFailReson_SchemaViolation, meta.GetFailReason())
+	assert.Len(t, levels, 1)
+
+	// 子字段路径按照上游字段的 schema 校验
+	param, err = node.NewVariableParam(&node.CreateVarParamRequest{
+		ParamName:    "level",
+		DagFieldName: "user",
+		SubPath:      "profile.unknown",
+		ParamType:    dtype.Int64,
+	})
+	assert.NoError(t, err)
+	ds = New()
+	_, err = ds.BuildRoot(genNodeCfg("root_func", []string{"uid"}, []string{"uid_out"}, sup)[0])
+	assert.NoError(t, err)
+	_, err = ds.BuildNode(user)
+	assert.NoError(t, err)
+	level = genNodeCfg("level_func", nil, []string{"level_out"}, sup)[0]
+	level.Params = []node.Param{*param}
+	_, err = ds.BuildNode(level)
+	assert.NoError(t, err)
+	_, err = ds.BuildDAG(&DAGConfig{ID: "tests_object_invalid"})
+	assert.Error(t, err)
+}
+
+// comment: comment_in -> content, uid; login: login_in -> uid, ip
+// user_level(uid) 两个入口共享, text_label(content) 和 ip_geo(ip) 只属于各自的入口, mix(content, ip) 不属于任何入口.
+func TestDAGEntries(t *testing.T) {
+	ds := New()
+	entrySupplier := supplier.NewDefaultSupplier("supplier_entries", []supplier.IPlugin{
+		tests.NewTestPlugin("comment_root", []string{"comment_in"}, []string{"content", "uid"}),
+		tests.NewTestPlugin("login_root", []string{"login_in"}, []string{"uid", "ip"}),
+		tests.NewTestPlugin("user_func", []string{"uid"}, []string{"user_level"}),
+		tests.NewTestPlugin("text_func", []string{"content"}, []string{"text_label"}),
+		tests.NewTestPlugin("ip_func", []string{"ip"}, []string{"ip_geo"}),
+		tests.NewTestPlugin("mix_func", []string{"content", "ip"}, []string{"mix"}),
+	})
+	for entry, root := range map[string][]*NodeConfig{
+		"comment": genNodeCfg("comment_root", []string{"comment_in"}, []string{"content", "uid"}, entrySupplier),
+		"login":   genNodeCfg("login_root", []string{"login_in"}, []string{"uid", "ip"}, entrySupplier),
+	} {
+		root[0].Fields = append(root[0].Fields, root[1].Fields...)
+		_, err := ds.BuildEntry(entry, root[0])
+		assert.NoError(t, err)
+	}
+	for _, cfg := range [][]*NodeConfig{
+		genNodeCfg("user_func", []string{"uid"}, []string{"user_level"}, entrySupplier),
+		genNodeCfg("text_func", []string{"content"}, []string{"text_label"}, entrySupplier),
+		genNodeCfg("ip_func", []string{"ip"}, []string{"ip_geo"}, entrySupplier),
+		genNodeCfg("mix_func", []string{"content", "ip"}, []string{"mix"}, entrySupplier),
+	} {
+		_, err := ds.BuildNode(cfg[0])
+		assert.NoError(t, err)
+	}
+	entryDAG, err := ds.BuildDAG(&DAGConfig{ID: "tests_entries", NodeConcurrent: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"comment", "login"}, entryDAG.GetEntries())
+
+	testCases := []struct {
+		entry  string
+		params map[string]interface{}
+		fields []string
+	}{
+		{"comment", map[string]interface{}{"comment_in": "x"}, []string{"content", "uid", "user_level", "text_label"}},
+		{"login", map[string]interface{}{"login_in": "x"}, []string{"uid", "ip", "user_level", "ip_geo"}},
+		{"unknown", map[string]interface{}{"comment_in": "x"}, []string{}},
+	}
+	for _, tcase := range testCases {
+		result := entryDAG.SupplyEntry(context.TODO(), tcase.entry, "test", tcase.params)
+		assert.Len(t, result.Fields, len(tcase.fields), tcase.entry)
+		if tcase.entry == "unknown" {
+			assert.Equal(t, dag.FailReasonEntryNotFound+": unknown", result.GetFailReason())
+		} else {
+			assert.Equal(t, "", result.GetFailReason(), tcase.entry)
+		}
+		for _, field := range tcase.fields {
+			value, err := result.GetFieldValue(field)
+			assert.NoError(t, err, field)
+			assert.Equal(t, "x", value, field)
+		}
+	}
+
+	// 没有 default 入口时, Supply 使用名称最小的入口
+	result := entryDAG.Supply(context.TODO(), "test", map[string]interface{}{"comment_in": "x"})
+	assert.Len(t, result.Fields, 4)
+
+	// 外部 DAG 的入口不会传递给插件内部的 DAG, 内部 DAG 使用插件指定的入口
+	outerSupplier := supplier.NewDefaultSupplier("supplier_entries_outer", []supplier.IPlugin{
+		tests.NewTestPlugin("review_root", []string{"review_in"}, []string{"review_out"}),
+		dag.NewEntryPlugin("login_profile", entryDAG, "login", nil, []string{"ip_geo"}),
+		dag.NewEntryPlugin("unknown_profile", entryDAG, "unknown", []string{"login_in"}, []string{"ip_geo"}),
+	})
+	outer := New()
+	_, err = outer.BuildEntry("review", genNodeCfg("review_root", []string{"review_in"}, []string{"review_out"},
+		outerSupplier)[0])
+	assert.NoError(t, err)
+	_, err = outer.BuildNode(genNodeCfg("login_profile", []string{"review_out"}, []string{"ip_geo"}, outerSupplier)[0])
+	assert.NoError(t, err)
+	outerDAG, err := outer.BuildDAG(&DAGConfig{ID: "tests_entries_outer"})
+	assert.NoError(t, err)
+	result = outerDAG.SupplyEntry(context.TODO(), "review", "test", map[string]interface{}{"review_in": "x"})
+	value, err := result.GetFieldValue("ip_geo")
+	assert.NoError(t, err)
+	assert.Equal(t, "x", value)
+
+	// 插件指定的入口不存在时返回错误
+	_, err = outerSupplier.Supply(context.TODO(), "unknown_profile", []interface{}{"x"})
+	assert.ErrorContains(t, err, dag.FailReasonEntryNotFound)
+}
+
 func genNodeCfg(funcName string, _params, _fields []string, supplier supplier.ISupplier) []*NodeConfig {
 	params := make([]node.Param, len(_params))
 	for i, param := range _params {
@@ -14,6 +14,7 @@ import (
 // }
 
 // 将当前值转换为 dtype 类型的值. nil 会被转换为相应类型的零值.
+// 用户类型(Register)使用注册的 converter 和 validator, nil 转换为注册的零值.
 func Convert(value interface{}, dtype DType) (interface{}, error) {
 	// rtype := reflect.TypeOf(value)
 	// if rtype == nil {
@@ -24,6 +25,8 @@ func Convert(value interface{}, dtype DType) (interface{}, error) {
 		return ToString(value), nil
 	case Int64:
 		return ToInt64(value)
+	case Uint64:
+		return ToUint64(value)
 	case Float64:
 		return ToFloat64(value)
 	case Bool:
@@ -32,7 +35,8 @@ func Convert(value interface{}, dtype DType) (interface{}, error) {
 		return ToMap(value)
 	case ArrayInt64:
 		return ToInt64Array(value)
-	// case ArrayUint64:
+	case ArrayUint64:
+		return ToUint64Array(value)
 	case ArrayString:
 		return ToStringArray(value)
 	case ArrayByte:
@@ -40,6 +44,55 @@ func Convert(value interface{}, dtype DType) (interface{}, error) {
 		if ok {
 			return v, nil
 		}
+	case ArrayFloat64:
+		return ToFloat64Array(value)
+	case ArrayInt:
+		return ToIntArray(value)
+	case ArrayBytes:
+		return ToByteArray(value)
+	case Time:
+		return ToTime(value)
+	case Duration:
+		return ToDuration(value)
+	case ArrayBool:
+		return ToBoolArray(value)
+	case MapString:
+		return ToStringMap(value)
+	case ArrayMap:
+		return ToMapArray(value)
+	default:
+		if t, ok := getUserType(dtype); ok {
+			return t.convert(value, dtype)
+		}
 	}
 	return value, fmt.Errorf(fmt.Sprintf("convert %v to %s error", value, dtype.String()))
 }
+
+// 可以通过 Convert 转换为 key 类型的其他类型. 只收录对合法取值都能成功转换的类型, 字符串需要是相应格式.
+var convertibleFrom = map[DType][]DType{
+	String:       {Int64, Uint64, Float64, Bool, ArrayByte},
+	Int64:        {String, Uint64, Float64, ArrayByte},
+	Uint64:       {String, Int64, Float64, ArrayByte},
+	Float64:      {String, Int64, ArrayByte},
+	Bool:         {String, Int64, Float64, ArrayByte},
+	Map:          {String},
+	ArrayInt64:   {String},
+	ArrayString:  {String, ArrayInt64, ArrayUint64, ArrayFloat64, ArrayInt, ArrayBool},
+	ArrayFloat64: {String},
+	ArrayInt:     {String},
+	Time:         {String, Int64, Float64, ArrayByte},
+	Duration:     {String, Int64, Uint64, Float64, ArrayByte},
+}
+
+// CanConvert 判断 from 类型的值能否通过 Convert 转换为 to 类型, 用于构建时校验配置.
+func CanConvert(from, to DType) bool {
+	if from == to {
+		return true
+	}
+	for _, t := range convertibleFrom[to] {
+		if t == from {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,9 @@
 package dtype
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -111,8 +113,168 @@ func (suite *ConvertTestSuite) TestToArrayString() {
 	}
 }
 
+func (suite *ConvertTestSuite) TestToUint64() {
+	testCases := []struct {
+		name   string
+		value  interface{}
+		except uint64
+	}{
+		{"int2uint64", 100, 100},
+		{"uint642uint64", uint64(math.MaxUint64), math.MaxUint64},
+		{"string2uint64", "18446744073709551615", math.MaxUint64},
+		{"float2uint64", 102.01, 102},
+		{"nil2uint64", nil, 0},
+	}
+	for _, tcase := range testCases {
+		suite.Run(tcase.name, func() {
+			value, err := Convert(tcase.value, Uint64)
+			assert.NoError(suite.T(), err)
+			assert.Equal(suite.T(), tcase.except, value.(uint64))
+		})
+	}
+	_, err := Convert(-1, Uint64)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ConvertTestSuite) TestToArrayNumber() {
+	testCases := []struct {
+		name   string
+		value  interface{}
+		dtype  DType
+		except interface{}
+	}{
+		{"[]uint642[]uint64", []uint64{1, 2}, ArrayUint64, []uint64{1, 2}},
+		{"string2[]uint64", "[1,2]", ArrayUint64, []uint64{1, 2}},
+		{"[]interface2[]uint64", []interface{}{1, "2"}, ArrayUint64, []uint64{1, 2}},
+		{"string2[]float64", "[1.5,2]", ArrayFloat64, []float64{1.5, 2}},
+		{"[]interface2[]float64", []interface{}{1, "2.5"}, ArrayFloat64, []float64{1, 2.5}},
+		{"string2[]int", "[1,2]", ArrayInt, []int{1, 2}},
+		{"[]interface2[]int", []interface{}{int64(1), "2"}, ArrayInt, []int{1, 2}},
+		{"string2[]bool", "[true,false]", ArrayBool, []bool{true, false}},
+		{"[]interface2[]bool", []interface{}{"true", 0}, ArrayBool, []bool{true, false}},
+		{"[]interface2[][]byte", []interface{}{[]byte("a")}, ArrayBytes, [][]byte{[]byte("a")}},
+		{"[][]byte2[][]byte", [][]byte{[]byte("a")}, ArrayBytes, [][]byte{[]byte("a")}},
+	}
+	for _, tcase := range testCases {
+		suite.Run(tcase.name, func() {
+			value, err := Convert(tcase.value, tcase.dtype)
+			assert.NoError(suite.T(), err)
+			assert.Equal(suite.T(), tcase.except, value)
+		})
+	}
+}
+
+func (suite *ConvertTestSuite) TestToTime() {
+	except := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	testCases := []struct {
+		name   string
+		value  interface{}
+		except time.Time
+	}{
+		{"time2time", except, except},
+		{"rfc3339_2time", "2023-01-02T03:04:05Z", except},
+		{"rfc3339_offset2time", "2023-01-02T11:04:05+08:00", except},
+		{"unix2time", except.Unix(), except},
+		{"unix_ms2time", except.UnixNano() / int64(time.Millisecond), except},
+		{"unix_string2time", "1672628645", except},
+		{"unix_ms_float2time", float64(1672628645000), except},
+		{"nil2time", nil, time.Time{}},
+	}
+	for _, tcase := range testCases {
+		suite.Run(tcase.name, func() {
+			value, err := Convert(tcase.value, Time)
+			assert.NoError(suite.T(), err)
+			assert.True(suite.T(), tcase.except.Equal(value.(time.Time)), value)
+		})
+	}
+	_, err := Convert("2023-01-02", Time)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ConvertTestSuite) TestToDuration() {
+	testCases := []struct {
+		name   string
+		value  interface{}
+		except time.Duration
+	}{
+		{"duration2duration", time.Second, time.Second},
+		{"string2duration", "1.5s", 1500 * time.Millisecond},
+		{"int2duration", int64(time.Millisecond), time.Millisecond},
+		{"int_string2duration", "1000", time.Microsecond},
+		{"nil2duration", nil, 0},
+	}
+	for _, tcase := range testCases {
+		suite.Run(tcase.name, func() {
+			value, err := Convert(tcase.value, Duration)
+			assert.NoError(suite.T(), err)
+			assert.Equal(suite.T(), tcase.except, value.(time.Duration))
+		})
+	}
+	_, err := Convert("1x", Duration)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ConvertTestSuite) TestToMapString() {
+	testCases := []struct {
+		name   string
+		value  interface{}
+		except map[string]string
+	}{
+		{"map_string2map_string", map[string]string{"a": "1"}, map[string]string{"a": "1"}},
+		{"map2map_string", map[string]interface{}{"a": 1, "b": true}, map[string]string{"a": "1", "b": "true"}},
+		{"string2map_string", `{"a":"x","b":2}`, map[string]string{"a": "x", "b": "2"}},
+	}
+	for _, tcase := range testCases {
+		suite.Run(tcase.name, func() {
+			value, err := Convert(tcase.value, MapString)
+			assert.NoError(suite.T(), err)
+			assert.Equal(suite.T(), tcase.except, value.(map[string]string))
+		})
+	}
+}
+
+func (suite *ConvertTestSuite) TestToArrayMap() {
+	testCases := []struct {
+		name   string
+		value  interface{}
+		except []map[string]interface{}
+	}{
+		{"empty_string2[]map", "[]", []map[string]interface{}{}},
+		{"string2[]map", `[{"a":"x"}]`, []map[string]interface{}{{"a": "x"}}},
+		{"[]interface2[]map", []interface{}{map[string]interface{}{"a": 1}, `{"b":"y"}`},
+			[]map[string]interface{}{{"a": 1}, {"b": "y"}}},
+	}
+	for _, tcase := range testCases {
+		suite.Run(tcase.name, func() {
+			value, err := Convert(tcase.value, ArrayMap)
+			assert.NoError(suite.T(), err)
+			assert.Equal(suite.T(), tcase.except, value.([]map[string]interface{}))
+		})
+	}
+}
+
 func TestConvert(t *testing.T) {
 	suite.Run(t, new(ConvertTestSuite))
 }
 
+func TestCanConvert(t *testing.T) {
+	testCases := []struct {
+		name   string
+		from   DType
+		to     DType
+		expect bool
+	}{
+		{"same", Map, Map, true},
+		{"string2int64", String, Int64, true},
+		{"int642string", Int64, String, true},
+		{"int642time", Int64, Time, true},
+		{"uint642float64", Uint64, Float64, false},
+		{"map2int64", Map, Int64, false},
+		{"int642array", Int64, ArrayInt64, false},
+	}
+	for _, tcase := range testCases {
+		assert.Equal(t, tcase.expect, CanConvert(tcase.from, tcase.to), tcase.name)
+	}
+}
+
 // todo [optimize] other test
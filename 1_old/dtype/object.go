@@ -0,0 +1,159 @@
+package dtype
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ObjectField 对象类型的子字段. Type 可以是另一个对象类型, 用于描述嵌套结构.
+type ObjectField struct {
+	Name     string `json:"name"`
+	Type     DType  `json:"type"`
+	Required bool   `json:"required"` // 为 true 时子字段缺失或为 null 视为不符合 schema
+}
+
+// ObjectSchema 对象类型的子字段定义.
+type ObjectSchema struct {
+	Fields []ObjectField `json:"fields"`
+}
+
+// SchemaError 值不符合对象类型的 schema.
+type SchemaError struct {
+	Path   string // 出错的子字段路径, 以 . 分隔, 为空时表示对象本身
+	Reason string
+}
+
+func (e *SchemaError) Error() string {
+	if e.Path == "" {
+		return "schema violation: " + e.Reason
+	}
+	return fmt.Sprintf("schema violation at [%s]: %s", e.Path, e.Reason)
+}
+
+// RegisterObject 注册对象类型. Convert 按照 schema 递归校验和转换子字段, 结果为 map[string]interface{},
+// 只保留 schema 中定义的子字段, 缺失的可选子字段不会出现在结果中.
+// 值不符合 schema 时 Convert 返回的错误可以通过 errors.As 取到 *SchemaError.
+func RegisterObject(name string, schema *ObjectSchema) (DType, error) {
+	if err := schema.validate(); err != nil {
+		return 0, fmt.Errorf("dtype %s schema error: %s", name, err.Error())
+	}
+	return register(name, &userType{
+		converter: schema.convert,
+		schema:    schema,
+	})
+}
+
+// MustRegisterObject 与 RegisterObject 相同, 注册失败时 panic.
+func MustRegisterObject(name string, schema *ObjectSchema) DType {
+	dtype, err := RegisterObject(name, schema)
+	if err != nil {
+		panic(err)
+	}
+	return dtype
+}
+
+// GetObjectSchema 返回对象类型的 schema, 不是对象类型时返回 false.
+func GetObjectSchema(dtype DType) (*ObjectSchema, bool) {
+	t, ok := getUserType(dtype)
+	if !ok || t.schema == nil {
+		return nil, false
+	}
+	return t.schema, true
+}
+
+func (schema *ObjectSchema) validate() error {
+	if schema == nil || len(schema.Fields) == 0 {
+		return errors.New("fields can not be empty")
+	}
+	names := make(map[string]struct{}, len(schema.Fields))
+	for _, field := range schema.Fields {
+		if field.Name == "" || strings.Contains(field.Name, ".") {
+			return fmt.Errorf("field name [%s] invalid", field.Name)
+		}
+		if _, ok := names[field.Name]; ok {
+			return fmt.Errorf("field [%s] repeat", field.Name)
+		}
+		names[field.Name] = struct{}{}
+		if _, ok := GetDtype(field.Type.String()); !ok {
+			return fmt.Errorf("field [%s] type not defined", field.Name)
+		}
+	}
+	return nil
+}
+
+func (schema *ObjectSchema) getField(name string) (ObjectField, bool) {
+	for _, field := range schema.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return ObjectField{}, false
+}
+
+// PathType 返回子字段路径(以 . 分隔, 如 "user.level")的类型, 路径不存在时返回 false.
+func (schema *ObjectSchema) PathType(path string) (DType, bool) {
+	current := schema
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		field, ok := current.getField(name)
+		if !ok {
+			return 0, false
+		}
+		if i == len(names)-1 {
+			return field.Type, true
+		}
+		if current, ok = GetObjectSchema(field.Type); !ok {
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+func (schema *ObjectSchema) convert(value interface{}) (interface{}, error) {
+	m, err := ToMap(value)
+	if err != nil || m == nil {
+		return nil, &SchemaError{Reason: fmt.Sprintf("expect object, got %T", value)}
+	}
+	obj := make(map[string]interface{}, len(schema.Fields))
+	for _, field := range schema.Fields {
+		v, ok := m[field.Name]
+		if !ok || v == nil {
+			if field.Required {
+				return nil, &SchemaError{Path: field.Name, Reason: "required field is missing"}
+			}
+			continue
+		}
+		v, err := Convert(v, field.Type)
+		if err != nil {
+			var schemaErr *SchemaError
+			if errors.As(err, &schemaErr) {
+				return nil, &SchemaError{Path: joinPath(field.Name, schemaErr.Path), Reason: schemaErr.Reason}
+			}
+			return nil, &SchemaError{Path: field.Name, Reason: err.Error()}
+		}
+		obj[field.Name] = v
+	}
+	return obj, nil
+}
+
+// GetPath 按照子字段路径(以 . 分隔)从 Convert 后的对象值中取值, 路径不存在时返回 false.
+func GetPath(value interface{}, path string) (interface{}, bool) {
+	for _, name := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func joinPath(name, path string) string {
+	if path == "" {
+		return name
+	}
+	return name + "." + path
+}
@@ -0,0 +1,81 @@
+package dtype
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterObject(t *testing.T) {
+	levelType, err := RegisterObject(testTypeName("test_level"), &ObjectSchema{Fields: []ObjectField{
+		{Name: "level", Type: Int64, Required: true},
+		{Name: "tags", Type: ArrayString},
+	}})
+	assert.NoError(t, err)
+	userType, err := RegisterObject(testTypeName("test_user"), &ObjectSchema{Fields: []ObjectField{
+		{Name: "uid", Type: String, Required: true},
+		{Name: "profile", Type: levelType},
+	}})
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		value  interface{}
+		except interface{}
+		path   string // 为空时不是 schema 错误
+	}{
+		{"coerce", map[string]interface{}{"uid": 1, "profile": map[string]interface{}{"level": "3", "tags": []interface{}{"a"}}},
+			map[string]interface{}{"uid": "1", "profile": map[string]interface{}{"level": int64(3), "tags": []string{"a"}}}, ""},
+		{"json", `{"uid":"1","profile":{"level":3},"unknown":1}`,
+			map[string]interface{}{"uid": "1", "profile": map[string]interface{}{"level": int64(3)}}, ""},
+		{"optional_missing", map[string]interface{}{"uid": "1"}, map[string]interface{}{"uid": "1"}, ""},
+		{"nil", nil, nil, ""},
+		{"not_object", 1, nil, "-"},
+		{"required_missing", map[string]interface{}{"profile": map[string]interface{}{"level": 1}}, nil, "uid"},
+		{"nested_required", map[string]interface{}{"uid": "1", "profile": map[string]interface{}{}}, nil, "profile.level"},
+		{"nested_convert", map[string]interface{}{"uid": "1", "profile": map[string]interface{}{"level": "x"}}, nil, "profile.level"},
+	}
+	for _, tcase := range testCases {
+		value, err := Convert(tcase.value, userType)
+		if tcase.path == "" {
+			assert.NoError(t, err, tcase.name)
+			assert.Equal(t, tcase.except, value, tcase.name)
+			continue
+		}
+		var schemaErr *SchemaError
+		if assert.True(t, errors.As(err, &schemaErr), tcase.name) && tcase.path != "-" {
+			assert.Equal(t, tcase.path, schemaErr.Path, tcase.name)
+		}
+	}
+
+	schema, ok := GetObjectSchema(userType)
+	assert.True(t, ok)
+	pathType, ok := schema.PathType("profile.level")
+	assert.True(t, ok)
+	assert.Equal(t, Int64, pathType)
+	for _, path := range []string{"profile.unknown", "uid.level", "profile."} {
+		_, ok = schema.PathType(path)
+		assert.False(t, ok, path)
+	}
+	_, ok = GetObjectSchema(Map)
+	assert.False(t, ok)
+
+	value, _ := Convert(map[string]interface{}{"uid": "1", "profile": map[string]interface{}{"level": 2}}, userType)
+	level, ok := GetPath(value, "profile.level")
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), level)
+	_, ok = GetPath(value, "profile.tags")
+	assert.False(t, ok)
+
+	// schema 配置错误
+	for _, schema := range []*ObjectSchema{
+		nil,
+		{Fields: []ObjectField{{Name: "a.b", Type: String}}},
+		{Fields: []ObjectField{{Name: "a", Type: String}, {Name: "a", Type: Int64}}},
+		{Fields: []ObjectField{{Name: "a", Type: DType(199)}}},
+	} {
+		_, err := RegisterObject("test_invalid_object", schema)
+		assert.Error(t, err)
+	}
+}
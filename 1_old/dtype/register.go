@@ -0,0 +1,94 @@
+package dtype
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Converter 将任意值转换为用户类型的值, 输入不会是 nil.
+type Converter func(value interface{}) (interface{}, error)
+
+// Validator 校验转换后的值, 返回错误时转换失败.
+type Validator func(value interface{}) error
+
+type userType struct {
+	converter Converter
+	zeroValue interface{}
+	validator Validator
+	schema    *ObjectSchema // 对象类型的 schema, 见 RegisterObject
+}
+
+// key: dtype, 与 toNames/toTypes 共用 typeLock
+var userTypes = map[DType]*userType{}
+
+// 下一个可分配的用户类型
+var nextUserDType = userDefineStart
+
+// Register 注册用户类型, 返回分配的 dtype(从 200 开始). 注册后 Convert 和 JSON 解析都可以使用该类型.
+// zeroValue 为 nil 的转换结果, 如字段的 AutoNilToZero; validator 为空时不校验.
+func Register(name string, converter Converter, zeroValue interface{}, validator Validator) (DType, error) {
+	if name == "" {
+		return 0, errors.New("dtype name can not be empty")
+	}
+	if converter == nil {
+		return 0, fmt.Errorf("dtype %s converter can not be nil", name)
+	}
+	return register(name, &userType{
+		converter: converter,
+		zeroValue: zeroValue,
+		validator: validator,
+	})
+}
+
+func register(name string, t *userType) (DType, error) {
+	typeLock.Lock()
+	defer typeLock.Unlock()
+	if _, ok := toTypes[name]; ok {
+		return 0, fmt.Errorf("dtype %s has been defined", name)
+	}
+	// 跳过 NewDType 占用的值
+	for {
+		if _, ok := toNames[nextUserDType]; !ok {
+			break
+		}
+		nextUserDType++
+	}
+	dtype := nextUserDType
+	nextUserDType++
+	toNames[dtype] = name
+	toTypes[name] = dtype
+	userTypes[dtype] = t
+	return dtype, nil
+}
+
+// MustRegister 与 Register 相同, 注册失败时 panic. 用于包初始化时注册类型.
+func MustRegister(name string, converter Converter, zeroValue interface{}, validator Validator) DType {
+	dtype, err := Register(name, converter, zeroValue, validator)
+	if err != nil {
+		panic(err)
+	}
+	return dtype
+}
+
+func getUserType(dtype DType) (*userType, bool) {
+	typeLock.RLock()
+	defer typeLock.RUnlock()
+	t, ok := userTypes[dtype]
+	return t, ok
+}
+
+func (t *userType) convert(value interface{}, dtype DType) (interface{}, error) {
+	if value == nil {
+		return t.zeroValue, nil
+	}
+	v, err := t.converter(value)
+	if err != nil {
+		return value, fmt.Errorf("convert %v to %s error: %w", value, dtype, err)
+	}
+	if t.validator != nil {
+		if err := t.validator(v); err != nil {
+			return value, fmt.Errorf("validate %v as %s error: %w", v, dtype, err)
+		}
+	}
+	return v, nil
+}
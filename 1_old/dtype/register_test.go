@@ -0,0 +1,103 @@
+package dtype
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testIP [4]byte
+
+var testTypeSeq int64
+
+// 类型注册是全局的, 测试使用每次运行唯一的名称, 保证 -count=N 时可以重复运行.
+func testTypeName(name string) string {
+	return fmt.Sprintf("%s_%d", name, atomic.AddInt64(&testTypeSeq, 1))
+}
+
+func TestRegister(t *testing.T) {
+	ipName := testTypeName("test_ip")
+	ipType, err := Register(ipName, func(value interface{}) (interface{}, error) {
+		var ip testIP
+		parts := strings.Split(ToString(value), ".")
+		if len(parts) != 4 {
+			return nil, errors.New("invalid ip")
+		}
+		for i, part := range parts {
+			n, err := ToInt64(part)
+			if err != nil {
+				return nil, err
+			}
+			ip[i] = byte(n)
+		}
+		return ip, nil
+	}, testIP{}, func(value interface{}) error {
+		if value.(testIP)[0] == 0 {
+			return errors.New("first byte can not be 0")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, int(ipType), 200)
+	assert.Equal(t, ipName, ipType.String())
+
+	testCases := []struct {
+		name   string
+		value  interface{}
+		except interface{}
+		isErr  bool
+	}{
+		{"string2ip", "10.0.0.1", testIP{10, 0, 0, 1}, false},
+		{"nil2zero", nil, testIP{}, false},
+		{"convert_error", "10.0.1", nil, true},
+		{"validate_error", "0.0.0.1", nil, true},
+	}
+	for _, tcase := range testCases {
+		value, err := Convert(tcase.value, ipType)
+		assert.Equal(t, tcase.isErr, err != nil, tcase.name)
+		if !tcase.isErr {
+			assert.Equal(t, tcase.except, value, tcase.name)
+		}
+	}
+
+	// 配置中可以使用类型名称
+	var dt DType
+	assert.NoError(t, json.Unmarshal([]byte(`"`+ipName+`"`), &dt))
+	assert.Equal(t, ipType, dt)
+
+	_, err = Register(ipName, func(value interface{}) (interface{}, error) { return value, nil }, nil, nil)
+	assert.Error(t, err)
+	_, err = Register("string", func(value interface{}) (interface{}, error) { return value, nil }, nil, nil)
+	assert.Error(t, err)
+	_, err = Register("test_nil", nil, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestRegisterConcurrent(t *testing.T) {
+	wg := sync.WaitGroup{}
+	dtypes := make([]DType, 20)
+	for i := range dtypes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dtype, err := Register(testTypeName(fmt.Sprintf("test_concurrent_%d", i)),
+				func(value interface{}) (interface{}, error) { return ToString(value), nil }, "", nil)
+			assert.NoError(t, err)
+			dtypes[i] = dtype
+			_, _ = Convert(1, dtype)
+			_, _ = GetDtype(dtype.String())
+		}(i)
+	}
+	wg.Wait()
+	seen := map[DType]struct{}{}
+	for _, dtype := range dtypes {
+		seen[dtype] = struct{}{}
+	}
+	assert.Len(t, seen, len(dtypes))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"sync"
 )
 
 // 与外界交互使用易读性更好的 string, 内部计算使用更高效的 uint.
@@ -28,40 +29,71 @@ const (
 	String  DType = DType(reflect.String)
 
 	// datasupply define, [100-200)
-	ArrayInt64  DType = iota + 100 // []int64
-	ArrayUint64                    // []uint64
-	ArrayString                    // []string
-	ArrayByte                      // []byte
+	ArrayInt64   DType = iota + 100 // []int64
+	ArrayUint64                     // []uint64
+	ArrayString                     // []string
+	ArrayByte                       // []byte
+	ArrayFloat64                    // []float64
+	ArrayInt                        // []int
+	ArrayBytes                      // [][]byte
+	Time                            // time.Time, 支持 RFC3339 字符串和 unix 秒/毫秒时间戳
+	Duration                        // time.Duration, 支持 "1.5s" 格式的字符串和纳秒数
+	ArrayBool                       // []bool
+	MapString                       // map[string]string
+	ArrayMap                        // []map[string]interface{}
 
-	// user define, [200,x)
+	// user define, [200,x), 通过 Register 注册
+	userDefineStart DType = 200
 )
 
+// toNames/toTypes 的读写都需要加锁, 用户类型可以在运行时注册.
+var typeLock sync.RWMutex
+
 var toNames = map[DType]string{
-	Bool:        "bool",
-	Int64:       "int64",
-	Uint64:      "uint64",
-	Float64:     "float64",
-	Map:         "map",
-	String:      "string",
-	ArrayInt64:  "[]int64",
-	ArrayUint64: "[]uint64",
-	ArrayString: "[]string",
-	ArrayByte:   "[]byte",
+	Bool:         "bool",
+	Int64:        "int64",
+	Uint64:       "uint64",
+	Float64:      "float64",
+	Map:          "map",
+	String:       "string",
+	ArrayInt64:   "[]int64",
+	ArrayUint64:  "[]uint64",
+	ArrayString:  "[]string",
+	ArrayByte:    "[]byte",
+	ArrayFloat64: "[]float64",
+	ArrayInt:     "[]int",
+	ArrayBytes:   "[][]byte",
+	Time:         "time",
+	Duration:     "duration",
+	ArrayBool:    "[]bool",
+	MapString:    "map[string]string",
+	ArrayMap:     "[]map",
 }
 var toTypes = map[string]DType{
-	"bool":     Bool,
-	"int64":    Int64,
-	"uint64":   Uint64,
-	"float64":  Float64,
-	"map":      Map,
-	"string":   String,
-	"[]int64":  ArrayInt64,
-	"[]uint64": ArrayUint64,
-	"[]string": ArrayString,
-	"[]byte":   ArrayByte,
+	"bool":              Bool,
+	"int64":             Int64,
+	"uint64":            Uint64,
+	"float64":           Float64,
+	"map":               Map,
+	"string":            String,
+	"[]int64":           ArrayInt64,
+	"[]uint64":          ArrayUint64,
+	"[]string":          ArrayString,
+	"[]byte":            ArrayByte,
+	"[]float64":         ArrayFloat64,
+	"[]int":             ArrayInt,
+	"[][]byte":          ArrayBytes,
+	"time":              Time,
+	"duration":          Duration,
+	"[]bool":            ArrayBool,
+	"map[string]string": MapString,
+	"[]map":             ArrayMap,
 }
 
+// NewDType 只注册类型名称, 需要转换值时使用 Register.
 func NewDType(dtype uint, dtypeStr string) (DType, error) {
+	typeLock.Lock()
+	defer typeLock.Unlock()
 	if _, ok := toNames[DType(dtype)]; ok {
 		return 0, errors.New("dtype has been defined")
 	}
@@ -74,12 +106,16 @@ func NewDType(dtype uint, dtypeStr string) (DType, error) {
 }
 
 func GetDtype(dtypeStr string) (DType, bool) {
+	typeLock.RLock()
+	defer typeLock.RUnlock()
 	dtype, isExist := toTypes[dtypeStr]
 	return dtype, isExist
 }
 
 func (dtype DType) String() string {
+	typeLock.RLock()
 	str, ok := toNames[dtype]
+	typeLock.RUnlock()
 	if ok {
 		return str
 	}
@@ -96,7 +132,7 @@ func (dtype *DType) UnmarshalJSON(b []byte) error {
 		return err
 	}
 	var ok bool
-	*dtype, ok = toTypes[str]
+	*dtype, ok = GetDtype(str)
 	if !ok {
 		return errors.New("unknown dtype name " + str)
 	}
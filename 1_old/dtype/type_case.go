@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"go/types"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/tidwall/gjson"
@@ -402,6 +404,9 @@ func ToArray(value interface{}) ([]interface{}, error) {
 }
 
 func ToByteArray(value interface{}) ([][]byte, error) {
+	if value, ok := value.([][]byte); ok {
+		return value, nil
+	}
 	var result [][]byte
 	err := sliceHelper(value, func(n int) { result = make([][]byte, n) }, func(i int, v interface{}) error {
 		p, ok := v.([]byte)
@@ -580,3 +585,188 @@ func IsNumber(d interface{}) bool {
 	}
 	return true
 }
+
+func ToUint64(value interface{}) (uint64, error) {
+	switch value := value.(type) {
+	case uint:
+		return uint64(value), nil
+	case uint16:
+		return uint64(value), nil
+	case uint32:
+		return uint64(value), nil
+	case uint64:
+		return value, nil
+	case string:
+		return strconv.ParseUint(value, 10, 64)
+	case []byte:
+		return strconv.ParseUint(string(value), 10, 64)
+	case json.Number:
+		return strconv.ParseUint(value.String(), 10, 64)
+	case gjson.Result:
+		return value.Uint(), nil
+	case nil:
+		return 0, nil
+	}
+	i, err := ToInt64(value)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected type for Uint64, got type %T", value)
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("cannot convert negative number %d to Uint64", i)
+	}
+	return uint64(i), nil
+}
+
+func ToUint64Array(value interface{}) ([]uint64, error) {
+	switch value := value.(type) {
+	case []uint64:
+		return value, nil
+	case string:
+		var r []uint64
+		err := json.Unmarshal([]byte(value), &r)
+		return r, err
+	case nil:
+		return []uint64{}, nil
+	}
+	var result []uint64
+	err := sliceHelper(value, func(n int) { result = make([]uint64, n) }, func(i int, v interface{}) error {
+		u, err := ToUint64(v)
+		result[i] = u
+		return err
+	})
+	return result, err
+}
+
+func ToBoolArray(value interface{}) ([]bool, error) {
+	switch value := value.(type) {
+	case []bool:
+		return value, nil
+	case string:
+		var r []bool
+		err := json.Unmarshal([]byte(value), &r)
+		return r, err
+	case nil:
+		return []bool{}, nil
+	}
+	var result []bool
+	err := sliceHelper(value, func(n int) { result = make([]bool, n) }, func(i int, v interface{}) error {
+		b, err := ToBool(v)
+		result[i] = b
+		return err
+	})
+	return result, err
+}
+
+// 时间戳绝对值不小于 1e12 时按毫秒处理, 否则按秒处理. 1e12 秒约为 3 万年后, 1e12 毫秒约为 2001 年.
+const unixMilliThreshold = 1e12
+
+// ToTime 支持 time.Time, RFC3339 字符串, unix 秒/毫秒时间戳(数字或数字字符串). nil 转换为零值.
+func ToTime(value interface{}) (time.Time, error) {
+	switch value := value.(type) {
+	case time.Time:
+		return value, nil
+	case *time.Time:
+		if value == nil {
+			return time.Time{}, nil
+		}
+		return *value, nil
+	case string:
+		return parseTime(value)
+	case []byte:
+		return parseTime(string(value))
+	case gjson.Result:
+		if value.Type == gjson.String {
+			return parseTime(value.String())
+		}
+		return unixToTime(value.Float()), nil
+	case nil:
+		return time.Time{}, nil
+	}
+	f, err := ToFloat64(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected type for Time, got type %T", value)
+	}
+	return unixToTime(f), nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return unixToTime(f), nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+func unixToTime(ts float64) time.Time {
+	if math.Abs(ts) >= unixMilliThreshold {
+		ms := int64(ts)
+		return time.Unix(ms/1000, ms%1000*int64(time.Millisecond))
+	}
+	sec, frac := math.Modf(ts)
+	return time.Unix(int64(sec), int64(frac*float64(time.Second)))
+}
+
+// ToDuration 支持 time.Duration, time.ParseDuration 格式的字符串(如 "1.5s"), 以及纳秒数.
+func ToDuration(value interface{}) (time.Duration, error) {
+	switch value := value.(type) {
+	case time.Duration:
+		return value, nil
+	case string:
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Duration(i), nil
+		}
+		return time.ParseDuration(value)
+	case []byte:
+		return ToDuration(string(value))
+	case gjson.Result:
+		if value.Type == gjson.String {
+			return ToDuration(value.String())
+		}
+		return time.Duration(value.Int()), nil
+	case nil:
+		return 0, nil
+	}
+	i, err := ToInt64(value)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected type for Duration, got type %T", value)
+	}
+	return time.Duration(i), nil
+}
+
+// ToStringMap 将 map 的值转换为字符串. string 按 JSON 解析.
+func ToStringMap(value interface{}) (map[string]string, error) {
+	switch value := value.(type) {
+	case map[string]string:
+		return value, nil
+	case nil:
+		return nil, nil
+	}
+	m, err := ToMap(value)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = ToString(v)
+	}
+	return result, nil
+}
+
+func ToMapArray(value interface{}) ([]map[string]interface{}, error) {
+	switch value := value.(type) {
+	case []map[string]interface{}:
+		return value, nil
+	case string:
+		var r []map[string]interface{}
+		err := json.Unmarshal([]byte(value), &r)
+		return r, err
+	case nil:
+		return []map[string]interface{}{}, nil
+	}
+	var result []map[string]interface{}
+	err := sliceHelper(value, func(n int) { result = make([]map[string]interface{}, n) }, func(i int, v interface{}) error {
+		m, err := ToMap(v)
+		result[i] = m
+		return err
+	})
+	return result, err
+}
@@ -19,3 +19,15 @@ func TestDType(t *testing.T) {
 	assert.NoError(t, err)
 	fmt.Println(dt2)
 }
+
+func TestDTypeNames(t *testing.T) {
+	for dtype, name := range toNames {
+		assert.Equal(t, name, dtype.String())
+		b, err := json.Marshal(dtype)
+		assert.NoError(t, err)
+		var dt DType
+		assert.NoError(t, json.Unmarshal(b, &dt))
+		assert.Equal(t, dtype, dt, name)
+	}
+	assert.Equal(t, len(toNames), len(toTypes))
+}
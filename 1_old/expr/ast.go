@@ -0,0 +1,75 @@
+package expr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// 语法树节点. String 返回规范化的表达式, 用于生成稳定的参数 ID.
+type astNode interface {
+	String() string
+}
+
+type (
+	literalNode struct {
+		value interface{} // int64, float64, string, bool, nil
+	}
+	identNode struct {
+		name string
+	}
+	unaryNode struct {
+		op      string
+		operand astNode
+	}
+	binaryNode struct {
+		op          string
+		left, right astNode
+	}
+	ternaryNode struct {
+		cond, then, otherwise astNode
+	}
+	callNode struct {
+		name string
+		args []astNode
+	}
+)
+
+func (n *literalNode) String() string {
+	switch v := n.value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+func (n *identNode) String() string {
+	return n.name
+}
+
+func (n *unaryNode) String() string {
+	return n.op + n.operand.String()
+}
+
+func (n *binaryNode) String() string {
+	return "(" + n.left.String() + " " + n.op + " " + n.right.String() + ")"
+}
+
+func (n *ternaryNode) String() string {
+	return "(" + n.cond.String() + " ? " + n.then.String() + " : " + n.otherwise.String() + ")"
+}
+
+func (n *callNode) String() string {
+	args := make([]string, len(n.args))
+	for i, arg := range n.args {
+		args[i] = arg.String()
+	}
+	return n.name + "(" + strings.Join(args, ", ") + ")"
+}
@@ -0,0 +1,282 @@
+package expr
+
+import (
+	"fmt"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+)
+
+// null 字面量的类型, 可以与任意类型统一.
+const typeNull dtype.DType = 0
+
+// 编译后的求值函数, vars 按照 Var 的声明顺序传入.
+type evalFn func(vars []interface{}) (interface{}, error)
+
+type checker struct {
+	vars map[string]int // name->index
+	defs []Var
+}
+
+func isNumber(t dtype.DType) bool {
+	return t == dtype.Int64 || t == dtype.Float64
+}
+
+// 统一两个类型, 用于三元运算, ?? 等. 整数和浮点数统一为浮点数.
+func unify(a, b dtype.DType) (dtype.DType, bool) {
+	switch {
+	case a == typeNull:
+		return b, true
+	case b == typeNull || a == b:
+		return a, true
+	case isNumber(a) && isNumber(b):
+		return dtype.Float64, true
+	}
+	return 0, false
+}
+
+func typeName(t dtype.DType) string {
+	if t == typeNull {
+		return "null"
+	}
+	return t.String()
+}
+
+func (c *checker) check(node astNode) (dtype.DType, evalFn, error) {
+	switch n := node.(type) {
+	case *literalNode:
+		var t dtype.DType
+		switch n.value.(type) {
+		case int64:
+			t = dtype.Int64
+		case float64:
+			t = dtype.Float64
+		case string:
+			t = dtype.String
+		case bool:
+			t = dtype.Bool
+		}
+		value := n.value
+		return t, func([]interface{}) (interface{}, error) { return value, nil }, nil
+	case *identNode:
+		i, ok := c.vars[n.name]
+		if !ok {
+			return 0, nil, fmt.Errorf("undefined variable %s", n.name)
+		}
+		return c.defs[i].Type, func(vars []interface{}) (interface{}, error) { return vars[i], nil }, nil
+	case *unaryNode:
+		return c.checkUnary(n)
+	case *binaryNode:
+		return c.checkBinary(n)
+	case *ternaryNode:
+		return c.checkTernary(n)
+	case *callNode:
+		return c.checkCall(n)
+	}
+	return 0, nil, fmt.Errorf("unknown expression %s", node)
+}
+
+func (c *checker) checkUnary(n *unaryNode) (dtype.DType, evalFn, error) {
+	t, operand, err := c.check(n.operand)
+	if err != nil {
+		return 0, nil, err
+	}
+	switch n.op {
+	case "-":
+		if !isNumber(t) && t != typeNull {
+			return 0, nil, fmt.Errorf("operator - not defined on %s in %s", typeName(t), n)
+		}
+		return t, func(vars []interface{}) (interface{}, error) {
+			v, err := operand(vars)
+			if err != nil || v == nil {
+				return nil, err
+			}
+			if i, ok := v.(int64); ok {
+				return -i, nil
+			}
+			return -v.(float64), nil
+		}, nil
+	case "!":
+		if t != dtype.Bool && t != typeNull {
+			return 0, nil, fmt.Errorf("operator ! not defined on %s in %s", typeName(t), n)
+		}
+		return dtype.Bool, func(vars []interface{}) (interface{}, error) {
+			v, err := operand(vars)
+			if err != nil {
+				return nil, err
+			}
+			return !truthy(v), nil
+		}, nil
+	}
+	return 0, nil, fmt.Errorf("unknown operator %s", n.op)
+}
+
+func (c *checker) checkBinary(n *binaryNode) (dtype.DType, evalFn, error) {
+	lt, left, err := c.check(n.left)
+	if err != nil {
+		return 0, nil, err
+	}
+	rt, right, err := c.check(n.right)
+	if err != nil {
+		return 0, nil, err
+	}
+	mismatch := fmt.Errorf("operator %s not defined on %s and %s in %s", n.op, typeName(lt), typeName(rt), n)
+
+	switch n.op {
+	case "&&", "||":
+		if (lt != dtype.Bool && lt != typeNull) || (rt != dtype.Bool && rt != typeNull) {
+			return 0, nil, mismatch
+		}
+		isAnd := n.op == "&&"
+		return dtype.Bool, func(vars []interface{}) (interface{}, error) {
+			l, err := left(vars)
+			if err != nil {
+				return nil, err
+			}
+			// 短路求值
+			if truthy(l) != isAnd {
+				return !isAnd, nil
+			}
+			r, err := right(vars)
+			if err != nil {
+				return nil, err
+			}
+			return truthy(r), nil
+		}, nil
+	case "??":
+		t, ok := unify(lt, rt)
+		if !ok {
+			return 0, nil, mismatch
+		}
+		return t, coerce(t, func(vars []interface{}) (interface{}, error) {
+			l, err := left(vars)
+			if err != nil || l != nil {
+				return l, err
+			}
+			return right(vars)
+		}), nil
+	case "==", "!=":
+		if _, ok := unify(lt, rt); !ok {
+			return 0, nil, mismatch
+		}
+		isEqual := n.op == "=="
+		return dtype.Bool, func(vars []interface{}) (interface{}, error) {
+			l, r, err := evalBoth(vars, left, right)
+			if err != nil {
+				return nil, err
+			}
+			return equal(l, r) == isEqual, nil
+		}, nil
+	case "<", "<=", ">", ">=":
+		if t, ok := unify(lt, rt); !ok || !isComparable(t) {
+			return 0, nil, mismatch
+		}
+		op := n.op
+		return dtype.Bool, nullable(left, right, func(l, r interface{}) (interface{}, error) {
+			cmp := compare(l, r)
+			switch op {
+			case "<":
+				return cmp < 0, nil
+			case "<=":
+				return cmp <= 0, nil
+			case ">":
+				return cmp > 0, nil
+			}
+			return cmp >= 0, nil
+		}), nil
+	case "+":
+		if lt == dtype.String || rt == dtype.String {
+			if t, ok := unify(lt, rt); !ok || t != dtype.String {
+				return 0, nil, mismatch
+			}
+			return dtype.String, nullable(left, right, func(l, r interface{}) (interface{}, error) {
+				return l.(string) + r.(string), nil
+			}), nil
+		}
+		fallthrough
+	case "-", "*", "/", "%":
+		if (!isNumber(lt) && lt != typeNull) || (!isNumber(rt) && rt != typeNull) {
+			return 0, nil, mismatch
+		}
+		t, _ := unify(lt, rt)
+		switch n.op {
+		case "/":
+			t = dtype.Float64
+		case "%":
+			if t != dtype.Int64 && t != typeNull {
+				return 0, nil, mismatch
+			}
+		}
+		return t, nullable(left, right, arithmetic(n.op)), nil
+	}
+	return 0, nil, fmt.Errorf("unknown operator %s", n.op)
+}
+
+func isComparable(t dtype.DType) bool {
+	return isNumber(t) || t == dtype.String || t == typeNull
+}
+
+func (c *checker) checkTernary(n *ternaryNode) (dtype.DType, evalFn, error) {
+	ct, cond, err := c.check(n.cond)
+	if err != nil {
+		return 0, nil, err
+	}
+	if ct != dtype.Bool && ct != typeNull {
+		return 0, nil, fmt.Errorf("condition must be bool, got %s in %s", typeName(ct), n)
+	}
+	tt, then, err := c.check(n.then)
+	if err != nil {
+		return 0, nil, err
+	}
+	ot, otherwise, err := c.check(n.otherwise)
+	if err != nil {
+		return 0, nil, err
+	}
+	t, ok := unify(tt, ot)
+	if !ok {
+		return 0, nil, fmt.Errorf("ternary branches type mismatch: %s and %s in %s", typeName(tt), typeName(ot), n)
+	}
+	return t, coerce(t, func(vars []interface{}) (interface{}, error) {
+		c, err := cond(vars)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(c) {
+			return then(vars)
+		}
+		return otherwise(vars)
+	}), nil
+}
+
+// 类型统一为浮点数时, 整数结果需要转换为浮点数
+func coerce(t dtype.DType, fn evalFn) evalFn {
+	if t != dtype.Float64 {
+		return fn
+	}
+	return func(vars []interface{}) (interface{}, error) {
+		v, err := fn(vars)
+		if i, ok := v.(int64); ok {
+			return float64(i), err
+		}
+		return v, err
+	}
+}
+
+func evalBoth(vars []interface{}, left, right evalFn) (interface{}, interface{}, error) {
+	l, err := left(vars)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := right(vars)
+	return l, r, err
+}
+
+// 任一操作数为 null 时结果为 null
+func nullable(left, right evalFn, fn func(l, r interface{}) (interface{}, error)) evalFn {
+	return func(vars []interface{}) (interface{}, error) {
+		l, r, err := evalBoth(vars, left, right)
+		if err != nil || l == nil || r == nil {
+			return nil, err
+		}
+		return fn(l, r)
+	}
+}
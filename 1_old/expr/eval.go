@@ -0,0 +1,100 @@
+package expr
+
+import (
+	"strings"
+)
+
+// 逻辑运算中 null 视为 false
+func truthy(v interface{}) bool {
+	b, _ := v.(bool)
+	return b
+}
+
+func toFloat(v interface{}) float64 {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+func equal(l, r interface{}) bool {
+	if l == nil || r == nil {
+		return l == nil && r == nil
+	}
+	li, lok := l.(int64)
+	ri, rok := r.(int64)
+	if lok && rok {
+		return li == ri
+	}
+	if lok || rok {
+		return toFloat(l) == toFloat(r)
+	}
+	return l == r
+}
+
+// 比较两个非 null 的数值或字符串, 返回 -1/0/1
+func compare(l, r interface{}) int {
+	if ls, ok := l.(string); ok {
+		return strings.Compare(ls, r.(string))
+	}
+	li, lok := l.(int64)
+	ri, rok := r.(int64)
+	if lok && rok {
+		switch {
+		case li < ri:
+			return -1
+		case li > ri:
+			return 1
+		}
+		return 0
+	}
+	lf, rf := toFloat(l), toFloat(r)
+	switch {
+	case lf < rf:
+		return -1
+	case lf > rf:
+		return 1
+	}
+	return 0
+}
+
+// 算术运算. 两个整数运算结果为整数(除法除外), 否则为浮点数. 除数为 0 时结果为 null.
+func arithmetic(op string) func(l, r interface{}) (interface{}, error) {
+	return func(l, r interface{}) (interface{}, error) {
+		li, lok := l.(int64)
+		ri, rok := r.(int64)
+		if lok && rok && op != "/" {
+			switch op {
+			case "+":
+				return li + ri, nil
+			case "-":
+				return li - ri, nil
+			case "*":
+				return li * ri, nil
+			case "%":
+				if ri == 0 {
+					return nil, nil
+				}
+				return li % ri, nil
+			}
+		}
+		lf, rf := toFloat(l), toFloat(r)
+		switch op {
+		case "+":
+			return lf + rf, nil
+		case "-":
+			return lf - rf, nil
+		case "*":
+			return lf * rf, nil
+		case "/":
+			if rf == 0 {
+				return nil, nil
+			}
+			return lf / rf, nil
+		}
+		return nil, nil
+	}
+}
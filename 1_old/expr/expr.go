@@ -0,0 +1,122 @@
+/*
+Package expr 是用于派生字段的表达式引擎, 如 `a / b`, `age_days < 7`.
+
+表达式在构建时编译并根据变量的 dtype 做类型检查, 运行时只做求值.
+
+支持的语法:
+  - 字面量: 整数, 浮点数, 字符串('..' 或 ".."), true, false, null
+  - 算术: + - * / %. 两个整数运算结果为整数, / 的结果总是浮点数, 除数为 0 时结果为 null. + 也用于字符串拼接
+  - 比较: == != < <= > >=
+  - 逻辑: && || !
+  - 三元: cond ? a : b
+  - null 处理: a ?? b, isNull(a), coalesce(a, b, ...)
+  - 函数: len, lower, upper, trim, contains, startsWith, endsWith, substr, abs, min, max, toString, toInt, toFloat
+
+null 语义: 算术, 比较和函数的任一操作数为 null 时结果为 null(isNull/coalesce 除外);
+逻辑运算和三元运算的条件中 null 视为 false; == 和 != 可以用于判断 null.
+*/
+package expr
+
+import (
+	"fmt"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+)
+
+// Var 表达式中的变量及其类型.
+type Var struct {
+	Name string      `json:"name"`
+	Type dtype.DType `json:"type"`
+}
+
+// Expr 编译后的表达式, 并发安全.
+type Expr struct {
+	src  string
+	ast  astNode
+	vars []Var
+	typ  dtype.DType
+	eval evalFn
+}
+
+// Compile 编译表达式, 语法错误, 未定义的变量/函数或类型不匹配时返回错误.
+// vars 为表达式可以使用的变量, Eval 时按照相同的顺序传入变量值.
+func Compile(src string, vars []Var) (*Expr, error) {
+	ast, err := parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("expr [%s] parse error: %s", src, err.Error())
+	}
+	c := &checker{vars: make(map[string]int, len(vars)), defs: vars}
+	for i, v := range vars {
+		if _, ok := c.vars[v.Name]; ok {
+			return nil, fmt.Errorf("expr [%s] variable repeat: %s", src, v.Name)
+		}
+		if _, ok := dtype.GetDtype(v.Type.String()); !ok {
+			return nil, fmt.Errorf("expr [%s] variable %s type not defined", src, v.Name)
+		}
+		c.vars[v.Name] = i
+	}
+	typ, eval, err := c.check(ast)
+	if err != nil {
+		return nil, fmt.Errorf("expr [%s] type error: %s", src, err.Error())
+	}
+	return &Expr{
+		src:  src,
+		ast:  ast,
+		vars: vars,
+		typ:  typ,
+		eval: eval,
+	}, nil
+}
+
+// MustCompile 与 Compile 相同, 编译失败时 panic. 用于常量表达式的初始化.
+func MustCompile(src string, vars []Var) *Expr {
+	e, err := Compile(src, vars)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// Vars 返回表达式的变量, 顺序与 Compile 时相同.
+func (e *Expr) Vars() []Var {
+	return e.vars
+}
+
+// Type 返回表达式结果的类型. 表达式的结果总是 null 时返回 0.
+func (e *Expr) Type() dtype.DType {
+	return e.typ
+}
+
+// Source 返回表达式原文.
+func (e *Expr) Source() string {
+	return e.src
+}
+
+// String 返回规范化的表达式, 语义相同的表达式(如仅空格不同)返回相同的值. 可用于生成参数 ID.
+func (e *Expr) String() string {
+	return e.ast.String()
+}
+
+// DeepCopy 编译后的表达式不可变, 复制时直接返回自身. 实现 deepcopy.Interface.
+func (e *Expr) DeepCopy() interface{} {
+	return e
+}
+
+// Eval 使用变量值求值, values 与 Vars 一一对应. 变量值会被转换为声明的类型, nil 视为 null.
+func (e *Expr) Eval(values ...interface{}) (interface{}, error) {
+	if len(values) != len(e.vars) {
+		return nil, fmt.Errorf("expr [%s] expects %d variables, got %d", e.src, len(e.vars), len(values))
+	}
+	vars := make([]interface{}, len(values))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		v, err := dtype.Convert(value, e.vars[i].Type)
+		if err != nil {
+			return nil, fmt.Errorf("expr [%s] variable %s: %s", e.src, e.vars[i].Name, err.Error())
+		}
+		vars[i] = v
+	}
+	return e.eval(vars)
+}
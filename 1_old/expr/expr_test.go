@@ -0,0 +1,137 @@
+package expr
+
+import (
+	"testing"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"github.com/stretchr/testify/assert"
+)
+
+var testVars = []Var{
+	{Name: "a", Type: dtype.Int64},
+	{Name: "b", Type: dtype.Int64},
+	{Name: "f", Type: dtype.Float64},
+	{Name: "s", Type: dtype.String},
+	{Name: "ok", Type: dtype.Bool},
+	{Name: "tags", Type: dtype.ArrayString},
+	{Name: "n", Type: dtype.Int64}, // 测试时总是 null
+}
+
+var testValues = []interface{}{int64(7), "2", 0.5, "Hello", true, []string{"x", "y"}, nil}
+
+func TestEval(t *testing.T) {
+	cases := []struct {
+		src    string
+		expect interface{}
+		typ    dtype.DType
+	}{
+		// 算术
+		{"a + b * 2", int64(11), dtype.Int64},
+		{"(a + b) * 2", int64(18), dtype.Int64},
+		{"a - b - 1", int64(4), dtype.Int64},
+		{"a / b", 3.5, dtype.Float64},
+		{"a % b", int64(1), dtype.Int64},
+		{"a + f", 7.5, dtype.Float64},
+		{"-a + 1", int64(-6), dtype.Int64},
+		{"a / 0", nil, dtype.Float64},
+		{"s + ' world'", "Hello world", dtype.String},
+		// 比较和逻辑
+		{"a > b && f < 1", true, dtype.Bool},
+		{"a < b || !ok", false, dtype.Bool},
+		{"a == 7.0", true, dtype.Bool},
+		{"s >= 'H'", true, dtype.Bool},
+		{"s != \"Hello\"", false, dtype.Bool},
+		// 三元, 右结合
+		{"a > 5 ? 'big' : 'small'", "big", dtype.String},
+		{"a > 10 ? 1 : a > 5 ? 2 : 3", int64(2), dtype.Int64},
+		{"ok ? a : f", 7.0, dtype.Float64},
+		// null
+		{"n + 1", nil, dtype.Int64},
+		{"n > 1", nil, dtype.Bool},
+		{"n == null", true, dtype.Bool},
+		{"a == null", false, dtype.Bool},
+		{"n ?? 0", int64(0), dtype.Int64},
+		{"n ?? f", 0.5, dtype.Float64},
+		{"n > 1 ? 'y' : 'n'", "n", dtype.String},
+		{"n > 1 || ok", true, dtype.Bool},
+		{"isNull(n)", true, dtype.Bool},
+		{"coalesce(n, null, a)", int64(7), dtype.Int64},
+		{"null", nil, typeNull},
+		// 函数
+		{"len(s) + len(tags)", int64(7), dtype.Int64},
+		{"lower(s) + upper(s)", "helloHELLO", dtype.String},
+		{"trim('  x ')", "x", dtype.String},
+		{"contains(tags, 'y') && contains(s, 'ell')", true, dtype.Bool},
+		{"startsWith(s, 'He') && endsWith(s, 'lo')", true, dtype.Bool},
+		{"substr(s, 1, 3)", "ell", dtype.String},
+		{"substr(s, 3, 100)", "lo", dtype.String},
+		{"abs(-f)", 0.5, dtype.Float64},
+		{"max(a, f)", 7.0, dtype.Float64},
+		{"min(a, b)", int64(2), dtype.Int64},
+		{"toString(a) + s", "7Hello", dtype.String},
+		{"toInt('12') + toInt(ok)", int64(13), dtype.Int64},
+		{"toFloat(a)", 7.0, dtype.Float64},
+		{"lower(toString(n))", nil, dtype.String},
+	}
+	for _, c := range cases {
+		e, err := Compile(c.src, testVars)
+		if !assert.NoError(t, err, c.src) {
+			continue
+		}
+		assert.Equal(t, c.typ, e.Type(), c.src)
+		v, err := e.Eval(testValues...)
+		assert.NoError(t, err, c.src)
+		assert.Equal(t, c.expect, v, c.src)
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	cases := []string{
+		"",
+		"a +",
+		"(a + b",
+		"a b",
+		"'abc",
+		"a ? b",
+		"1.2.3",
+		"a # b",
+		"unknown + 1",
+		"unknown(a)",
+		"a + s",
+		"a && ok",
+		"!a",
+		"-s",
+		"f % 2",
+		"s < 1",
+		"ok ? a : s",
+		"a ? 1 : 2",
+		"len(a)",
+		"substr(s, 1)",
+		"contains(tags, 1)",
+		"tags > 1",
+	}
+	for _, src := range cases {
+		_, err := Compile(src, testVars)
+		assert.Error(t, err, src)
+	}
+
+	_, err := Compile("a", []Var{{Name: "a", Type: dtype.Int64}, {Name: "a", Type: dtype.String}})
+	assert.Error(t, err)
+	_, err = Compile("a", []Var{{Name: "a"}})
+	assert.Error(t, err)
+}
+
+func TestExpr(t *testing.T) {
+	e1 := MustCompile("a+b  *2", testVars)
+	e2 := MustCompile("a + (b * 2)", testVars)
+	assert.Equal(t, "(a + (b * 2))", e1.String())
+	assert.Equal(t, e1.String(), e2.String())
+	assert.Equal(t, "a+b  *2", e1.Source())
+	assert.Same(t, e1, e1.DeepCopy())
+
+	_, err := e1.Eval(1, 2)
+	assert.Error(t, err)
+	_, err = e1.Eval("x", 1, 1, "", true, nil, nil)
+	assert.Error(t, err)
+	assert.Panics(t, func() { MustCompile("a +", testVars) })
+}
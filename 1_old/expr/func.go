@@ -0,0 +1,280 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+)
+
+// 内置函数. check 根据参数类型返回结果类型, nullAware 为 false 时任一参数为 null 则结果为 null, 不调用 eval.
+type function struct {
+	check     func(args []dtype.DType) (dtype.DType, bool)
+	eval      func(args []interface{}) (interface{}, error)
+	nullAware bool
+}
+
+func fixedArgs(result dtype.DType, params ...dtype.DType) func([]dtype.DType) (dtype.DType, bool) {
+	return func(args []dtype.DType) (dtype.DType, bool) {
+		if len(args) != len(params) {
+			return 0, false
+		}
+		for i, arg := range args {
+			if arg != params[i] && arg != typeNull {
+				return 0, false
+			}
+		}
+		return result, true
+	}
+}
+
+func stringFunc(fn func(string) string) function {
+	return function{
+		check: fixedArgs(dtype.String, dtype.String),
+		eval: func(args []interface{}) (interface{}, error) {
+			return fn(args[0].(string)), nil
+		},
+	}
+}
+
+func stringPredicate(fn func(string, string) bool) function {
+	return function{
+		check: fixedArgs(dtype.Bool, dtype.String, dtype.String),
+		eval: func(args []interface{}) (interface{}, error) {
+			return fn(args[0].(string), args[1].(string)), nil
+		},
+	}
+}
+
+func numberFunc(pick func(l, r interface{}) bool) function {
+	return function{
+		check: func(args []dtype.DType) (dtype.DType, bool) {
+			if len(args) != 2 || (!isNumber(args[0]) && args[0] != typeNull) || (!isNumber(args[1]) && args[1] != typeNull) {
+				return 0, false
+			}
+			return unify(args[0], args[1])
+		},
+		eval: func(args []interface{}) (interface{}, error) {
+			if pick(args[0], args[1]) {
+				return args[0], nil
+			}
+			return args[1], nil
+		},
+	}
+}
+
+var functions = map[string]function{
+	"len": {
+		check: func(args []dtype.DType) (dtype.DType, bool) {
+			if len(args) != 1 {
+				return 0, false
+			}
+			switch args[0] {
+			case dtype.String, dtype.ArrayString, dtype.ArrayInt64, dtype.ArrayByte, dtype.Map, typeNull:
+				return dtype.Int64, true
+			}
+			return 0, false
+		},
+		eval: func(args []interface{}) (interface{}, error) {
+			switch v := args[0].(type) {
+			case string:
+				return int64(utf8.RuneCountInString(v)), nil
+			case []string:
+				return int64(len(v)), nil
+			case []int64:
+				return int64(len(v)), nil
+			case []byte:
+				return int64(len(v)), nil
+			case map[string]interface{}:
+				return int64(len(v)), nil
+			}
+			return nil, fmt.Errorf("len not defined on %T", args[0])
+		},
+	},
+	"lower":      stringFunc(strings.ToLower),
+	"upper":      stringFunc(strings.ToUpper),
+	"trim":       stringFunc(strings.TrimSpace),
+	"startsWith": stringPredicate(strings.HasPrefix),
+	"endsWith":   stringPredicate(strings.HasSuffix),
+	"contains": {
+		check: func(args []dtype.DType) (dtype.DType, bool) {
+			if len(args) != 2 {
+				return 0, false
+			}
+			switch args[0] {
+			case dtype.String, dtype.ArrayString:
+				return dtype.Bool, args[1] == dtype.String || args[1] == typeNull
+			case dtype.ArrayInt64:
+				return dtype.Bool, args[1] == dtype.Int64 || args[1] == typeNull
+			}
+			return 0, false
+		},
+		eval: func(args []interface{}) (interface{}, error) {
+			switch v := args[0].(type) {
+			case string:
+				return strings.Contains(v, args[1].(string)), nil
+			case []string:
+				for _, item := range v {
+					if item == args[1].(string) {
+						return true, nil
+					}
+				}
+				return false, nil
+			case []int64:
+				for _, item := range v {
+					if item == args[1].(int64) {
+						return true, nil
+					}
+				}
+				return false, nil
+			}
+			return nil, fmt.Errorf("contains not defined on %T", args[0])
+		},
+	},
+	// substr(s, start, length), 按字符计算, 超出范围时截断
+	"substr": {
+		check: fixedArgs(dtype.String, dtype.String, dtype.Int64, dtype.Int64),
+		eval: func(args []interface{}) (interface{}, error) {
+			runes := []rune(args[0].(string))
+			start, length := args[1].(int64), args[2].(int64)
+			if start < 0 {
+				start = 0
+			}
+			if start > int64(len(runes)) {
+				start = int64(len(runes))
+			}
+			end := start + length
+			if length < 0 || end > int64(len(runes)) {
+				end = int64(len(runes))
+			}
+			return string(runes[start:end]), nil
+		},
+	},
+	"abs": {
+		check: func(args []dtype.DType) (dtype.DType, bool) {
+			if len(args) != 1 || !isNumber(args[0]) {
+				return 0, false
+			}
+			return args[0], true
+		},
+		eval: func(args []interface{}) (interface{}, error) {
+			switch v := args[0].(type) {
+			case int64:
+				if v < 0 {
+					return -v, nil
+				}
+				return v, nil
+			case float64:
+				if v < 0 {
+					return -v, nil
+				}
+				return v, nil
+			}
+			return nil, nil
+		},
+	},
+	"min": numberFunc(func(l, r interface{}) bool { return compare(l, r) <= 0 }),
+	"max": numberFunc(func(l, r interface{}) bool { return compare(l, r) >= 0 }),
+	"isNull": {
+		check: func(args []dtype.DType) (dtype.DType, bool) {
+			return dtype.Bool, len(args) == 1
+		},
+		eval: func(args []interface{}) (interface{}, error) {
+			return args[0] == nil, nil
+		},
+		nullAware: true,
+	},
+	// coalesce(a, b, ...) 返回第一个不为 null 的参数
+	"coalesce": {
+		check: func(args []dtype.DType) (dtype.DType, bool) {
+			if len(args) == 0 {
+				return 0, false
+			}
+			t := args[0]
+			for _, arg := range args[1:] {
+				var ok bool
+				if t, ok = unify(t, arg); !ok {
+					return 0, false
+				}
+			}
+			return t, true
+		},
+		eval: func(args []interface{}) (interface{}, error) {
+			for _, arg := range args {
+				if arg != nil {
+					return arg, nil
+				}
+			}
+			return nil, nil
+		},
+		nullAware: true,
+	},
+	"toString": {
+		check: func(args []dtype.DType) (dtype.DType, bool) {
+			return dtype.String, len(args) == 1 && (isComparable(args[0]) || args[0] == dtype.Bool)
+		},
+		eval: func(args []interface{}) (interface{}, error) {
+			return dtype.ToString(args[0]), nil
+		},
+	},
+	"toInt": {
+		check: func(args []dtype.DType) (dtype.DType, bool) {
+			return dtype.Int64, len(args) == 1 && (isComparable(args[0]) || args[0] == dtype.Bool)
+		},
+		eval: func(args []interface{}) (interface{}, error) {
+			if b, ok := args[0].(bool); ok {
+				if b {
+					return int64(1), nil
+				}
+				return int64(0), nil
+			}
+			return dtype.ToInt64(args[0])
+		},
+	},
+	"toFloat": {
+		check: func(args []dtype.DType) (dtype.DType, bool) {
+			return dtype.Float64, len(args) == 1 && isComparable(args[0])
+		},
+		eval: func(args []interface{}) (interface{}, error) {
+			return dtype.ToFloat64(args[0])
+		},
+	},
+}
+
+func (c *checker) checkCall(n *callNode) (dtype.DType, evalFn, error) {
+	fn, ok := functions[n.name]
+	if !ok {
+		return 0, nil, fmt.Errorf("undefined function %s", n.name)
+	}
+	argTypes := make([]dtype.DType, len(n.args))
+	argFns := make([]evalFn, len(n.args))
+	for i, arg := range n.args {
+		var err error
+		if argTypes[i], argFns[i], err = c.check(arg); err != nil {
+			return 0, nil, err
+		}
+	}
+	t, ok := fn.check(argTypes)
+	if !ok {
+		names := make([]string, len(argTypes))
+		for i, argType := range argTypes {
+			names[i] = typeName(argType)
+		}
+		return 0, nil, fmt.Errorf("function %s not defined on (%s)", n.name, strings.Join(names, ", "))
+	}
+	return t, coerce(t, func(vars []interface{}) (interface{}, error) {
+		args := make([]interface{}, len(argFns))
+		for i, argFn := range argFns {
+			v, err := argFn(vars)
+			if err != nil {
+				return nil, err
+			}
+			if v == nil && !fn.nullAware {
+				return nil, nil
+			}
+			args[i] = v
+		}
+		return fn.eval(args)
+	}), nil
+}
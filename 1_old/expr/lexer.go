@@ -0,0 +1,100 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenInt
+	tokenFloat
+	tokenString
+	tokenIdent
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string // tokenString 时为去掉引号并处理转义后的值
+	pos  int
+}
+
+// 按长度从长到短排列, 保证优先匹配长的运算符
+var operators = []string{
+	"??", "==", "!=", "<=", ">=", "&&", "||",
+	"+", "-", "*", "/", "%", "<", ">", "!", "?", ":", "(", ")", ",",
+}
+
+func tokenize(src string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			start, kind := i, tokenInt
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				if runes[i] == '.' {
+					if kind == tokenFloat {
+						return nil, fmt.Errorf("invalid number at %d", start)
+					}
+					kind = tokenFloat
+				}
+				i++
+			}
+			tokens = append(tokens, token{kind: kind, text: string(runes[start:i]), pos: start})
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+		case r == '\'' || r == '"':
+			start := i
+			builder := strings.Builder{}
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated string at %d", start)
+				}
+				if runes[i] == r {
+					i++
+					break
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						builder.WriteRune('\n')
+					case 't':
+						builder.WriteRune('\t')
+					default:
+						builder.WriteRune(runes[i])
+					}
+					continue
+				}
+				builder.WriteRune(runes[i])
+			}
+			tokens = append(tokens, token{kind: tokenString, text: builder.String(), pos: start})
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at %d", r, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
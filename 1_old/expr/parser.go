@@ -0,0 +1,189 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// 二元运算符优先级, 数值越大优先级越高. 三元运算符优先级最低.
+var binaryPrecedence = map[string]int{
+	"??": 2,
+	"||": 3,
+	"&&": 4,
+	"==": 5, "!=": 5,
+	"<": 6, "<=": 6, ">": 6, ">=": 6,
+	"+": 7, "-": 7,
+	"*": 8, "/": 8, "%": 8,
+}
+
+const (
+	precedenceTernary = 1
+	precedenceUnary   = 9
+)
+
+// Pratt parser
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func parse(src string) (astNode, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	node, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", tok.text, tok.pos)
+	}
+	return node, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(op string) error {
+	tok := p.next()
+	if tok.kind != tokenOp || tok.text != op {
+		if tok.kind == tokenEOF {
+			return fmt.Errorf("expect %q at end of expression", op)
+		}
+		return fmt.Errorf("expect %q at %d, got %q", op, tok.pos, tok.text)
+	}
+	return nil
+}
+
+func (p *parser) parseExpr(minPrecedence int) (astNode, error) {
+	left, err := p.parsePrefix()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind != tokenOp {
+			return left, nil
+		}
+		if tok.text == "?" {
+			if precedenceTernary < minPrecedence {
+				return left, nil
+			}
+			p.next()
+			then, err := p.parseExpr(0)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			// 右结合: a ? b : c ? d : e == a ? b : (c ? d : e)
+			otherwise, err := p.parseExpr(precedenceTernary)
+			if err != nil {
+				return nil, err
+			}
+			left = &ternaryNode{cond: left, then: then, otherwise: otherwise}
+			continue
+		}
+		precedence, ok := binaryPrecedence[tok.text]
+		if !ok || precedence < minPrecedence {
+			return left, nil
+		}
+		p.next()
+		// 左结合, 右侧只解析更高优先级的运算. ?? 为右结合.
+		nextPrecedence := precedence + 1
+		if tok.text == "??" {
+			nextPrecedence = precedence
+		}
+		right, err := p.parseExpr(nextPrecedence)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: tok.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parsePrefix() (astNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenInt:
+		v, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid int %s at %d", tok.text, tok.pos)
+		}
+		return &literalNode{value: v}, nil
+	case tokenFloat:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float %s at %d", tok.text, tok.pos)
+		}
+		return &literalNode{value: v}, nil
+	case tokenString:
+		return &literalNode{value: tok.text}, nil
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if next := p.peek(); next.kind == tokenOp && next.text == "(" {
+			p.next()
+			return p.parseCall(tok.text)
+		}
+		return &identNode{name: tok.text}, nil
+	case tokenOp:
+		switch tok.text {
+		case "(":
+			node, err := p.parseExpr(0)
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expect(")")
+		case "-", "!":
+			operand, err := p.parseExpr(precedenceUnary)
+			if err != nil {
+				return nil, err
+			}
+			return &unaryNode{op: tok.text, operand: operand}, nil
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", tok.text, tok.pos)
+}
+
+func (p *parser) parseCall(name string) (astNode, error) {
+	call := &callNode{name: name, args: []astNode{}}
+	if tok := p.peek(); tok.kind == tokenOp && tok.text == ")" {
+		p.next()
+		return call, nil
+	}
+	for {
+		arg, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		tok := p.next()
+		if tok.kind == tokenOp && tok.text == ")" {
+			return call, nil
+		}
+		if tok.kind != tokenOp || tok.text != "," {
+			return nil, fmt.Errorf("expect \",\" or \")\" in call %s at %d", name, tok.pos)
+		}
+	}
+}
@@ -25,6 +25,8 @@ type Field struct {
 	NotExport     bool                   `json:"not_export"`       // 该字段是否存到 dag.result 里
 	DelaySupply   time.Duration          `json:"delay_supply"`     // 单位毫秒
 	AutoNilToZero bool                   `json:"auto_nil_to_zero"` // 当字段时是 nil 是, 是否自动转为类型零值
+	Retry         *RetryPolicy           `json:"retry"`            // 补数失败时的重试策略, 为空时不重试
+	Fallbacks     []*FieldSource         `json:"fallbacks"`        // 备用数据源, 按顺序在主数据源失败时调用
 	Meta          map[string]interface{} `json:"meta"`             // 用户自己定义的元数据
 }
 
@@ -44,6 +46,16 @@ func (field *Field) Validate() error {
 	if int(field.OnError) > len(OnErrorHandlerNames) {
 		return fmt.Errorf("on_error validate error: %s", field.OnError)
 	}
+	if field.Retry != nil {
+		if err := field.Retry.Validate(); err != nil {
+			return err
+		}
+	}
+	for i, source := range field.Fallbacks {
+		if err := source.Validate(); err != nil {
+			return fmt.Errorf("field [%s] fallback %d validate error: %s", field.Code, i, err.Error())
+		}
+	}
 	return nil
 }
 
@@ -54,6 +66,36 @@ func (field *Field) LoadDefault() {
 	if field.Timeout == 0 {
 		field.Timeout = DefaultTimeout
 	}
+	if field.Retry != nil {
+		field.Retry.LoadDefault()
+	}
+	for _, source := range field.Fallbacks {
+		source.LoadDefault(field)
+	}
+}
+
+// Copy 返回字段的副本. LoadDefault 会修改 Retry 和 Fallbacks, 所以一并复制; DefaultValue, Meta 等值共享.
+func (field *Field) Copy() *Field {
+	f := *field
+	if field.Retry != nil {
+		f.Retry = field.Retry.copy()
+	}
+	if field.Fallbacks != nil {
+		f.Fallbacks = make([]*FieldSource, len(field.Fallbacks))
+		for i, source := range field.Fallbacks {
+			f.Fallbacks[i] = source.copy()
+		}
+	}
+	return &f
+}
+
+// 字段补数的最长时间, 包括所有备用数据源的调用时间.
+func (field *Field) totalTimeout() time.Duration {
+	timeout := field.Timeout
+	for _, source := range field.Fallbacks {
+		timeout += source.Timeout
+	}
+	return timeout
 }
 
 func (field *Field) ValueOnError(failReason string) *FieldResult {
@@ -0,0 +1,76 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+)
+
+// FieldSource 字段的备用数据源. 节点的主数据源(supplier+func)失败或超时时, 按照 Field.Fallbacks 的顺序依次调用,
+// 直到某个数据源成功. 产生字段值的备用数据源会记录在 FieldMeta.Source 中.
+type FieldSource struct {
+	Supplier      supplier.ISupplier `json:"supplier"`
+	FuncName      string             `json:"func_name"`
+	Params        []Param            `json:"params"`
+	FieldOfSupply string             `json:"field_of_supply"` // 为空时使用 Field.FieldOfSupply
+	Timeout       time.Duration      `json:"timeout"`         // 单次调用的超时时间, 为空时使用 Field.Timeout
+}
+
+func (source *FieldSource) LoadDefault(field *Field) {
+	if source.FieldOfSupply == "" {
+		source.FieldOfSupply = field.FieldOfSupply
+	}
+	if source.Timeout == 0 {
+		source.Timeout = field.Timeout
+	}
+	for i := range source.Params {
+		source.Params[i].LoadDefault()
+	}
+}
+
+func (source *FieldSource) Validate() error {
+	if source.Supplier == nil {
+		return errors.New("fallback must have supplier")
+	}
+	if source.FuncName == "" {
+		return errors.New("fallback must have func_name")
+	}
+	for _, param := range source.Params {
+		if err := param.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (source *FieldSource) copy() *FieldSource {
+	s := *source
+	s.Params = append([]Param(nil), source.Params...)
+	return &s
+}
+
+// Name 数据源的名称, 记录在 FieldMeta.Source 中. supplier_name.func_name
+func (source *FieldSource) Name() string {
+	return source.Supplier.GetName() + "." + source.FuncName
+}
+
+// 数据源的唯一标识, 与 nodeid 的生成方式相同. 一次执行中相同的数据源只调用一次.
+func (source *FieldSource) id() string {
+	builder := strings.Builder{}
+	builder.WriteString(source.Supplier.GetName())
+	builder.WriteString("_")
+	builder.WriteString(source.FuncName)
+	for _, param := range source.Params {
+		builder.WriteString("_")
+		builder.WriteString(param.ID)
+	}
+	return builder.String()
+}
+
+// 备用数据源的参数在 node 中所属的函数名.
+func fallbackFunc(fieldCode string, i int) string {
+	return fmt.Sprintf("fallback_%s_%d", fieldCode, i)
+}
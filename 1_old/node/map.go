@@ -0,0 +1,251 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"git.in.zhihu.com/antispam/datasupply/utils"
+)
+
+const DefaultMapConcurrent = 8
+
+// 映射模式下部分元素调用失败时的处理方式.
+// fail_all: 任一元素失败则节点失败; drop_failed: 丢弃失败的元素;
+// null_failed: 失败的元素结果为 nil. 字段值为 []interface{}, 成功的元素转换为字段数组元素的类型(见 mapFieldElemTypes),
+// 从而区分失败的元素和零值(如 0, "").
+type MapFailureMode int
+
+const (
+	MapFailAll MapFailureMode = iota
+	MapDropFailed
+	MapNullFailed
+)
+
+var MapFailureModeNames = []string{
+	"fail_all",
+	"drop_failed",
+	"null_failed",
+}
+
+func (s MapFailureMode) String() string {
+	if int(s) >= 0 && int(s) < len(MapFailureModeNames) {
+		return MapFailureModeNames[s]
+	}
+	return "map_failure_mode_" + strconv.Itoa(int(s))
+}
+
+func (s MapFailureMode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *MapFailureMode) UnmarshalJSON(b []byte) error {
+	str := ""
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	for mode, name := range MapFailureModeNames {
+		if name == str {
+			*s = MapFailureMode(mode)
+			return nil
+		}
+	}
+	return errors.New("unknown map failure mode " + str)
+}
+
+// MapPolicy 节点的映射模式. 对数组参数的每个元素调用一次插件, 再将插件每个输出 key 的结果按元素顺序聚合为数组.
+// 节点字段的 FieldOfSupply 为单次调用的输出 key, FieldType 为聚合后的数组类型.
+// 每个元素的调用单独重试, 所有元素的调用共享节点的超时时间.
+type MapPolicy struct {
+	ParamIndex int            `json:"param_index"` // 被映射的参数在 params 中的下标, 参数类型必须为 ArrayString/ArrayInt64
+	Concurrent int            `json:"concurrent"`  // 元素并发调用数, 默认为 DefaultMapConcurrent
+	OnFailure  MapFailureMode `json:"on_failure"`  // 部分元素失败时的处理方式
+}
+
+func (policy *MapPolicy) LoadDefault() {
+	if policy.Concurrent == 0 {
+		policy.Concurrent = DefaultMapConcurrent
+	}
+}
+
+func (policy *MapPolicy) Validate(params []Param, fields []*Field) error {
+	if policy.Concurrent < 0 {
+		return errors.New("map.concurrent can not be negative")
+	}
+	if policy.OnFailure < MapFailAll || policy.OnFailure > MapNullFailed {
+		return fmt.Errorf("map.on_failure %s not supported", policy.OnFailure)
+	}
+	if policy.ParamIndex < 0 || policy.ParamIndex >= len(params) {
+		return fmt.Errorf("map.param_index %d out of range, params len %d", policy.ParamIndex, len(params))
+	}
+	param := params[policy.ParamIndex]
+	if _, ok := mapElemType(param.ValueType); !ok {
+		return fmt.Errorf("map param [%s] type must be %s or %s, got %s",
+			param.ID, dtype.ArrayString, dtype.ArrayInt64, param.ValueType)
+	}
+	if policy.OnFailure == MapNullFailed {
+		for _, field := range fields {
+			if _, ok := mapFieldElemTypes[field.FieldType]; !ok {
+				return fmt.Errorf("map.on_failure %s not supported for field [%s] type %s",
+					policy.OnFailure, field.Code, field.FieldType)
+			}
+		}
+	}
+	return nil
+}
+
+// ID 参与生成节点 ID, 映射方式不同的节点不会被合并.
+func (policy *MapPolicy) ID() string {
+	return "map_" + strconv.Itoa(policy.ParamIndex) + "_" + policy.OnFailure.String()
+}
+
+// 数组参数元素的类型.
+func mapElemType(arrayType dtype.DType) (dtype.DType, bool) {
+	switch arrayType {
+	case dtype.ArrayString:
+		return dtype.String, true
+	case dtype.ArrayInt64:
+		return dtype.Int64, true
+	}
+	return 0, false
+}
+
+// null_failed 模式下字段类型对应的数组元素类型.
+var mapFieldElemTypes = map[dtype.DType]dtype.DType{
+	dtype.ArrayInt64:   dtype.Int64,
+	dtype.ArrayUint64:  dtype.Uint64,
+	dtype.ArrayFloat64: dtype.Float64,
+	dtype.ArrayString:  dtype.String,
+	dtype.ArrayBool:    dtype.Bool,
+	dtype.ArrayMap:     dtype.Map,
+}
+
+// null_failed 模式下的聚合结果, 失败的元素为 nil.
+type nullableItems []interface{}
+
+// 按元素转换为字段数组元素的类型, 保留失败元素的 nil.
+func (items nullableItems) convert(fieldType dtype.DType) ([]interface{}, error) {
+	elemType := mapFieldElemTypes[fieldType]
+	values := make([]interface{}, len(items))
+	for i, item := range items {
+		if item == nil {
+			continue
+		}
+		value, err := dtype.Convert(item, elemType)
+		if err != nil {
+			return nil, fmt.Errorf("map item [%d]: %w", i, err)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// 将被映射的参数值转换为元素列表.
+func mapItems(value interface{}, arrayType dtype.DType) ([]interface{}, error) {
+	value, err := dtype.Convert(value, arrayType)
+	if err != nil {
+		return nil, err
+	}
+	var items []interface{}
+	switch value := value.(type) {
+	case []string:
+		items = make([]interface{}, len(value))
+		for i, v := range value {
+			items[i] = v
+		}
+	case []int64:
+		items = make([]interface{}, len(value))
+		for i, v := range value {
+			items[i] = v
+		}
+	}
+	return items, nil
+}
+
+// 按照映射模式调用 supplier, 返回值的 key 为字段的 FieldOfSupply, value 为按元素顺序聚合的数组,
+// null_failed 模式下为 nullableItems.
+// fail_all 模式下返回第一个失败元素的错误, 调用元数据为所有元素调用的汇总.
+func (node *Node) supplyMap(ctx context.Context, params []interface{}) (map[string]interface{}, supplyMeta, error) {
+	policy := node.mapPolicy
+	items, err := mapItems(params[policy.ParamIndex], node.GetParamsByFunc(SupplierFunc)[policy.ParamIndex].ValueType)
+	if err != nil {
+		return nil, supplyMeta{}, fmt.Errorf("map param convert error: %s", err.Error())
+	}
+
+	type itemResult struct {
+		supplyFields map[string]interface{}
+		meta         supplyMeta
+		err          error
+	}
+	results := make([]itemResult, len(items))
+	sem := make(chan struct{}, policy.Concurrent)
+	wg := sync.WaitGroup{}
+	for i, item := range items {
+		select {
+		case <-ctx.Done():
+			results[i].err = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+		itemParams := make([]interface{}, len(params))
+		copy(itemParams, params)
+		itemParams[policy.ParamIndex] = item
+		wg.Add(1)
+		i := i
+		// 释放并发和 wg.Done 只在这里执行一次, panic 时只记录错误
+		go func() {
+			defer func() { <-sem }()
+			defer wg.Done()
+			err := utils.SafelyRun(func() {
+				supplyFields, meta, err := node.supply(ctx, node.supplier, node.funcName, itemParams)
+				results[i] = itemResult{supplyFields: supplyFields, meta: meta, err: err}
+			})
+			if err != nil {
+				results[i] = itemResult{err: fmt.Errorf("panic: %v", err)}
+			}
+		}()
+	}
+	wg.Wait()
+
+	meta := supplyMeta{cacheHit: len(items) > 0}
+	for _, r := range results {
+		meta.attempts += r.meta.attempts
+		meta.cacheHit = meta.cacheHit && r.meta.cacheHit
+	}
+
+	outputs := make(map[string][]interface{}, len(node.fields))
+	for _, field := range node.fields {
+		outputs[field.FieldOfSupply] = make([]interface{}, 0, len(items))
+	}
+	for i, r := range results {
+		if r.err != nil {
+			switch policy.OnFailure {
+			case MapDropFailed:
+				node.logger.Warnf(ctx, "node [%s] map item [%d] dropped, error [%v]", node.id, i, r.err)
+				continue
+			case MapNullFailed:
+				node.logger.Warnf(ctx, "node [%s] map item [%d] set to nil, error [%v]", node.id, i, r.err)
+			default:
+				return nil, meta, fmt.Errorf("map item [%d] error: %w", i, r.err)
+			}
+		}
+		for key := range outputs {
+			// 失败的元素 supplyFields 为 nil, 取到的值为 nil
+			outputs[key] = append(outputs[key], r.supplyFields[key])
+		}
+	}
+
+	supplyFields := make(map[string]interface{}, len(outputs))
+	for key, values := range outputs {
+		if policy.OnFailure == MapNullFailed {
+			supplyFields[key] = nullableItems(values)
+			continue
+		}
+		supplyFields[key] = values
+	}
+	return supplyFields, meta, nil
+}
@@ -56,11 +56,12 @@ func (mw *Middleware) MiddlewareFunc() MiddlewareFunc {
 	return mw.mf
 }
 
-// 打点中间点. 记录 node 执行次数, 失败次数, 执行时间(包含下游中间件), 字段执行失败统计.
+// 打点中间点. 记录 node 执行次数, 失败次数, 重试次数, 执行时间(包含下游中间件), 字段执行失败统计.
 func StatsdMiddleware(statd statsd.IStatsd, nodeName string) IMiddleware {
 	nodeRunCount := fmt.Sprintf(constant.NodeRunCount, nodeName)
 	nodeRunSpeed := fmt.Sprintf(constant.NodeRunSpeed, nodeName)
 	nodeRunError := fmt.Sprintf(constant.NodeRunError, nodeName)
+	nodeRunRetry := fmt.Sprintf(constant.NodeRunRetry, nodeName)
 	mf := func(next Handler) Handler {
 		return func(ctx context.Context, paramMap map[string]interface{}) Result {
 			statd.Increment(nodeRunCount)
@@ -68,15 +69,23 @@ func StatsdMiddleware(statd statsd.IStatsd, nodeName string) IMiddleware {
 			result := next(ctx, paramMap)
 			// 记录字段补数失败次数
 			hasFieldError := false
+			attempts := 0
 			for field, fieldResult := range result {
 				if !fieldResult.IsSupplySuccess() {
 					hasFieldError = true
 					statd.Increment(fmt.Sprintf(constant.NodeFieldSupplyError, nodeName+"."+field))
 				}
+				if fieldResult.Meta.GetAttempts() > attempts {
+					attempts = fieldResult.Meta.GetAttempts()
+				}
 			}
 			if hasFieldError {
 				statd.Increment(nodeRunError)
 			}
+			// 记录重试次数, 首次调用不计入
+			if attempts > 1 {
+				statd.Count(nodeRunRetry, int64(attempts-1))
+			}
 			return result
 		}
 	}
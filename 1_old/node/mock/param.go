@@ -48,6 +48,20 @@ func (mr *MockINodeParamsMockRecorder) AddFuncParams(funcName, funcParams interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFuncParams", reflect.TypeOf((*MockINodeParams)(nil).AddFuncParams), funcName, funcParams)
 }
 
+// AddOptionalFuncParams mocks base method.
+func (m *MockINodeParams) AddOptionalFuncParams(funcName string, funcParams []node.Param) node.INodeParams {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOptionalFuncParams", funcName, funcParams)
+	ret0, _ := ret[0].(node.INodeParams)
+	return ret0
+}
+
+// AddOptionalFuncParams indicates an expected call of AddOptionalFuncParams.
+func (mr *MockINodeParamsMockRecorder) AddOptionalFuncParams(funcName, funcParams interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOptionalFuncParams", reflect.TypeOf((*MockINodeParams)(nil).AddOptionalFuncParams), funcName, funcParams)
+}
+
 // GetParamMap mocks base method.
 func (m *MockINodeParams) GetParamMap() map[string][]node.Param {
 	m.ctrl.T.Helper()
@@ -4,11 +4,18 @@ const (
 	FieldFailReson_ValueIsNil               = "field_value_is_nil"
 	FieldFailReson_NotFoundInSupplyResponse = "field_not_found_in_supply_response"
 	FieldFailReson_TypeConvertError         = "type_convert_error"
+	FieldFailReson_CircuitOpen              = "circuit_open"
+	FieldFailReson_RateLimited              = "rate_limited"
+	FieldFailReson_Skipped                  = "skipped"          // 节点的执行条件(When)不满足
+	FieldFailReson_SchemaViolation          = "schema_violation" // 值不符合对象类型的 schema
 )
 
 //go:generate msgp
 type FieldMeta struct {
 	FailReason string `json:"fail_reason"`
+	Attempts   int    `json:"attempts"`  // supplier 调用次数, 包含重试
+	CacheHit   bool   `json:"cache_hit"` // 是否命中 supplier 缓存
+	Source     string `json:"source"`    // 产生字段值的备用数据源(FieldSource.Name), 为空时表示主数据源
 }
 
 // func (Meta) Marshal()   {}
@@ -18,6 +25,18 @@ func (meta FieldMeta) GetFailReason() string {
 	return meta.FailReason
 }
 
+func (meta FieldMeta) GetAttempts() int {
+	return meta.Attempts
+}
+
+func (meta FieldMeta) IsCacheHit() bool {
+	return meta.CacheHit
+}
+
+func (meta FieldMeta) GetSource() string {
+	return meta.Source
+}
+
 // 每一个 Field 对应一个 Result.
 //go:generate msgp
 type FieldResult struct {
@@ -30,6 +30,24 @@ func (z *FieldMeta) DecodeMsg(dc *msgp.Reader) (err error) {
 				err = msgp.WrapError(err, "FailReason")
 				return
 			}
+		case "Attempts":
+			z.Attempts, err = dc.ReadInt()
+			if err != nil {
+				err = msgp.WrapError(err, "Attempts")
+				return
+			}
+		case "CacheHit":
+			z.CacheHit, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "CacheHit")
+				return
+			}
+		case "Source":
+			z.Source, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "Source")
+				return
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -42,10 +60,10 @@ func (z *FieldMeta) DecodeMsg(dc *msgp.Reader) (err error) {
 }
 
 // EncodeMsg implements msgp.Encodable
-func (z FieldMeta) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 1
+func (z *FieldMeta) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 4
 	// write "FailReason"
-	err = en.Append(0x81, 0xaa, 0x46, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e)
+	err = en.Append(0x84, 0xaa, 0x46, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e)
 	if err != nil {
 		return
 	}
@@ -54,16 +72,55 @@ func (z FieldMeta) EncodeMsg(en *msgp.Writer) (err error) {
 		err = msgp.WrapError(err, "FailReason")
 		return
 	}
+	// write "Attempts"
+	err = en.Append(0xa8, 0x41, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt(z.Attempts)
+	if err != nil {
+		err = msgp.WrapError(err, "Attempts")
+		return
+	}
+	// write "CacheHit"
+	err = en.Append(0xa8, 0x43, 0x61, 0x63, 0x68, 0x65, 0x48, 0x69, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.CacheHit)
+	if err != nil {
+		err = msgp.WrapError(err, "CacheHit")
+		return
+	}
+	// write "Source"
+	err = en.Append(0xa6, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.Source)
+	if err != nil {
+		err = msgp.WrapError(err, "Source")
+		return
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
-func (z FieldMeta) MarshalMsg(b []byte) (o []byte, err error) {
+func (z *FieldMeta) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 1
+	// map header, size 4
 	// string "FailReason"
-	o = append(o, 0x81, 0xaa, 0x46, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e)
+	o = append(o, 0x84, 0xaa, 0x46, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e)
 	o = msgp.AppendString(o, z.FailReason)
+	// string "Attempts"
+	o = append(o, 0xa8, 0x41, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x73)
+	o = msgp.AppendInt(o, z.Attempts)
+	// string "CacheHit"
+	o = append(o, 0xa8, 0x43, 0x61, 0x63, 0x68, 0x65, 0x48, 0x69, 0x74)
+	o = msgp.AppendBool(o, z.CacheHit)
+	// string "Source"
+	o = append(o, 0xa6, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65)
+	o = msgp.AppendString(o, z.Source)
 	return
 }
 
@@ -91,6 +148,24 @@ func (z *FieldMeta) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				err = msgp.WrapError(err, "FailReason")
 				return
 			}
+		case "Attempts":
+			z.Attempts, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Attempts")
+				return
+			}
+		case "CacheHit":
+			z.CacheHit, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "CacheHit")
+				return
+			}
+		case "Source":
+			z.Source, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Source")
+				return
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -104,8 +179,8 @@ func (z *FieldMeta) UnmarshalMsg(bts []byte) (o []byte, err error) {
 }
 
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
-func (z FieldMeta) Msgsize() (s int) {
-	s = 1 + 11 + msgp.StringPrefixSize + len(z.FailReason)
+func (z *FieldMeta) Msgsize() (s int) {
+	s = 1 + 11 + msgp.StringPrefixSize + len(z.FailReason) + 9 + msgp.IntSize + 9 + msgp.BoolSize + 7 + msgp.StringPrefixSize + len(z.Source)
 	return
 }
 
@@ -128,34 +203,11 @@ func (z *FieldResult) DecodeMsg(dc *msgp.Reader) (err error) {
 		}
 		switch msgp.UnsafeString(field) {
 		case "Meta":
-			var zb0002 uint32
-			zb0002, err = dc.ReadMapHeader()
+			err = z.Meta.DecodeMsg(dc)
 			if err != nil {
 				err = msgp.WrapError(err, "Meta")
 				return
 			}
-			for zb0002 > 0 {
-				zb0002--
-				field, err = dc.ReadMapKeyPtr()
-				if err != nil {
-					err = msgp.WrapError(err, "Meta")
-					return
-				}
-				switch msgp.UnsafeString(field) {
-				case "FailReason":
-					z.Meta.FailReason, err = dc.ReadString()
-					if err != nil {
-						err = msgp.WrapError(err, "Meta", "FailReason")
-						return
-					}
-				default:
-					err = dc.Skip()
-					if err != nil {
-						err = msgp.WrapError(err, "Meta")
-						return
-					}
-				}
-			}
 		case "Value":
 			z.Value, err = dc.ReadIntf()
 			if err != nil {
@@ -181,15 +233,9 @@ func (z *FieldResult) EncodeMsg(en *msgp.Writer) (err error) {
 	if err != nil {
 		return
 	}
-	// map header, size 1
-	// write "FailReason"
-	err = en.Append(0x81, 0xaa, 0x46, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e)
+	err = z.Meta.EncodeMsg(en)
 	if err != nil {
-		return
-	}
-	err = en.WriteString(z.Meta.FailReason)
-	if err != nil {
-		err = msgp.WrapError(err, "Meta", "FailReason")
+		err = msgp.WrapError(err, "Meta")
 		return
 	}
 	// write "Value"
@@ -211,10 +257,11 @@ func (z *FieldResult) MarshalMsg(b []byte) (o []byte, err error) {
 	// map header, size 2
 	// string "Meta"
 	o = append(o, 0x82, 0xa4, 0x4d, 0x65, 0x74, 0x61)
-	// map header, size 1
-	// string "FailReason"
-	o = append(o, 0x81, 0xaa, 0x46, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e)
-	o = msgp.AppendString(o, z.Meta.FailReason)
+	o, err = z.Meta.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Meta")
+		return
+	}
 	// string "Value"
 	o = append(o, 0xa5, 0x56, 0x61, 0x6c, 0x75, 0x65)
 	o, err = msgp.AppendIntf(o, z.Value)
@@ -244,34 +291,11 @@ func (z *FieldResult) UnmarshalMsg(bts []byte) (o []byte, err error) {
 		}
 		switch msgp.UnsafeString(field) {
 		case "Meta":
-			var zb0002 uint32
-			zb0002, bts, err = msgp.ReadMapHeaderBytes(bts)
+			bts, err = z.Meta.UnmarshalMsg(bts)
 			if err != nil {
 				err = msgp.WrapError(err, "Meta")
 				return
 			}
-			for zb0002 > 0 {
-				zb0002--
-				field, bts, err = msgp.ReadMapKeyZC(bts)
-				if err != nil {
-					err = msgp.WrapError(err, "Meta")
-					return
-				}
-				switch msgp.UnsafeString(field) {
-				case "FailReason":
-					z.Meta.FailReason, bts, err = msgp.ReadStringBytes(bts)
-					if err != nil {
-						err = msgp.WrapError(err, "Meta", "FailReason")
-						return
-					}
-				default:
-					bts, err = msgp.Skip(bts)
-					if err != nil {
-						err = msgp.WrapError(err, "Meta")
-						return
-					}
-				}
-			}
 		case "Value":
 			z.Value, bts, err = msgp.ReadIntfBytes(bts)
 			if err != nil {
@@ -292,7 +316,7 @@ func (z *FieldResult) UnmarshalMsg(bts []byte) (o []byte, err error) {
 
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z *FieldResult) Msgsize() (s int) {
-	s = 1 + 5 + 1 + 11 + msgp.StringPrefixSize + len(z.Meta.FailReason) + 6 + msgp.GuessSize(z.Value)
+	s = 1 + 5 + z.Meta.Msgsize() + 6 + msgp.GuessSize(z.Value)
 	return
 }
 
@@ -480,34 +504,11 @@ func (z *Result) DecodeMsg(dc *msgp.Reader) (err error) {
 				}
 				switch msgp.UnsafeString(field) {
 				case "Meta":
-					var zb0005 uint32
-					zb0005, err = dc.ReadMapHeader()
+					err = zb0002.Meta.DecodeMsg(dc)
 					if err != nil {
 						err = msgp.WrapError(err, zb0001, "Meta")
 						return
 					}
-					for zb0005 > 0 {
-						zb0005--
-						field, err = dc.ReadMapKeyPtr()
-						if err != nil {
-							err = msgp.WrapError(err, zb0001, "Meta")
-							return
-						}
-						switch msgp.UnsafeString(field) {
-						case "FailReason":
-							zb0002.Meta.FailReason, err = dc.ReadString()
-							if err != nil {
-								err = msgp.WrapError(err, zb0001, "Meta", "FailReason")
-								return
-							}
-						default:
-							err = dc.Skip()
-							if err != nil {
-								err = msgp.WrapError(err, zb0001, "Meta")
-								return
-							}
-						}
-					}
 				case "Value":
 					zb0002.Value, err = dc.ReadIntf()
 					if err != nil {
@@ -535,13 +536,13 @@ func (z Result) EncodeMsg(en *msgp.Writer) (err error) {
 		err = msgp.WrapError(err)
 		return
 	}
-	for zb0006, zb0007 := range z {
-		err = en.WriteString(zb0006)
+	for zb0005, zb0006 := range z {
+		err = en.WriteString(zb0005)
 		if err != nil {
 			err = msgp.WrapError(err)
 			return
 		}
-		if zb0007 == nil {
+		if zb0006 == nil {
 			err = en.WriteNil()
 			if err != nil {
 				return
@@ -553,15 +554,9 @@ func (z Result) EncodeMsg(en *msgp.Writer) (err error) {
 			if err != nil {
 				return
 			}
-			// map header, size 1
-			// write "FailReason"
-			err = en.Append(0x81, 0xaa, 0x46, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e)
-			if err != nil {
-				return
-			}
-			err = en.WriteString(zb0007.Meta.FailReason)
+			err = zb0006.Meta.EncodeMsg(en)
 			if err != nil {
-				err = msgp.WrapError(err, zb0006, "Meta", "FailReason")
+				err = msgp.WrapError(err, zb0005, "Meta")
 				return
 			}
 			// write "Value"
@@ -569,9 +564,9 @@ func (z Result) EncodeMsg(en *msgp.Writer) (err error) {
 			if err != nil {
 				return
 			}
-			err = en.WriteIntf(zb0007.Value)
+			err = en.WriteIntf(zb0006.Value)
 			if err != nil {
-				err = msgp.WrapError(err, zb0006, "Value")
+				err = msgp.WrapError(err, zb0005, "Value")
 				return
 			}
 		}
@@ -583,23 +578,24 @@ func (z Result) EncodeMsg(en *msgp.Writer) (err error) {
 func (z Result) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
 	o = msgp.AppendMapHeader(o, uint32(len(z)))
-	for zb0006, zb0007 := range z {
-		o = msgp.AppendString(o, zb0006)
-		if zb0007 == nil {
+	for zb0005, zb0006 := range z {
+		o = msgp.AppendString(o, zb0005)
+		if zb0006 == nil {
 			o = msgp.AppendNil(o)
 		} else {
 			// map header, size 2
 			// string "Meta"
 			o = append(o, 0x82, 0xa4, 0x4d, 0x65, 0x74, 0x61)
-			// map header, size 1
-			// string "FailReason"
-			o = append(o, 0x81, 0xaa, 0x46, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e)
-			o = msgp.AppendString(o, zb0007.Meta.FailReason)
+			o, err = zb0006.Meta.MarshalMsg(o)
+			if err != nil {
+				err = msgp.WrapError(err, zb0005, "Meta")
+				return
+			}
 			// string "Value"
 			o = append(o, 0xa5, 0x56, 0x61, 0x6c, 0x75, 0x65)
-			o, err = msgp.AppendIntf(o, zb0007.Value)
+			o, err = msgp.AppendIntf(o, zb0006.Value)
 			if err != nil {
-				err = msgp.WrapError(err, zb0006, "Value")
+				err = msgp.WrapError(err, zb0005, "Value")
 				return
 			}
 		}
@@ -658,34 +654,11 @@ func (z *Result) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				}
 				switch msgp.UnsafeString(field) {
 				case "Meta":
-					var zb0005 uint32
-					zb0005, bts, err = msgp.ReadMapHeaderBytes(bts)
+					bts, err = zb0002.Meta.UnmarshalMsg(bts)
 					if err != nil {
 						err = msgp.WrapError(err, zb0001, "Meta")
 						return
 					}
-					for zb0005 > 0 {
-						zb0005--
-						field, bts, err = msgp.ReadMapKeyZC(bts)
-						if err != nil {
-							err = msgp.WrapError(err, zb0001, "Meta")
-							return
-						}
-						switch msgp.UnsafeString(field) {
-						case "FailReason":
-							zb0002.Meta.FailReason, bts, err = msgp.ReadStringBytes(bts)
-							if err != nil {
-								err = msgp.WrapError(err, zb0001, "Meta", "FailReason")
-								return
-							}
-						default:
-							bts, err = msgp.Skip(bts)
-							if err != nil {
-								err = msgp.WrapError(err, zb0001, "Meta")
-								return
-							}
-						}
-					}
 				case "Value":
 					zb0002.Value, bts, err = msgp.ReadIntfBytes(bts)
 					if err != nil {
@@ -711,13 +684,13 @@ func (z *Result) UnmarshalMsg(bts []byte) (o []byte, err error) {
 func (z Result) Msgsize() (s int) {
 	s = msgp.MapHeaderSize
 	if z != nil {
-		for zb0006, zb0007 := range z {
-			_ = zb0007
-			s += msgp.StringPrefixSize + len(zb0006)
-			if zb0007 == nil {
+		for zb0005, zb0006 := range z {
+			_ = zb0006
+			s += msgp.StringPrefixSize + len(zb0005)
+			if zb0006 == nil {
 				s += msgp.NilSize
 			} else {
-				s += 1 + 5 + 1 + 11 + msgp.StringPrefixSize + len(zb0007.Meta.FailReason) + 6 + msgp.GuessSize(zb0007.Value)
+				s += 1 + 5 + zb0006.Meta.Msgsize() + 6 + msgp.GuessSize(zb0006.Value)
 			}
 		}
 	}
@@ -17,10 +17,12 @@ package node
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"git.in.zhihu.com/antispam/datasupply/constant"
 	"git.in.zhihu.com/antispam/datasupply/dtype"
 	"git.in.zhihu.com/antispam/datasupply/log"
 	"git.in.zhihu.com/antispam/datasupply/supplier"
@@ -41,6 +43,9 @@ type INode interface {
 	GetFieldCodes() []string
 	GetTimeout() time.Duration
 	GetDelaySupply() time.Duration
+	GetSupplier() supplier.ISupplier
+	GetFuncName() string
+	GetMapPolicy() *MapPolicy
 
 	// 动作
 	CreateRuntime() IRuntime
@@ -80,8 +85,15 @@ type Node struct {
 	fields      []*Field      // 节点对外输出的字段, code 重复会覆盖
 	fieldCodes  []string      // field.code 集合
 	supplyStage SupplyStage   // min(fields.supplyStage)
-	timeout     time.Duration // max(fields.timeout)
+	timeout     time.Duration // max(fields.timeout + sum(fields.fallbacks.timeout))
 	delaySupply time.Duration // max(delaySupply)
+	retry       *RetryPolicy  // request.retry 或 max(fields.retry.max_attempts)
+	when        *WhenCondition
+	mapPolicy   *MapPolicy // 映射模式, 为空时不映射
+
+	// 备用数据源
+	hasFallback   bool          // 是否有字段配置了备用数据源
+	supplyTimeout time.Duration // 有备用数据源时, 主数据源的超时时间. max(fields.timeout)
 
 	// 中间件
 	mwchain     Handler
@@ -103,14 +115,25 @@ func New(request *CreateNodeRequest, options ...Option) (*Node, error) {
 
 	params := NewNodeParams()
 	params.AddFuncParams(SupplierFunc, request.Params)
+	if request.When != nil {
+		params.AddFuncParams(WhenFunc, request.When.params)
+	}
 
-	var timeout, delaySupply time.Duration
+	var timeout, supplyTimeout, delaySupply time.Duration
+	hasFallback := false
 	supplyStage := SupplyStageLazy
 	fieldCodes := make([]string, len(request.Fields))
 	for i, field := range request.Fields {
 		fieldCodes[i] = field.Code
-		if field.Timeout > timeout {
-			timeout = field.Timeout
+		if field.totalTimeout() > timeout {
+			timeout = field.totalTimeout()
+		}
+		if field.Timeout > supplyTimeout {
+			supplyTimeout = field.Timeout
+		}
+		for j, source := range field.Fallbacks {
+			hasFallback = true
+			params.AddOptionalFuncParams(fallbackFunc(field.Code, j), source.Params)
 		}
 		if supplyStage > field.SupplyStage {
 			supplyStage = field.SupplyStage
@@ -120,26 +143,39 @@ func New(request *CreateNodeRequest, options ...Option) (*Node, error) {
 		}
 	}
 
+	retry := request.Retry
+	if retry == nil {
+		retry = mergeRetryPolicy(request.Fields)
+	}
+
 	logger := request.Logger
 	if logger == nil {
 		logger = log.NewDefaultLog()
 	}
 
 	node := &Node{
-		id:          request.GenNodeID(),
-		supplier:    request.Supplier,
-		funcName:    request.FuncName,
-		NodeParams:  params,
-		fields:      request.Fields,
-		fieldCodes:  fieldCodes,
-		supplyStage: supplyStage,
-		timeout:     timeout,
-		delaySupply: delaySupply,
-		middlewares: []IMiddleware{},
-		mwChainLock: &sync.Mutex{},
-		logger:      logger,
+		id:            request.GenNodeID(),
+		supplier:      request.Supplier,
+		funcName:      request.FuncName,
+		NodeParams:    params,
+		fields:        request.Fields,
+		fieldCodes:    fieldCodes,
+		supplyStage:   supplyStage,
+		timeout:       timeout,
+		supplyTimeout: supplyTimeout,
+		hasFallback:   hasFallback,
+		delaySupply:   delaySupply,
+		retry:         retry,
+		when:          request.When,
+		mapPolicy:     request.Map,
+		middlewares:   []IMiddleware{},
+		mwChainLock:   &sync.Mutex{},
+		logger:        logger,
 	}
 	node.mwchain = node.handler
+	if err := ValidateSchema(node); err != nil {
+		return &Node{}, err
+	}
 	for _, option := range options {
 		option(node)
 	}
@@ -147,14 +183,7 @@ func New(request *CreateNodeRequest, options ...Option) (*Node, error) {
 }
 
 func (node *Node) CreateRuntime() IRuntime {
-	paramsLen := len(node.GetParamVariables())
-	return &Runtime{
-		varParams: map[string]interface{}{},
-		node:      node,
-		nodeid:    node.GetID(),
-		paramCnt:  int64(paramsLen),
-		locker:    &sync.Mutex{},
-	}
+	return newRuntime(node)
 }
 
 func (node *Node) Use(middlewares ...IMiddleware) {
@@ -172,6 +201,18 @@ func (node *Node) Use(middlewares ...IMiddleware) {
 }
 
 func (node *Node) Run(ctx context.Context, paramMap map[string]interface{}) Result {
+	// 执行条件在中间件之前判断, 跳过的节点不计入限流, 熔断和打点.
+	if node.when != nil {
+		ok, err := node.when.check(paramMap)
+		if err != nil {
+			node.logger.Warnf(ctx, "node [%s] when check error [%v]", node.id, err)
+			return node.ValueOnError("when_error: " + err.Error())
+		}
+		if !ok {
+			return node.ValueOnError(FieldFailReson_Skipped)
+		}
+	}
+
 	ctx, canel := context.WithTimeout(ctx, node.timeout)
 
 	valueCh := make(chan Result, 1)
@@ -199,59 +240,321 @@ func (node *Node) Run(ctx context.Context, paramMap map[string]interface{}) Resu
 }
 
 func (node *Node) handler(ctx context.Context, paramMap map[string]interface{}) Result {
-	paramsCfg := node.GetParamsByFunc(SupplierFunc)
+	if !node.hasFallback {
+		return node.supplyFields(ctx, paramMap)
+	}
+	// 主数据源超时后还需要调用备用数据源, 所以单独限制主数据源的超时时间.
+	supplyCtx, cancel := context.WithTimeout(ctx, node.supplyTimeout)
+	defer cancel()
+	valueCh := make(chan Result, 1)
+	utils.SafelyGo(
+		func() {
+			valueCh <- node.supplyFields(supplyCtx, paramMap)
+		},
+		func(err error) {
+			valueCh <- node.ValueOnError("node_run_err:" + fmt.Sprint(err))
+		})
+	var result Result
+	select {
+	case <-supplyCtx.Done():
+		result = node.ValueOnError("timeout")
+	case result = <-valueCh:
+	}
+	return node.fallback(ctx, paramMap, result)
+}
+
+// 调用主数据源补数.
+func (node *Node) supplyFields(ctx context.Context, paramMap map[string]interface{}) Result {
+	params, err := node.funcParams(SupplierFunc, paramMap)
+	if err != nil {
+		return node.ValueOnError("param_value_check_error: " + err.Error())
+	}
+
+	var supplyFields map[string]interface{}
+	var meta supplyMeta
+	if node.mapPolicy != nil {
+		supplyFields, meta, err = node.supplyMap(ctx, params)
+	} else {
+		supplyFields, meta, err = node.supply(ctx, node.supplier, node.funcName, params)
+	}
+	if errors.Is(err, constant.CircuitOpenError) {
+		return meta.apply(node.ValueOnError(FieldFailReson_CircuitOpen))
+	}
+	if errors.Is(err, constant.RateLimitedError) {
+		return meta.apply(node.ValueOnError(FieldFailReson_RateLimited))
+	}
+	if err != nil {
+		node.logger.Errorf(ctx, "node [%s] supplier error, func [%s], params [%s], attempts [%d], error [%v]",
+			node.id, node.funcName, utils.StructToString(params), meta.attempts, err)
+		return meta.apply(node.ValueOnError("supplier_error: " + err.Error()))
+	}
+
+	result := make(Result, len(node.fields))
+	for _, field := range node.fields {
+		result[field.Code] = node.fieldResult(ctx, field, supplyFields, meta, field.FieldOfSupply, node.funcName)
+	}
+	return result
+}
+
+// 按照参数配置生成函数的参数值.
+func (node *Node) funcParams(funcName string, paramMap map[string]interface{}) ([]interface{}, error) {
+	paramsCfg := node.GetParamsByFunc(funcName)
 	params := make([]interface{}, len(paramsCfg))
 	for i, param := range paramsCfg {
 		switch param.Kind {
 		case ParamConstant:
 			params[i] = param.Value
 		case ParamVariable:
-			params[i] = paramMap[param.FieldName]
+			params[i] = param.varValue(paramMap)
 			if err := param.ValueCheck(params[i]); err != nil {
-				return node.ValueOnError("param_value_check_error: " + err.Error())
+				return nil, err
 			}
 		}
 	}
+	return params, nil
+}
 
-	supplyFields, err := node.supplier.Supply(ctx, node.funcName, params)
+// 从 supplier 的返回值中取出字段值, 并转换为字段类型, FieldMeta 的调用信息来自 meta.
+// 插件通过 supplier.CallInfo 提供了返回值的元数据时(如 dag.Plugin), 使用其中的失败原因, 调用次数, 缓存命中和数据源.
+func (node *Node) fieldResult(ctx context.Context, field *Field, supplyFields map[string]interface{},
+	meta supplyMeta, fieldOfSupply, funcName string) *FieldResult {
+	output, ok := meta.outputs[fieldOfSupply]
+	if !ok {
+		output = supplier.OutputMeta{Attempts: meta.attempts, CacheHit: meta.cacheHit}
+	}
+	fieldResult := node.fieldValue(ctx, field, supplyFields, output.FailReason, fieldOfSupply, funcName)
+	fieldResult.Meta.Attempts = output.Attempts
+	fieldResult.Meta.CacheHit = output.CacheHit
+	fieldResult.Meta.Source = output.Source
+	return fieldResult
+}
+
+// failReason 为返回值自带的失败原因, 没有值时按照该原因失败, 有值(如内部字段的默认值)时保留.
+func (node *Node) fieldValue(ctx context.Context, field *Field, supplyFields map[string]interface{},
+	failReason, fieldOfSupply, funcName string) *FieldResult {
+	fieldCode := field.Code
+	fieldValue, ok := supplyFields[fieldOfSupply]
+	if failReason != "" && fieldValue == nil {
+		return field.ValueOnError(failReason)
+	}
+	if !ok {
+		node.logger.Warnf(ctx, "field [%s] not found in func [%s] response", fieldCode, funcName)
+		return field.ValueOnError(FieldFailReson_NotFoundInSupplyResponse)
+	}
+	if fieldValue == nil {
+		node.logger.Warnf(ctx, "field [%s] value is nil", fieldCode)
+		if !field.AutoNilToZero {
+			return field.ValueOnError(FieldFailReson_ValueIsNil)
+		}
+	}
+	var err error
+	if items, ok := fieldValue.(nullableItems); ok {
+		fieldValue, err = items.convert(field.FieldType)
+	} else {
+		fieldValue, err = dtype.Convert(fieldValue, field.FieldType)
+	}
 	if err != nil {
-		node.logger.Errorf(ctx, "node [%s] supplier error, func [%s], params [%s], error [%v]",
-			node.id, node.funcName, utils.StructToString(params), err)
-		return node.ValueOnError("supplier_error: " + err.Error())
+		node.logger.Warnf(ctx, "match field [%s][%v] type [%s] error: %s", fieldCode, fieldValue, field.FieldType, err.Error())
+		var schemaErr *dtype.SchemaError
+		if errors.As(err, &schemaErr) {
+			return field.ValueOnError(FieldFailReson_SchemaViolation)
+		}
+		return field.ValueOnError(FieldFailReson_TypeConvertError)
+	}
+	return &FieldResult{
+		Meta:  FieldMeta{FailReason: failReason},
+		Value: fieldValue,
 	}
+}
 
-	result := make(Result, len(node.fields))
-	for _, field := range node.fields {
-		fieldCode := field.Code
-		fieldValue, ok := supplyFields[field.FieldOfSupply]
-		if !ok {
-			node.logger.Warnf(ctx, "field [%s] not found in func [%s] response", fieldCode, node.funcName)
-			result[fieldCode] = field.ValueOnError(FieldFailReson_NotFoundInSupplyResponse)
-			continue
+// 对主数据源补数失败的字段, 依次调用备用数据源.
+// 第 i 轮调用所有仍未成功的字段的第 i 个备用数据源, 不同的数据源并发调用.
+// 一次执行中相同的数据源(supplier+func+params)只调用一次, 备用数据源都失败时保留主数据源的失败结果.
+func (node *Node) fallback(ctx context.Context, paramMap map[string]interface{}, result Result) Result {
+	called := map[string]*sourceResult{} // key: source.id()
+	for round := 0; ; round++ {
+		sources := map[string][]*Field{} // key: source.id()
+		for _, field := range node.fields {
+			if round >= len(field.Fallbacks) || result[field.Code].Meta.GetFailReason() == "" {
+				continue
+			}
+			id := field.Fallbacks[round].id()
+			sources[id] = append(sources[id], field)
 		}
-		if fieldValue == nil {
-			node.logger.Warnf(ctx, "field [%s] value is nil", fieldCode)
-			if !field.AutoNilToZero {
-				result[fieldCode] = field.ValueOnError(FieldFailReson_ValueIsNil)
+		if len(sources) == 0 {
+			return result
+		}
+
+		pending := make(map[string]*Field, len(sources))
+		for id, fields := range sources {
+			if _, ok := called[id]; !ok {
+				pending[id] = fields[0]
+			}
+		}
+		locker := sync.Mutex{}
+		wg := sync.WaitGroup{}
+		for id, field := range pending {
+			wg.Add(1)
+			id, field := id, field
+			// wg.Done 只在这里执行一次, panic 时只记录错误
+			go func() {
+				defer wg.Done()
+				var r *sourceResult
+				err := utils.SafelyRun(func() {
+					r = node.supplySource(ctx, field.Fallbacks[round], fallbackFunc(field.Code, round), paramMap)
+				})
+				if err != nil {
+					r = &sourceResult{err: fmt.Errorf("panic: %v", err)}
+				}
+				locker.Lock()
+				called[id] = r
+				locker.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		for id, fields := range sources {
+			r := called[id]
+			if r.err != nil {
+				node.logger.Warnf(ctx, "node [%s] fallback [%s] error, attempts [%d], error [%v]",
+					node.id, fields[0].Fallbacks[round].Name(), r.meta.attempts, r.err)
 				continue
 			}
+			for _, field := range fields {
+				source := field.Fallbacks[round]
+				fieldResult := node.fieldResult(ctx, field, r.supplyFields, r.meta, source.FieldOfSupply, source.FuncName)
+				if fieldResult.Meta.GetFailReason() != "" {
+					continue
+				}
+				// 数据源为备用数据源的名称
+				fieldResult.Meta.Source = source.Name()
+				result[field.Code] = fieldResult
+			}
+		}
+	}
+}
+
+type sourceResult struct {
+	supplyFields map[string]interface{}
+	meta         supplyMeta
+	err          error
+}
+
+// 在 source.Timeout 内调用备用数据源.
+func (node *Node) supplySource(ctx context.Context, source *FieldSource, funcName string,
+	paramMap map[string]interface{}) *sourceResult {
+	paramMap, err := node.resolveOptionalParams(ctx, funcName, paramMap)
+	if err != nil {
+		return &sourceResult{err: err}
+	}
+	params, err := node.funcParams(funcName, paramMap)
+	if err != nil {
+		return &sourceResult{err: err}
+	}
+	ctx, cancel := context.WithTimeout(ctx, source.Timeout)
+	defer cancel()
+	resultCh := make(chan *sourceResult, 1)
+	utils.SafelyGo(
+		func() {
+			supplyFields, meta, err := node.supply(ctx, source.Supplier, source.FuncName, params)
+			resultCh <- &sourceResult{supplyFields: supplyFields, meta: meta, err: err}
+		},
+		func(err error) {
+			resultCh <- &sourceResult{err: fmt.Errorf("panic: %v", err)}
+		})
+	select {
+	case <-ctx.Done():
+		return &sourceResult{err: ctx.Err()}
+	case r := <-resultCh:
+		return r
+	}
+}
+
+// 备用数据源的参数是可选参数, 节点不等待其上游字段, paramMap 中没有时按需从 ctx 中取值.
+// 取不到时返回错误, 只跳过该数据源, 不会剪枝.
+func (node *Node) resolveOptionalParams(ctx context.Context, funcName string,
+	paramMap map[string]interface{}) (map[string]interface{}, error) {
+	var resolved map[string]interface{}
+	for _, param := range node.GetParamsByFunc(funcName) {
+		if param.Kind != ParamVariable {
+			continue
 		}
-		if fieldValue, err = dtype.Convert(fieldValue, field.FieldType); err != nil {
-			node.logger.Warnf(ctx, "match field [%s][%v] type [%s] error", fieldCode, fieldValue, field.FieldType)
-			result[fieldCode] = field.ValueOnError(FieldFailReson_TypeConvertError)
+		if _, ok := paramMap[param.FieldName]; ok {
 			continue
 		}
-		result[fieldCode] = &FieldResult{
-			Value: fieldValue,
+		value, ok := resolveParam(ctx, param.FieldName)
+		if !ok {
+			return nil, fmt.Errorf("param field [%s] not ready", param.FieldName)
+		}
+		if resolved == nil {
+			resolved = make(map[string]interface{}, len(paramMap)+1)
+			for k, v := range paramMap {
+				resolved[k] = v
+			}
 		}
+		resolved[param.FieldName] = value
+	}
+	if resolved == nil {
+		return paramMap, nil
 	}
+	return resolved, nil
+}
+
+// 一次 supplier 调用的元数据, 会写入节点所有字段的 FieldMeta.
+// outputs 为插件通过 supplier.CallInfo 提供的返回值元数据, 优先于 attempts 和 cacheHit.
+type supplyMeta struct {
+	attempts int
+	cacheHit bool
+	outputs  map[string]supplier.OutputMeta
+}
 
+func (meta supplyMeta) apply(result Result) Result {
+	for _, fieldResult := range result {
+		fieldResult.Meta.Attempts = meta.attempts
+		fieldResult.Meta.CacheHit = meta.cacheHit
+	}
 	return result
 }
 
+// 调用 supplier, 失败时按照 node.retry 进行重试. 返回最后一次调用的结果和调用元数据.
+func (node *Node) supply(ctx context.Context, sup supplier.ISupplier, funcName string,
+	params []interface{}) (map[string]interface{}, supplyMeta, error) {
+	meta := supplyMeta{}
+	for {
+		meta.attempts++
+		// 每次调用使用新的 CallInfo, 只保留最后一次调用的信息
+		callCtx, callInfo := supplier.WithCallInfo(ctx)
+		supplyFields, err := sup.Supply(callCtx, funcName, params)
+		meta.cacheHit = callInfo.IsCacheHit()
+		meta.outputs = callInfo.GetOutputMetas()
+		if err == nil || !node.retry.ShouldRetry(meta.attempts, err) {
+			return supplyFields, meta, err
+		}
+
+		// 重试必须在节点超时时间内完成, 剩余时间不够退避时直接返回.
+		backoff := node.retry.Backoff(meta.attempts)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= backoff {
+			return supplyFields, meta, err
+		}
+		node.logger.Warnf(ctx, "node [%s] supplier error, retry after [%s], attempts [%d], error [%v]",
+			node.id, backoff, meta.attempts, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return supplyFields, meta, err
+		case <-timer.C:
+		}
+	}
+}
+
 // todo [optimize] 可以优化下性能
 func (node *Node) Prune() []INode {
-	nextNodes := node.GetNexts()
+	return pruneNexts(node.GetNexts())
+}
+
+// 返回 nextNodes 及其所有后代节点.
+func pruneNexts(nextNodes []INode) []INode {
 	nodeMap := make(map[string]INode, len(nextNodes))
 	for _, cnode := range nextNodes {
 		nodeMap[cnode.GetID()] = cnode
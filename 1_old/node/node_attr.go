@@ -1,6 +1,10 @@
 package node
 
-import "time"
+import (
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+)
 
 // node attr 的 get/set 方法
 
@@ -40,6 +44,18 @@ func (node *Node) GetDelaySupply() time.Duration {
 	return node.delaySupply
 }
 
+func (node *Node) GetSupplier() supplier.ISupplier {
+	return node.supplier
+}
+
+func (node *Node) GetFuncName() string {
+	return node.funcName
+}
+
+func (node *Node) GetMapPolicy() *MapPolicy {
+	return node.mapPolicy
+}
+
 func (node *Node) AddFields(fields ...*Field) {
 	fields = append(fields, node.fields...)
 	fieldIDSet := make(map[string]struct{}, len(fields))
@@ -55,6 +71,20 @@ func (node *Node) AddFields(fields ...*Field) {
 
 	node.fields = newFields
 	node.fieldCodes = newfieldCodes
+
+	// 合并节点时, 被合并字段的备用数据源参数和超时时间需要加入当前节点.
+	for _, field := range fields {
+		for i, source := range field.Fallbacks {
+			node.hasFallback = true
+			node.AddOptionalFuncParams(fallbackFunc(field.Code, i), source.Params)
+		}
+		if field.totalTimeout() > node.timeout {
+			node.timeout = field.totalTimeout()
+		}
+		if field.Timeout > node.supplyTimeout {
+			node.supplyTimeout = field.Timeout
+		}
+	}
 }
 
 func (node *Node) RemoveNexts(nodes ...INode) {
@@ -16,6 +16,9 @@ type CreateNodeRequest struct {
 	Params      []Param            `json:"params"`
 	Supplier    supplier.ISupplier `json:"supplier"`
 	Fields      []*Field           `json:"fields"`
+	Retry       *RetryPolicy       `json:"retry"` // 节点级别的重试策略, 为空时使用 fields 中最大调用次数的策略
+	When        *WhenCondition     `json:"when"`  // 节点的执行条件, 为空时总是执行
+	Map         *MapPolicy         `json:"map"`   // 映射模式, 为空时每次执行只调用一次插件
 	Middlewares []interface{}
 	Logger      log.ILog
 }
@@ -27,6 +30,12 @@ func (request *CreateNodeRequest) LoadDefault() {
 	for i := range request.Params {
 		request.Params[i].LoadDefault()
 	}
+	if request.Retry != nil {
+		request.Retry.LoadDefault()
+	}
+	if request.Map != nil {
+		request.Map.LoadDefault()
+	}
 	if request.Logger == nil {
 		request.Logger = log.NewDefaultLog()
 	}
@@ -43,6 +52,13 @@ func (req *CreateNodeRequest) GenNodeID() string {
 		builder.WriteString(param.ID)
 		builder.WriteString("_")
 	}
+	if req.When != nil {
+		builder.WriteString(req.When.ID())
+		builder.WriteString("_")
+	}
+	if req.Map != nil {
+		builder.WriteString(req.Map.ID())
+	}
 	id := strings.TrimRight(builder.String(), "_")
 	return id
 }
@@ -51,6 +67,21 @@ func (req *CreateNodeRequest) Validate() error {
 	if req.Supplier == nil {
 		return errors.New("must have supplier")
 	}
+	if req.Retry != nil {
+		if err := req.Retry.Validate(); err != nil {
+			return err
+		}
+	}
+	if req.When != nil {
+		if err := req.When.Validate(); err != nil {
+			return err
+		}
+	}
+	if req.Map != nil {
+		if err := req.Map.Validate(req.Params, req.Fields); err != nil {
+			return err
+		}
+	}
 
 	// check fields
 	fieldIDSet := make(map[string]struct{}, len(req.Fields))
@@ -63,6 +94,9 @@ func (req *CreateNodeRequest) Validate() error {
 		if err := field.Validate(); err != nil {
 			return err
 		}
+		if req.Map != nil && len(field.Fallbacks) > 0 {
+			return fmt.Errorf("field [%s] fallbacks not supported with map", field.Code)
+		}
 	}
 
 	paramIDSet := make(map[string]struct{}, len(req.Params))
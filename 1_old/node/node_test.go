@@ -3,9 +3,15 @@ package node
 import (
 	"context"
 	"errors"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"git.in.zhihu.com/antispam/datasupply/expr"
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+	"git.in.zhihu.com/antispam/datasupply/supplier/local"
 	"git.in.zhihu.com/antispam/datasupply/tests"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -89,3 +95,441 @@ func (suite *NodeTestSuite) TestRun() {
 func TestNode(t *testing.T) {
 	suite.Run(t, new(NodeTestSuite))
 }
+
+func TestNodeCacheHit(t *testing.T) {
+	cacheSupplier := supplier.NewDefaultSupplier("supplier_tests", []supplier.IPlugin{
+		tests.NewTestPlugin("Cached", []string{}, []string{"out"}),
+	}, supplier.SetCachePolicy(&supplier.CachePolicy{}))
+	cnode, err := New(&CreateNodeRequest{
+		FuncName: "Cached",
+		Params:   []Param{},
+		Supplier: cacheSupplier,
+		Fields: []*Field{
+			{Code: "out_field", FieldOfSupply: "out", FieldType: dtype.String},
+		},
+		Logger: tests.DefaultLogger,
+	})
+	assert.NoError(t, err)
+
+	for _, expectHit := range []bool{false, true} {
+		result := cnode.Run(context.Background(), map[string]interface{}{})
+		assert.Equal(t, "x", result["out_field"].Value)
+		assert.Equal(t, expectHit, result["out_field"].Meta.IsCacheHit())
+	}
+}
+
+func TestNodeValidateSchema(t *testing.T) {
+	plugin, err := supplier.NewTypedPlugin("Typed", func(ctx context.Context, id int64, name string) (map[string]string, error) {
+		return map[string]string{}, nil
+	})
+	assert.NoError(t, err)
+	schemaSupplier := supplier.NewDefaultSupplier("supplier_tests", []supplier.IPlugin{
+		supplier.NewDescribedPlugin(tests.NewTestPlugin("Described", []string{"id"}, []string{"out"}),
+			&supplier.PluginSchema{
+				Params:  []supplier.ParamSchema{{Name: "id", Type: dtype.Int64, Required: true}},
+				Outputs: []supplier.OutputSchema{{Name: "out", Type: dtype.String}},
+			}),
+		plugin,
+	})
+
+	cases := []struct {
+		name     string
+		funcName string
+		params   []Param
+		output   string
+		isErr    bool
+	}{
+		{"ok", "Described", []Param{*NewConstantParam(1, dtype.Int64)}, "out", false},
+		{"output_typo", "Described", []Param{*NewConstantParam(1, dtype.Int64)}, "ou", true},
+		{"missing_param", "Described", []Param{}, "out", true},
+		{"too_many_params", "Described",
+			[]Param{*NewConstantParam(1, dtype.Int64), *NewConstantParam(2, dtype.Int64)}, "out", true},
+		{"param_type", "Described", []Param{*NewConstantParam("1", dtype.String)}, "out", true},
+		{"typed_convertible", "Typed",
+			[]Param{*NewConstantParam("1", dtype.String), *NewConstantParam("a", dtype.String)}, "any", false},
+		{"typed_not_convertible", "Typed",
+			[]Param{*NewConstantParam(1, dtype.Map), *NewConstantParam("a", dtype.String)}, "any", true},
+		{"typed_dynamic_output", "Typed",
+			[]Param{*NewConstantParam(1, dtype.Int64), *NewConstantParam("a", dtype.String)}, "any", false},
+		{"not_described", "NotFound", []Param{}, "any", false},
+	}
+	for _, c := range cases {
+		_, err := New(&CreateNodeRequest{
+			FuncName: c.funcName,
+			Params:   c.params,
+			Supplier: schemaSupplier,
+			Fields: []*Field{
+				{Code: "out_field", FieldOfSupply: c.output, FieldType: dtype.String},
+			},
+			Logger: tests.DefaultLogger,
+		})
+		assert.Equal(t, c.isErr, err != nil, c.name)
+	}
+}
+
+func TestNodeExpr(t *testing.T) {
+	e := expr.MustCompile("b == 0 ? null : a / b", []expr.Var{
+		{Name: "a", Type: dtype.Int64},
+		{Name: "b", Type: dtype.Int64},
+	})
+	params := []Param{*NewConstantParam(e, 0)}
+	for _, name := range []string{"a", "b"} {
+		param, err := NewVariableParam(&CreateVarParamRequest{
+			ParamName:    name,
+			DagFieldName: name,
+			ParamType:    dtype.Int64,
+		})
+		assert.NoError(t, err)
+		params = append(params, *param)
+	}
+	cnode, err := New(&CreateNodeRequest{
+		FuncName: local.ExprPlugin,
+		Params:   params,
+		Supplier: supplier.NewDefaultSupplier(local.SupplierName, []supplier.IPlugin{local.NewExprPlugin()}),
+		Fields: []*Field{
+			{Code: "ratio", FieldOfSupply: local.ExprOutput, FieldType: dtype.Float64},
+		},
+		Logger: tests.DefaultLogger,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "local_Expr_const_dtype_0_((b == 0) ? null : (a / b))_var_a_a_var_b_b", cnode.GetID())
+
+	result := cnode.Run(context.Background(), map[string]interface{}{"a": int64(1), "b": int64(4)})
+	assert.Equal(t, 0.25, result["ratio"].Value)
+	result = cnode.Run(context.Background(), map[string]interface{}{"a": int64(1), "b": int64(0)})
+	assert.Equal(t, FieldFailReson_ValueIsNil, result["ratio"].Meta.FailReason)
+
+	// 构建时校验表达式变量的数量和类型
+	exprVar, err := NewVariableParam(&CreateVarParamRequest{
+		ParamName:    "expr",
+		DagFieldName: "expr",
+		ParamType:    dtype.String,
+	})
+	assert.NoError(t, err)
+	cases := []struct {
+		name   string
+		params []Param
+	}{
+		{"vars_not_match", params[:2]},
+		{"var_type", []Param{params[0], params[1], *NewConstantParam(map[string]interface{}{}, dtype.Map)}},
+		{"not_constant", append([]Param{*exprVar}, params[1:]...)},
+	}
+	for _, c := range cases {
+		_, err := New(&CreateNodeRequest{
+			FuncName: local.ExprPlugin,
+			Params:   c.params,
+			Supplier: supplier.NewDefaultSupplier(local.SupplierName, []supplier.IPlugin{local.NewExprPlugin()}),
+			Fields: []*Field{
+				{Code: "ratio", FieldOfSupply: local.ExprOutput, FieldType: dtype.Float64},
+			},
+			Logger: tests.DefaultLogger,
+		})
+		assert.Error(t, err, c.name)
+	}
+}
+
+func TestNodeWhen(t *testing.T) {
+	newRequest := func(when *WhenCondition) *CreateNodeRequest {
+		return &CreateNodeRequest{
+			FuncName: "Guarded",
+			Params:   []Param{*NewConstantParam(1, dtype.Int64)},
+			Supplier: supplier.NewDefaultSupplier("supplier_tests", []supplier.IPlugin{
+				tests.NewTestPlugin("Guarded", []string{"id"}, []string{"out"}),
+			}),
+			Fields: []*Field{
+				{Code: "out_field", FieldOfSupply: "out", FieldType: dtype.String},
+			},
+			When:   when,
+			Logger: tests.DefaultLogger,
+		}
+	}
+	newWhen := func(src string) *WhenCondition {
+		return &WhenCondition{
+			Expr: src,
+			Vars: []*CreateVarParamRequest{
+				{ParamName: "has_image", DagFieldName: "has_image", ParamType: dtype.Bool},
+				{ParamName: "cnt", DagFieldName: "image_cnt", ParamType: dtype.Int64},
+			},
+		}
+	}
+
+	cnode, err := New(newRequest(newWhen("has_image && cnt > 0")))
+	assert.NoError(t, err)
+	assert.Len(t, cnode.GetParamVariables(), 2)
+	assert.Contains(t, cnode.GetID(), "when_(has_image && (cnt > 0))")
+	plain, err := New(newRequest(nil))
+	assert.NoError(t, err)
+	assert.NotEqual(t, plain.GetID(), cnode.GetID())
+
+	testCases := []struct {
+		name         string
+		paramMap     map[string]interface{}
+		expectReason string
+	}{
+		{"run", map[string]interface{}{"has_image": true, "image_cnt": int64(2)}, ""},
+		{"skipped", map[string]interface{}{"has_image": false, "image_cnt": int64(2)}, FieldFailReson_Skipped},
+		{"null_skipped", map[string]interface{}{"has_image": nil, "image_cnt": int64(2)}, FieldFailReson_Skipped},
+		{"eval_error", map[string]interface{}{"has_image": "x", "image_cnt": int64(2)}, "when_error: "},
+	}
+	for _, c := range testCases {
+		result := cnode.Run(context.Background(), c.paramMap)
+		if c.expectReason == "" {
+			assert.Equal(t, "x", result["out_field"].Value, c.name)
+		}
+		assert.True(t, strings.HasPrefix(result["out_field"].Meta.FailReason, c.expectReason), c.name)
+	}
+
+	for _, src := range []string{"", "cnt + 1", "unknown"} {
+		_, err := New(newRequest(newWhen(src)))
+		assert.Error(t, err, src)
+	}
+}
+
+func TestNodeFallback(t *testing.T) {
+	var primaryCalls, cacheCalls int32
+	fallbackSupplier := supplier.NewDefaultSupplier("supplier_fallback", []supplier.IPlugin{
+		supplier.NewDefaultPlugin("Primary", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			atomic.AddInt32(&primaryCalls, 1)
+			switch args[0] {
+			case "error":
+				return map[string]interface{}{}, errors.New("primary error")
+			case "slow":
+				time.Sleep(50 * time.Millisecond)
+			}
+			return map[string]interface{}{"region": "primary", "level": int64(1)}, nil
+		}),
+		supplier.NewDefaultPlugin("Broken", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{}, errors.New("broken")
+		}),
+		supplier.NewDefaultPlugin("Cache", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			atomic.AddInt32(&cacheCalls, 1)
+			return map[string]interface{}{"region": "cache_" + args[0].(string), "level_cache": int64(2)}, nil
+		}),
+	})
+	modeParam, _ := NewVariableParam(&CreateVarParamRequest{
+		ParamName: "mode", DagFieldName: "mode", ParamType: dtype.String})
+	uidParam, _ := NewVariableParam(&CreateVarParamRequest{
+		ParamName: "uid", DagFieldName: "uid", ParamType: dtype.String})
+	newFields := func() []*Field {
+		return []*Field{
+			{
+				Code: "region", FieldOfSupply: "region", FieldType: dtype.String, Timeout: 20 * time.Millisecond,
+				Fallbacks: []*FieldSource{
+					{Supplier: fallbackSupplier, FuncName: "Broken"},
+					{Supplier: fallbackSupplier, FuncName: "Cache", Params: []Param{*uidParam}},
+				},
+			},
+			{
+				Code: "level", FieldOfSupply: "level", FieldType: dtype.Int64, Timeout: 20 * time.Millisecond,
+				Fallbacks: []*FieldSource{
+					{Supplier: fallbackSupplier, FuncName: "Cache", Params: []Param{*uidParam},
+						FieldOfSupply: "level_cache"},
+				},
+			},
+		}
+	}
+
+	cnode, err := New(&CreateNodeRequest{
+		FuncName: "Primary",
+		Params:   []Param{*modeParam},
+		Supplier: fallbackSupplier,
+		Fields:   newFields(),
+		Logger:   tests.DefaultLogger,
+	})
+	assert.NoError(t, err)
+	// 备用数据源的 uid 是可选参数, 不是节点的依赖
+	assert.Len(t, cnode.GetParamVariables(), 1)
+	assert.Len(t, cnode.GetParamsByFunc(fallbackFunc("region", 1)), 1)
+	assert.Equal(t, 60*time.Millisecond, cnode.GetTimeout())
+
+	testCases := []struct {
+		mode         string
+		expectRegion interface{}
+		expectLevel  interface{}
+		expectSource string
+		expectCache  int32
+	}{
+		{"ok", "primary", int64(1), "", 0},
+		// Broken 失败后调用 Cache, 两个字段共用一次 Cache 调用
+		{"error", "cache_u1", int64(2), "supplier_fallback.Cache", 1},
+		{"slow", "cache_u1", int64(2), "supplier_fallback.Cache", 1},
+	}
+	for _, c := range testCases {
+		atomic.StoreInt32(&cacheCalls, 0)
+		result := cnode.Run(context.Background(), map[string]interface{}{"mode": c.mode, "uid": "u1"})
+		assert.Equal(t, c.expectRegion, result["region"].Value, c.mode)
+		assert.Equal(t, c.expectLevel, result["level"].Value, c.mode)
+		assert.Equal(t, "", result["region"].Meta.GetFailReason(), c.mode)
+		assert.Equal(t, c.expectSource, result["region"].Meta.GetSource(), c.mode)
+		assert.Equal(t, c.expectCache, atomic.LoadInt32(&cacheCalls), c.mode)
+	}
+
+	// paramMap 中没有备用数据源的参数时, 按需从 ParamResolver 取值, 取不到时只跳过该数据源
+	resolverCtx := WithParamResolver(context.Background(), func(fieldName string) (interface{}, bool) {
+		return "u3", fieldName == "uid"
+	})
+	result := cnode.Run(resolverCtx, map[string]interface{}{"mode": "error"})
+	assert.Equal(t, "cache_u3", result["region"].Value)
+	assert.Equal(t, "supplier_fallback.Cache", result["region"].Meta.GetSource())
+	result = cnode.Run(context.Background(), map[string]interface{}{"mode": "error"})
+	assert.Nil(t, result["region"].Value)
+	assert.NotEqual(t, "", result["region"].Meta.GetFailReason())
+	result = cnode.Run(context.Background(), map[string]interface{}{"mode": "ok"})
+	assert.Equal(t, "primary", result["region"].Value)
+
+	// 合并节点(dagBuilder.mergeNodes)时, 被合并字段的备用数据源同样生效
+	merged, err := New(&CreateNodeRequest{
+		FuncName: "Primary",
+		Params:   []Param{*modeParam},
+		Supplier: fallbackSupplier,
+		Fields: []*Field{{Code: "plain", FieldOfSupply: "region", FieldType: dtype.String,
+			Timeout: 20 * time.Millisecond}},
+		Logger: tests.DefaultLogger,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 20*time.Millisecond, merged.GetTimeout())
+	merged.AddFields(cnode.GetFields()...)
+	assert.Len(t, merged.GetParamVariables(), 1)
+	assert.Equal(t, 60*time.Millisecond, merged.GetTimeout())
+	result = merged.Run(context.Background(), map[string]interface{}{"mode": "error", "uid": "u2"})
+	assert.Equal(t, "cache_u2", result["region"].Value)
+	assert.Equal(t, "supplier_fallback.Cache", result["region"].Meta.GetSource())
+	assert.NotEqual(t, "", result["plain"].Meta.GetFailReason())
+
+	_, err = New(&CreateNodeRequest{
+		FuncName: "Primary",
+		Params:   []Param{*modeParam},
+		Supplier: fallbackSupplier,
+		Fields: []*Field{{Code: "region", FieldOfSupply: "region", FieldType: dtype.String,
+			Fallbacks: []*FieldSource{{FuncName: "Cache"}}}},
+		Logger: tests.DefaultLogger,
+	})
+	assert.Error(t, err)
+
+	// 备用数据源 panic 时只有该数据源失败, 其他数据源正常返回
+	panicParam := *uidParam
+	panicParam.AddValueCheckFns(func(interface{}) error { panic("check") })
+	panicNode, err := New(&CreateNodeRequest{
+		FuncName: "Primary",
+		Params:   []Param{*modeParam},
+		Supplier: fallbackSupplier,
+		Fields: []*Field{
+			{Code: "region", FieldOfSupply: "region", FieldType: dtype.String, Timeout: 20 * time.Millisecond,
+				Fallbacks: []*FieldSource{{Supplier: fallbackSupplier, FuncName: "Broken", Params: []Param{panicParam}}}},
+			{Code: "level", FieldOfSupply: "level", FieldType: dtype.Int64, Timeout: 20 * time.Millisecond,
+				Fallbacks: []*FieldSource{{Supplier: fallbackSupplier, FuncName: "Cache", Params: []Param{*uidParam},
+					FieldOfSupply: "level_cache"}}},
+		},
+		Logger: tests.DefaultLogger,
+	})
+	assert.NoError(t, err)
+	result = panicNode.Run(context.Background(), map[string]interface{}{"mode": "error", "uid": "u1"})
+	assert.NotEqual(t, "", result["region"].Meta.GetFailReason())
+	assert.Equal(t, int64(2), result["level"].Value)
+}
+
+func TestNodeMap(t *testing.T) {
+	var running, maxRunning int32
+	mapSupplier := supplier.NewDefaultSupplier("supplier_map", []supplier.IPlugin{
+		supplier.NewDefaultPlugin("Double", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				prev := atomic.LoadInt32(&maxRunning)
+				if n <= prev || atomic.CompareAndSwapInt32(&maxRunning, prev, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			v := args[1].(int64)
+			if v == 0 {
+				panic("zero")
+			}
+			if v < 0 {
+				return map[string]interface{}{}, errors.New("negative")
+			}
+			return map[string]interface{}{"double": v * 2, "tag": args[0]}, nil
+		}),
+	})
+	idsParam, _ := NewVariableParam(&CreateVarParamRequest{
+		ParamName: "ids", DagFieldName: "ids", ParamType: dtype.ArrayInt64})
+	newRequest := func(policy *MapPolicy) *CreateNodeRequest {
+		return &CreateNodeRequest{
+			FuncName: "Double",
+			Params:   []Param{*NewConstantParam("t", dtype.String), *idsParam},
+			Supplier: mapSupplier,
+			Fields: []*Field{
+				{Code: "doubles", FieldOfSupply: "double", FieldType: dtype.ArrayInt64},
+				{Code: "tags", FieldOfSupply: "tag", FieldType: dtype.ArrayString},
+			},
+			Map:    policy,
+			Logger: tests.DefaultLogger,
+		}
+	}
+
+	testCases := []struct {
+		name         string
+		onFailure    MapFailureMode
+		ids          interface{}
+		expect       interface{}
+		expectTags   interface{}
+		expectReason string
+	}{
+		{"all_success", MapFailAll, []int64{1, 2, 3, 4, 5}, []int64{2, 4, 6, 8, 10}, []string{"t", "t", "t", "t", "t"}, ""},
+		{"empty", MapFailAll, []int64{}, []int64{}, []string{}, ""},
+		{"fail_all", MapFailAll, []int64{1, -1, 3}, nil, nil, "supplier_error: "},
+		{"drop_failed", MapDropFailed, []int64{1, -1, 3}, []int64{2, 6}, []string{"t", "t"}, ""},
+		// 失败的元素为 nil, 与零值区分
+		{"null_failed", MapNullFailed, []int64{1, -1, 3},
+			[]interface{}{int64(2), nil, int64(6)}, []interface{}{"t", nil, "t"}, ""},
+	}
+	for _, c := range testCases {
+		cnode, err := New(newRequest(&MapPolicy{ParamIndex: 1, Concurrent: 2, OnFailure: c.onFailure}))
+		assert.NoError(t, err, c.name)
+		result := cnode.Run(context.Background(), map[string]interface{}{"ids": c.ids})
+		assert.True(t, strings.HasPrefix(result["doubles"].Meta.FailReason, c.expectReason), c.name)
+		if c.expectReason == "" {
+			assert.Equal(t, c.expect, result["doubles"].Value, c.name)
+			assert.Equal(t, c.expectTags, result["tags"].Value, c.name)
+			assert.Equal(t, len(c.ids.([]int64)), result["doubles"].Meta.Attempts, c.name)
+		}
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(2))
+
+	// 插件 panic 的元素按照失败处理, 不影响其他元素
+	for _, c := range []struct {
+		onFailure MapFailureMode
+		expect    interface{}
+	}{
+		{MapDropFailed, []int64{2, 6, 10}},
+		{MapNullFailed, []interface{}{int64(2), nil, int64(6), nil, int64(10)}},
+	} {
+		cnode, err := New(newRequest(&MapPolicy{ParamIndex: 1, Concurrent: 2, OnFailure: c.onFailure}))
+		assert.NoError(t, err)
+		result := cnode.Run(context.Background(), map[string]interface{}{"ids": []int64{1, 0, 3, 0, 5}})
+		assert.Equal(t, "", result["doubles"].Meta.FailReason)
+		assert.Equal(t, c.expect, result["doubles"].Value)
+	}
+
+	plain, err := New(newRequest(nil))
+	assert.NoError(t, err)
+	mapped, err := New(newRequest(&MapPolicy{ParamIndex: 1}))
+	assert.NoError(t, err)
+	assert.NotEqual(t, plain.GetID(), mapped.GetID())
+	assert.Equal(t, DefaultMapConcurrent, mapped.GetMapPolicy().Concurrent)
+
+	for _, policy := range []*MapPolicy{{ParamIndex: 0}, {ParamIndex: 2}, {ParamIndex: 1, Concurrent: -1}} {
+		_, err := New(newRequest(policy))
+		assert.Error(t, err, policy)
+	}
+	// null_failed 模式下字段类型需要是可以按元素转换的数组类型
+	request := newRequest(&MapPolicy{ParamIndex: 1, OnFailure: MapNullFailed})
+	request.Fields[1].FieldType = dtype.String
+	_, err = New(request)
+	assert.Error(t, err)
+	// 备用数据源不支持按元素展开调用
+	request = newRequest(&MapPolicy{ParamIndex: 1})
+	request.Fields[0].Fallbacks = []*FieldSource{{Supplier: mapSupplier, FuncName: "Double"}}
+	_, err = New(request)
+	assert.Error(t, err)
+}
@@ -1,9 +1,11 @@
 package node
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"git.in.zhihu.com/antispam/datasupply/dtype"
 	"git.in.zhihu.com/antispam/datasupply/internal/deepcopy"
@@ -17,6 +19,9 @@ import (
 //go:generate mockgen -package mock_node -destination ./mock/param.go -source=param.go
 type INodeParams interface {
 	AddFuncParams(funcName string, funcParams []Param) INodeParams
+	// 添加函数的可选参数, 只能通过 GetParamsByFunc 取到, 不加入拉平后的参数列表, 所以不会成为节点的依赖.
+	// 运行时变量参数的值通过 WithParamResolver 按需获取, 如备用数据源的参数.
+	AddOptionalFuncParams(funcName string, funcParams []Param) INodeParams
 	// 存储 node 用到的所有函数的参数列表. key: func, value: func.params
 	GetParamMap() map[string][]Param
 	// 拉平后的参数列表
@@ -43,11 +48,7 @@ func NewNodeParams() *NodeParams {
 }
 
 func (params *NodeParams) AddFuncParams(funcName string, _funcParams []Param) INodeParams {
-	funcParams := make([]Param, len(_funcParams))
-	for i := range _funcParams {
-		funcParams[i] = deepcopy.Copy(_funcParams[i]).(Param)
-		funcParams[i].ID = funcName + "_" + funcParams[i].ID
-	}
+	funcParams := copyFuncParams(funcName, _funcParams)
 	params.paramsMap[funcName] = funcParams
 
 	paramsMap := make(map[string]struct{}, len(params.params))
@@ -68,6 +69,20 @@ func (params *NodeParams) AddFuncParams(funcName string, _funcParams []Param) IN
 	return params
 }
 
+func (params *NodeParams) AddOptionalFuncParams(funcName string, _funcParams []Param) INodeParams {
+	params.paramsMap[funcName] = copyFuncParams(funcName, _funcParams)
+	return params
+}
+
+func copyFuncParams(funcName string, _funcParams []Param) []Param {
+	funcParams := make([]Param, len(_funcParams))
+	for i := range _funcParams {
+		funcParams[i] = deepcopy.Copy(_funcParams[i]).(Param)
+		funcParams[i].ID = funcName + "_" + funcParams[i].ID
+	}
+	return funcParams
+}
+
 func (params *NodeParams) GetParams() []Param {
 	return params.params
 }
@@ -97,8 +112,11 @@ type Param struct {
 
 	// 变量时才有的分支
 	FieldName string `json:"field_name"` // 变量时, 取 dag.result.field 作为参数值
+	SubPath   string `json:"sub_path"`   // 字段为对象类型时, 取子字段(以 . 分隔)作为参数值, 为空时取整个字段
 	// TODO [optimize] 可以看下这个 onerror 有无更好的处理方式. 不应该放在 node 上, 因为不同的下游可能有不同的处理.
 	OnError ParamOnErrorHandler `json:"on_error"`
+	// 按上游字段的失败原因(如 circuit_open)指定处理方式, 未指定的失败原因使用 OnError.
+	OnFailReason map[string]ParamOnErrorHandler `json:"on_fail_reason"`
 
 	// 验证函数
 	ValueCheckFns []func(value interface{}) error `json:"-"`
@@ -117,8 +135,11 @@ func NewConstantParam(value interface{}, valueType dtype.DType) *Param {
 type CreateVarParamRequest struct {
 	ParamName    string              // 参数的名称, 用于确定参数在当前函数的唯一id.
 	DagFieldName string              // 参数的值, 将 dag.result.field 作为参数值
+	SubPath      string              // 可选, 上游字段为对象类型时取该路径的子字段, 如 "user.level"
 	ParamType    dtype.DType         // 参数类型
 	OnError      ParamOnErrorHandler // 参数值错误时的处理方式
+	// 按上游字段的失败原因指定处理方式, 可选
+	OnFailReason map[string]ParamOnErrorHandler
 }
 
 func (req *CreateVarParamRequest) Validate() error {
@@ -128,12 +149,24 @@ func (req *CreateVarParamRequest) Validate() error {
 	if req.DagFieldName == "" {
 		return errors.New("dag_field_name can not be empty")
 	}
+	if req.SubPath != "" {
+		for _, name := range strings.Split(req.SubPath, ".") {
+			if name == "" {
+				return fmt.Errorf("sub_path [%s] invalid", req.SubPath)
+			}
+		}
+	}
 	if _, exist := dtype.GetDtype(req.ParamType.String()); !exist {
 		return errors.New("param_type not defined")
 	}
 	if int(req.OnError) >= len(ParamOnErrorHandlerNames) {
 		return errors.New("on_error not found")
 	}
+	for failReason, handler := range req.OnFailReason {
+		if int(handler) >= len(ParamOnErrorHandlerNames) {
+			return fmt.Errorf("on_fail_reason [%s] handler not found", failReason)
+		}
+	}
 	return nil
 }
 
@@ -141,12 +174,18 @@ func NewVariableParam(request *CreateVarParamRequest) (*Param, error) {
 	if err := request.Validate(); err != nil {
 		return &Param{}, err
 	}
+	id := fmt.Sprintf("var_%s_%s", request.ParamName, request.DagFieldName)
+	if request.SubPath != "" {
+		id += "." + request.SubPath
+	}
 	return &Param{
-		ID:        fmt.Sprintf("var_%s_%s", request.ParamName, request.DagFieldName),
-		Kind:      ParamVariable,
-		ValueType: request.ParamType,
-		FieldName: request.DagFieldName,
-		OnError:   request.OnError,
+		ID:           id,
+		Kind:         ParamVariable,
+		ValueType:    request.ParamType,
+		FieldName:    request.DagFieldName,
+		SubPath:      request.SubPath,
+		OnError:      request.OnError,
+		OnFailReason: request.OnFailReason,
 	}, nil
 }
 
@@ -156,6 +195,35 @@ func (param *Param) Validate() error {
 	return nil
 }
 
+// 从上游字段值中取变量参数的值. 指定 SubPath 时取对象的子字段, 子字段不存在时为 nil.
+func (param *Param) varValue(paramMap map[string]interface{}) interface{} {
+	value := paramMap[param.FieldName]
+	if param.SubPath == "" {
+		return value
+	}
+	value, _ = dtype.GetPath(value, param.SubPath)
+	return value
+}
+
+type paramResolverKey struct{}
+
+// ParamResolver 按字段名返回本次运行中已经补数成功的字段值, 字段未就绪或补数失败时返回 false.
+type ParamResolver func(fieldName string) (interface{}, bool)
+
+// WithParamResolver 设置可选参数的取值方式, 由 dag 在运行时提供.
+func WithParamResolver(ctx context.Context, resolver ParamResolver) context.Context {
+	return context.WithValue(ctx, paramResolverKey{}, resolver)
+}
+
+// 按需获取可选参数依赖的字段值, 不会等待上游节点.
+func resolveParam(ctx context.Context, fieldName string) (interface{}, bool) {
+	resolver, ok := ctx.Value(paramResolverKey{}).(ParamResolver)
+	if !ok {
+		return nil, false
+	}
+	return resolver(fieldName)
+}
+
 func (param *Param) AddValueCheckFns(fn func(interface{}) error) {
 	// 常量不需要验证值
 	if param.Kind == ParamConstant {
@@ -176,8 +244,17 @@ func (param *Param) ValueCheck(v interface{}) error {
 	return nil
 }
 
-func (param *Param) HandleError() (isPrune bool, paramValue interface{}) {
-	switch param.OnError {
+// 上游字段补数失败时的处理方式, failReason 为上游字段的失败原因.
+// 上游节点因执行条件被跳过时默认不剪枝, 除非 OnFailReason 中指定了 skipped 的处理方式.
+func (param *Param) HandleError(failReason string) (isPrune bool, paramValue interface{}) {
+	onError := param.OnError
+	if failReason == FieldFailReson_Skipped {
+		onError = ParamOnErrorSkip
+	}
+	if handler, ok := param.OnFailReason[failReason]; ok {
+		onError = handler
+	}
+	switch onError {
 	case ParamOnErrorPrune:
 		return true, nil
 	// case ParamOnErrorDefault:
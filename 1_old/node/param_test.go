@@ -1,3 +1,63 @@
 package node
 
+import (
+	"testing"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"github.com/stretchr/testify/assert"
+)
+
 // todo param new test
+
+func TestParamHandleError(t *testing.T) {
+	param := &Param{
+		Kind:    ParamVariable,
+		OnError: ParamOnErrorPrune,
+		OnFailReason: map[string]ParamOnErrorHandler{
+			FieldFailReson_CircuitOpen: ParamOnErrorSkip,
+		},
+	}
+	skippedPrune := &Param{
+		Kind:    ParamVariable,
+		OnError: ParamOnErrorSkip,
+		OnFailReason: map[string]ParamOnErrorHandler{
+			FieldFailReson_Skipped: ParamOnErrorPrune,
+		},
+	}
+	testCases := []struct {
+		name        string
+		param       *Param
+		failReason  string
+		expectPrune bool
+	}{
+		{"default", param, "timeout", true},
+		{"circuit_open", param, FieldFailReson_CircuitOpen, false},
+		{"skipped_default", param, FieldFailReson_Skipped, false},
+		{"skipped_prune", skippedPrune, FieldFailReson_Skipped, true},
+	}
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			isPrune, _ := tcase.param.HandleError(tcase.failReason)
+			assert.Equal(t, tcase.expectPrune, isPrune)
+		})
+	}
+}
+
+func TestParamSubPath(t *testing.T) {
+	request := &CreateVarParamRequest{ParamName: "level", DagFieldName: "user", ParamType: dtype.Int64}
+	whole, err := NewVariableParam(request)
+	assert.NoError(t, err)
+	request.SubPath = "profile.level"
+	sub, err := NewVariableParam(request)
+	assert.NoError(t, err)
+	assert.NotEqual(t, whole.ID, sub.ID)
+
+	user := map[string]interface{}{"profile": map[string]interface{}{"level": int64(3)}}
+	assert.Equal(t, user, whole.varValue(map[string]interface{}{"user": user}))
+	assert.Equal(t, int64(3), sub.varValue(map[string]interface{}{"user": user}))
+	assert.Nil(t, sub.varValue(map[string]interface{}{"user": "x"}))
+
+	request.SubPath = "profile..level"
+	_, err = NewVariableParam(request)
+	assert.Error(t, err)
+}
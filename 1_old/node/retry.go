@@ -0,0 +1,189 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/constant"
+)
+
+const (
+	DefaultRetryInitialBackoff = time.Millisecond * 10
+	DefaultRetryMaxBackoff     = time.Second
+	DefaultRetryMultiplier     = 2
+	DefaultRetryJitter         = 0.2
+)
+
+// RetryPolicy 定义 supplier 调用失败时的重试策略, 可配置在 field 或 node 上.
+// 重试始终在节点超时时间内进行, 剩余时间不足以等待下一次退避时直接返回最后一次的错误.
+type RetryPolicy struct {
+	MaxAttempts    int           `json:"max_attempts"`    // 最大调用次数, 包含首次调用. <=1 时不重试
+	InitialBackoff time.Duration `json:"initial_backoff"` // 首次重试前的等待时间, 单位毫秒, 也可以使用 "100ms" 格式的字符串
+	MaxBackoff     time.Duration `json:"max_backoff"`     // 退避时间上限, 单位毫秒, 也可以使用 "1s" 格式的字符串
+	Multiplier     float64       `json:"multiplier"`      // 指数退避系数
+	// 随机抖动比例, 范围 [0,1]. 为空时使用 DefaultRetryJitter, 设置为 0 时不抖动.
+	Jitter *float64 `json:"jitter"`
+
+	// 判断错误是否可以重试, 为空时使用 DefaultRetryable.
+	Retryable func(err error) bool `json:"-"`
+}
+
+// 不带方法的别名, 用于 JSON 编解码时复用其他字段.
+type retryPolicyAlias RetryPolicy
+
+// UnmarshalJSON 退避时间为数字时单位为毫秒, 与 Field.Timeout 一致; 为字符串时按 time.ParseDuration 解析.
+func (policy *RetryPolicy) UnmarshalJSON(b []byte) error {
+	raw := struct {
+		*retryPolicyAlias
+		InitialBackoff json.RawMessage `json:"initial_backoff"`
+		MaxBackoff     json.RawMessage `json:"max_backoff"`
+	}{retryPolicyAlias: (*retryPolicyAlias)(policy)}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	var err error
+	if policy.InitialBackoff, err = parseBackoff(raw.InitialBackoff, policy.InitialBackoff); err != nil {
+		return fmt.Errorf("retry.initial_backoff %s", err.Error())
+	}
+	if policy.MaxBackoff, err = parseBackoff(raw.MaxBackoff, policy.MaxBackoff); err != nil {
+		return fmt.Errorf("retry.max_backoff %s", err.Error())
+	}
+	return nil
+}
+
+// MarshalJSON 退避时间输出为 "100ms" 格式的字符串, 可以被 UnmarshalJSON 解析.
+func (policy *RetryPolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		*retryPolicyAlias
+		InitialBackoff string `json:"initial_backoff"`
+		MaxBackoff     string `json:"max_backoff"`
+	}{(*retryPolicyAlias)(policy), policy.InitialBackoff.String(), policy.MaxBackoff.String()})
+}
+
+// 未配置时保持原值.
+func parseBackoff(raw json.RawMessage, value time.Duration) (time.Duration, error) {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return value, nil
+	}
+	var ms float64
+	if err := json.Unmarshal(raw, &ms); err == nil {
+		return time.Duration(ms * float64(time.Millisecond)), nil
+	}
+	var str string
+	if err := json.Unmarshal(raw, &str); err != nil {
+		return 0, fmt.Errorf("must be milliseconds or duration string, got %s", raw)
+	}
+	return time.ParseDuration(str)
+}
+
+func (policy *RetryPolicy) LoadDefault() {
+	if policy.InitialBackoff == 0 {
+		policy.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if policy.MaxBackoff == 0 {
+		policy.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	if policy.Multiplier == 0 {
+		policy.Multiplier = DefaultRetryMultiplier
+	}
+	if policy.Jitter == nil {
+		jitter := float64(DefaultRetryJitter)
+		policy.Jitter = &jitter
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = DefaultRetryable
+	}
+}
+
+func (policy *RetryPolicy) copy() *RetryPolicy {
+	p := *policy
+	if policy.Jitter != nil {
+		jitter := *policy.Jitter
+		p.Jitter = &jitter
+	}
+	return &p
+}
+
+func (policy *RetryPolicy) Validate() error {
+	if policy.MaxAttempts < 0 {
+		return errors.New("retry.max_attempts can not be negative")
+	}
+	if policy.InitialBackoff < 0 || policy.MaxBackoff < 0 {
+		return errors.New("retry.backoff can not be negative")
+	}
+	if policy.Multiplier < 1 {
+		return errors.New("retry.multiplier must be greater than or equal to 1")
+	}
+	if policy.Jitter != nil && (*policy.Jitter < 0 || *policy.Jitter > 1) {
+		return errors.New("retry.jitter must be in [0,1]")
+	}
+	return nil
+}
+
+// ShouldRetry 判断第 attempts 次调用失败后是否继续重试. policy 为 nil 时不重试.
+func (policy *RetryPolicy) ShouldRetry(attempts int, err error) bool {
+	if policy == nil || attempts >= policy.MaxAttempts {
+		return false
+	}
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+	return retryable(err)
+}
+
+// Backoff 返回第 attempts 次调用失败后, 下一次调用前需要等待的时间.
+func (policy *RetryPolicy) Backoff(attempts int) time.Duration {
+	backoff := float64(policy.InitialBackoff) * math.Pow(policy.Multiplier, float64(attempts-1))
+	if backoff > float64(policy.MaxBackoff) {
+		backoff = float64(policy.MaxBackoff)
+	}
+	// 抖动范围 backoff*[1-jitter, 1+jitter)
+	jitter := policy.GetJitter()
+	backoff *= 1 - jitter + rand.Float64()*2*jitter
+	return time.Duration(backoff)
+}
+
+// GetJitter 返回抖动比例, 未设置时返回 DefaultRetryJitter.
+func (policy *RetryPolicy) GetJitter() float64 {
+	if policy.Jitter == nil {
+		return DefaultRetryJitter
+	}
+	return *policy.Jitter
+}
+
+// 默认的重试判断. 超时/取消, 找不到函数, 熔断, 以及命中缓存的错误重试也不会成功, 不进行重试.
+// 被限流时重试会进一步超出配额, 也不进行重试.
+func DefaultRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, constant.NotFoundError) || errors.Is(err, constant.CircuitOpenError) ||
+		errors.Is(err, constant.RateLimitedError) || errors.Is(err, constant.NegativeCacheError) {
+		return false
+	}
+	return true
+}
+
+// 选取 fields 中最大调用次数的重试策略作为节点的重试策略, 与 timeout 的处理方式相同.
+func mergeRetryPolicy(fields []*Field) *RetryPolicy {
+	var policy *RetryPolicy
+	for _, field := range fields {
+		if field.Retry == nil {
+			continue
+		}
+		if policy == nil || field.Retry.MaxAttempts > policy.MaxAttempts {
+			policy = field.Retry
+		}
+	}
+	return policy
+}
@@ -0,0 +1,174 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/constant"
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+	"git.in.zhihu.com/antispam/datasupply/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Millisecond * 10,
+		MaxBackoff:     time.Millisecond * 50,
+	}
+	policy.LoadDefault()
+	assert.NoError(t, policy.Validate())
+
+	testCases := []struct {
+		name     string
+		attempts int
+		base     time.Duration
+	}{
+		{"first", 1, time.Millisecond * 10},
+		{"second", 2, time.Millisecond * 20},
+		{"third", 3, time.Millisecond * 40},
+		{"max_backoff", 4, time.Millisecond * 50},
+	}
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			backoff := policy.Backoff(tcase.attempts)
+			jitter := time.Duration(float64(tcase.base) * policy.GetJitter())
+			assert.GreaterOrEqual(t, backoff, tcase.base-jitter)
+			assert.LessOrEqual(t, backoff, tcase.base+jitter)
+		})
+	}
+}
+
+func TestRetryPolicyJitter(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond * 10}
+	policy.LoadDefault()
+	assert.Equal(t, float64(DefaultRetryJitter), policy.GetJitter())
+
+	// 显式设置为 0 时不抖动
+	noJitter := &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond * 10}
+	assert.NoError(t, json.Unmarshal([]byte(`{"jitter": 0}`), noJitter))
+	noJitter.LoadDefault()
+	assert.NoError(t, noJitter.Validate())
+	assert.Equal(t, time.Millisecond*10, noJitter.Backoff(1))
+
+	invalid := 1.5
+	assert.Error(t, (&RetryPolicy{Multiplier: 2, Jitter: &invalid}).Validate())
+}
+
+func TestRetryPolicyJSON(t *testing.T) {
+	testCases := []struct {
+		name           string
+		data           string
+		initialBackoff time.Duration
+		maxBackoff     time.Duration
+		isErr          bool
+	}{
+		{"milliseconds", `{"max_attempts": 3, "initial_backoff": 10, "max_backoff": 100}`, time.Millisecond * 10, time.Millisecond * 100, false},
+		{"fraction", `{"initial_backoff": 0.5}`, time.Microsecond * 500, 0, false},
+		{"duration_string", `{"initial_backoff": "100ms", "max_backoff": "1.5s"}`, time.Millisecond * 100, time.Millisecond * 1500, false},
+		{"empty", `{"max_attempts": 3}`, 0, 0, false},
+		{"bad_string", `{"initial_backoff": "10x"}`, 0, 0, true},
+		{"bad_type", `{"max_backoff": true}`, 0, 0, true},
+	}
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			policy := &RetryPolicy{}
+			err := json.Unmarshal([]byte(tcase.data), policy)
+			if tcase.isErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tcase.initialBackoff, policy.InitialBackoff)
+			assert.Equal(t, tcase.maxBackoff, policy.MaxBackoff)
+		})
+	}
+
+	// 编码后可以还原
+	jitter := 0.1
+	policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond * 20, MaxBackoff: time.Second, Multiplier: 2, Jitter: &jitter}
+	data, err := json.Marshal(policy)
+	assert.NoError(t, err)
+	decoded := &RetryPolicy{}
+	assert.NoError(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, policy, decoded)
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 2}
+	policy.LoadDefault()
+
+	testCases := []struct {
+		name     string
+		policy   *RetryPolicy
+		attempts int
+		err      error
+		expect   bool
+	}{
+		{"nil_policy", nil, 1, errors.New("fake error"), false},
+		{"retryable", policy, 1, errors.New("fake error"), true},
+		{"max_attempts", policy, 2, errors.New("fake error"), false},
+		{"timeout", policy, 1, context.DeadlineExceeded, false},
+		{"not_found", policy, 1, constant.NotFoundError, false},
+		{"circuit_open", policy, 1, constant.CircuitOpenError, false},
+		{"rate_limited", policy, 1, constant.RateLimitedError, false},
+		{"negative_cache", policy, 1, constant.NegativeCacheError, false},
+	}
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			assert.Equal(t, tcase.expect, tcase.policy.ShouldRetry(tcase.attempts, tcase.err))
+		})
+	}
+}
+
+func TestNodeRetry(t *testing.T) {
+	var calls int32
+	testSupplier := tests.NewTestSupplier()
+	testSupplier.RegisterPlugin(supplier.NewDefaultPlugin("Flaky",
+		func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				return nil, errors.New("fake error")
+			}
+			return map[string]interface{}{"out": "x"}, nil
+		}))
+
+	newNode := func(maxAttempts int) *Node {
+		cnode, err := New(&CreateNodeRequest{
+			FuncName: "Flaky",
+			Params:   []Param{},
+			Supplier: testSupplier,
+			Fields: []*Field{
+				{
+					Code:          "out_field",
+					FieldOfSupply: "out",
+					FieldType:     dtype.String,
+					Retry: &RetryPolicy{
+						MaxAttempts:    maxAttempts,
+						InitialBackoff: time.Millisecond,
+					},
+				},
+			},
+			Logger: tests.DefaultLogger,
+		})
+		assert.NoError(t, err)
+		return cnode
+	}
+
+	t.Run("success_after_retry", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		result := newNode(3).Run(context.Background(), map[string]interface{}{})
+		assert.Equal(t, "x", result["out_field"].Value)
+		assert.Equal(t, 3, result["out_field"].Meta.GetAttempts())
+	})
+	t.Run("attempts_exhausted", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		result := newNode(2).Run(context.Background(), map[string]interface{}{})
+		assert.NotEmpty(t, result["out_field"].Meta.GetFailReason())
+		assert.Equal(t, 2, result["out_field"].Meta.GetAttempts())
+	})
+}
@@ -30,6 +30,16 @@ type Runtime struct {
 
 var _ IRuntime = new(Runtime)
 
+func newRuntime(node INode) *Runtime {
+	return &Runtime{
+		varParams: map[string]interface{}{},
+		node:      node,
+		nodeid:    node.GetID(),
+		paramCnt:  int64(len(node.GetParamVariables())),
+		locker:    &sync.Mutex{},
+	}
+}
+
 func (runtime *Runtime) GetNode() INode {
 	return runtime.node
 }
@@ -0,0 +1,84 @@
+package node
+
+import (
+	"fmt"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+)
+
+// ValidateSchema 使用插件的描述信息(supplier.IDescribedPlugin)校验节点的参数和字段配置, 包括字段的备用数据源.
+// 映射模式下按照数组元素的类型校验被映射的参数.
+// 插件不存在或未提供描述时不校验, 保持与运行时相同的行为.
+func ValidateSchema(cnode INode) error {
+	fieldOfSupply := make(map[string]string, len(cnode.GetFields()))
+	for _, field := range cnode.GetFields() {
+		fieldOfSupply[field.Code] = field.FieldOfSupply
+	}
+	params := cnode.GetParamsByFunc(SupplierFunc)
+	if policy := cnode.GetMapPolicy(); policy != nil {
+		// 映射模式下插件的参数为数组的元素
+		params = append([]Param{}, params...)
+		params[policy.ParamIndex].ValueType, _ = mapElemType(params[policy.ParamIndex].ValueType)
+	}
+	err := validatePluginSchema(cnode.GetSupplier(), cnode.GetFuncName(), params, fieldOfSupply)
+	if err != nil {
+		return fmt.Errorf("node [%s] schema validate error: %s", cnode.GetID(), err.Error())
+	}
+
+	for _, field := range cnode.GetFields() {
+		for i, source := range field.Fallbacks {
+			err := validatePluginSchema(source.Supplier, source.FuncName,
+				cnode.GetParamsByFunc(fallbackFunc(field.Code, i)),
+				map[string]string{field.Code: source.FieldOfSupply})
+			if err != nil {
+				return fmt.Errorf("node [%s] field [%s] fallback %d schema validate error: %s",
+					cnode.GetID(), field.Code, i, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// fieldOfSupply key: field.code, value: 取插件返回值的 key
+func validatePluginSchema(sup supplier.ISupplier, funcName string, params []Param,
+	fieldOfSupply map[string]string) error {
+	if sup == nil {
+		return nil
+	}
+	plugin, ok := sup.GetPlugin(funcName)
+	if !ok {
+		return nil
+	}
+	schema, ok := supplier.DescribePlugin(plugin)
+	if !ok {
+		return nil
+	}
+
+	paramTypes := make([]dtype.DType, len(params))
+	for i, param := range params {
+		paramTypes[i] = param.ValueType
+	}
+	if err := schema.ValidateParams(paramTypes); err != nil {
+		return err
+	}
+	if schema.CheckParams != nil {
+		values := make([]interface{}, len(params))
+		for i, param := range params {
+			if param.Kind == ParamConstant {
+				values[i] = param.Value
+			}
+		}
+		if err := schema.CheckParams(values, paramTypes); err != nil {
+			return err
+		}
+	}
+
+	for fieldCode, output := range fieldOfSupply {
+		if _, ok := schema.GetOutput(output); !ok {
+			return fmt.Errorf("field [%s] field_of_supply [%s] not found in plugin %s outputs",
+				fieldCode, output, schema.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package node
+
+import (
+	"context"
+	"fmt"
+)
+
+// DAGNode 共享节点在单个 DAG 中的视图.
+// 节点的参数, supplier, 中间件和调用由共享节点提供, 共享节点不持有上下游关系, 可以同时用于多个 DAG;
+// 上下游关系, 补数阶段, 权重和输出字段属于各自的 DAG.
+type DAGNode struct {
+	INode
+
+	fields      []*Field // 共享节点字段的子集, 需要是副本, 如按 DAG 设置 NotExport
+	fieldCodes  []string
+	supplyStage SupplyStage // min(fields.supplyStage)
+	priority    int
+
+	prevs []INode
+	nexts []INode
+}
+
+var _ INode = new(DAGNode)
+
+// NewDAGNode 使用共享节点的部分字段创建 DAG 内的节点, 字段需要由共享节点产生.
+func NewDAGNode(shared INode, fields []*Field) (*DAGNode, error) {
+	sharedCodes := make(map[string]struct{}, len(shared.GetFieldCodes()))
+	for _, code := range shared.GetFieldCodes() {
+		sharedCodes[code] = struct{}{}
+	}
+	supplyStage := SupplyStageLazy
+	fieldCodes := make([]string, len(fields))
+	for i, field := range fields {
+		if _, ok := sharedCodes[field.Code]; !ok {
+			return nil, fmt.Errorf("node [%s] field [%s] not found", shared.GetID(), field.Code)
+		}
+		fieldCodes[i] = field.Code
+		if supplyStage > field.SupplyStage {
+			supplyStage = field.SupplyStage
+		}
+	}
+	return &DAGNode{
+		INode:       shared,
+		fields:      fields,
+		fieldCodes:  fieldCodes,
+		supplyStage: supplyStage,
+		priority:    shared.GetPriority(),
+	}, nil
+}
+
+func (cnode *DAGNode) GetPriority() int {
+	return cnode.priority
+}
+
+func (cnode *DAGNode) GetSupplyStage() SupplyStage {
+	return cnode.supplyStage
+}
+
+func (cnode *DAGNode) GetPrevs() []INode {
+	return cnode.prevs
+}
+
+func (cnode *DAGNode) GetNexts() []INode {
+	return cnode.nexts
+}
+
+func (cnode *DAGNode) GetFields() []*Field {
+	return cnode.fields
+}
+
+func (cnode *DAGNode) GetFieldCodes() []string {
+	return cnode.fieldCodes
+}
+
+func (cnode *DAGNode) CreateRuntime() IRuntime {
+	return newRuntime(cnode)
+}
+
+// Run 调用共享节点, 只返回当前 DAG 的字段.
+func (cnode *DAGNode) Run(ctx context.Context, paramMap map[string]interface{}) Result {
+	return cnode.filter(cnode.INode.Run(ctx, paramMap))
+}
+
+func (cnode *DAGNode) Prune() []INode {
+	return pruneNexts(cnode.nexts)
+}
+
+func (cnode *DAGNode) ValueOnPrune() Result {
+	return cnode.ValueOnError("prune")
+}
+
+func (cnode *DAGNode) ValueOnError(failReason string) Result {
+	result := make(Result, len(cnode.fields))
+	for _, field := range cnode.fields {
+		result[field.Code] = field.ValueOnError(failReason)
+	}
+	return result
+}
+
+// AddFields 合并相同调用的节点时使用, 字段同样需要由共享节点产生.
+func (cnode *DAGNode) AddFields(fields ...*Field) {
+	fields = append(fields, cnode.fields...)
+	fieldIDSet := make(map[string]struct{}, len(fields))
+	newFields := make([]*Field, 0, len(fields))
+	newfieldCodes := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if _, ok := fieldIDSet[field.ID]; ok {
+			continue
+		}
+		fieldIDSet[field.ID] = struct{}{}
+		newFields = append(newFields, field)
+		newfieldCodes = append(newfieldCodes, field.Code)
+	}
+	cnode.fields = newFields
+	cnode.fieldCodes = newfieldCodes
+}
+
+func (cnode *DAGNode) RemoveNexts(nodes ...INode) {
+	removeNodeMap := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		removeNodeMap[node.GetID()] = struct{}{}
+	}
+	newNexts := make([]INode, 0, len(cnode.nexts))
+	for _, node := range cnode.nexts {
+		if _, ok := removeNodeMap[node.GetID()]; !ok {
+			newNexts = append(newNexts, node)
+		}
+	}
+	cnode.nexts = newNexts
+}
+
+func (cnode *DAGNode) AddPrevs(nodes ...INode) {
+	cnode.prevs = Deduplication(append(cnode.prevs, nodes...))
+}
+
+func (cnode *DAGNode) AddNexts(nodes ...INode) {
+	cnode.nexts = Deduplication(append(cnode.nexts, nodes...))
+}
+
+func (cnode *DAGNode) SetPriority(priority int) {
+	cnode.priority = priority
+}
+
+func (cnode *DAGNode) SetSupplyStage(stage SupplyStage) {
+	cnode.supplyStage = stage
+}
+
+func (cnode *DAGNode) filter(result Result) Result {
+	filtered := make(Result, len(cnode.fieldCodes))
+	for _, code := range cnode.fieldCodes {
+		if fieldResult, ok := result[code]; ok {
+			filtered[code] = fieldResult
+		}
+	}
+	return filtered
+}
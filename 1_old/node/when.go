@@ -0,0 +1,89 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"git.in.zhihu.com/antispam/datasupply/expr"
+)
+
+// 执行条件的参数所属的函数, 与 SupplierFunc 和中间件区分.
+const WhenFunc = "when"
+
+// WhenCondition 节点的执行条件, 根据上游字段判断是否需要调用 supplier.
+// 条件不为 true(包括 null) 时跳过调用, 节点字段的失败原因为 FieldFailReson_Skipped.
+type WhenCondition struct {
+	// 表达式, 结果必须为 bool, 语法见 expr 包. 如 `has_image && image_cnt > 0`
+	Expr string `json:"expr"`
+	// 表达式中的变量. ParamName 为变量名, DagFieldName 为取值的上游字段, ParamType 为变量类型.
+	// 上游字段失败时按照 OnError 处理, 需要按 null 求值时设置为 skip.
+	Vars []*CreateVarParamRequest `json:"vars"`
+
+	expr   *expr.Expr
+	params []Param
+}
+
+func (cond *WhenCondition) Validate() error {
+	if cond.Expr == "" {
+		return errors.New("when expr can not be empty")
+	}
+	vars := make([]expr.Var, len(cond.Vars))
+	params := make([]Param, len(cond.Vars))
+	for i, v := range cond.Vars {
+		param, err := NewVariableParam(v)
+		if err != nil {
+			return fmt.Errorf("when var [%s] error: %s", v.ParamName, err.Error())
+		}
+		vars[i] = expr.Var{Name: v.ParamName, Type: v.ParamType}
+		params[i] = *param
+	}
+	e, err := expr.Compile(cond.Expr, vars)
+	if err != nil {
+		return err
+	}
+	if e.Type() != dtype.Bool {
+		return fmt.Errorf("when expr [%s] type must be bool, got %s", cond.Expr, e.Type())
+	}
+	cond.expr = e
+	cond.params = params
+	return nil
+}
+
+// ID 参与生成节点 ID, 条件不同的节点不会被合并.
+func (cond *WhenCondition) ID() string {
+	builder := strings.Builder{}
+	builder.WriteString(WhenFunc)
+	builder.WriteString("_")
+	if cond.expr != nil {
+		builder.WriteString(cond.expr.String())
+	} else {
+		builder.WriteString(cond.Expr)
+	}
+	for _, v := range cond.Vars {
+		builder.WriteString("_")
+		builder.WriteString(v.ParamName)
+		builder.WriteString("_")
+		builder.WriteString(v.DagFieldName)
+		if v.SubPath != "" {
+			builder.WriteString(".")
+			builder.WriteString(v.SubPath)
+		}
+	}
+	return builder.String()
+}
+
+// 使用 paramMap 中的上游字段值求值. 需要先调用 Validate.
+func (cond *WhenCondition) check(paramMap map[string]interface{}) (bool, error) {
+	values := make([]interface{}, len(cond.params))
+	for i, param := range cond.params {
+		values[i] = param.varValue(paramMap)
+	}
+	value, err := cond.expr.Eval(values...)
+	if err != nil {
+		return false, err
+	}
+	ok, _ := value.(bool)
+	return ok, nil
+}
@@ -0,0 +1,270 @@
+package datasupply
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"git.in.zhihu.com/antispam/datasupply/dag"
+	"git.in.zhihu.com/antispam/datasupply/node"
+)
+
+// Registry 统一管理入口和节点的定义, 按字段子集构建多个 DAG.
+// 每个定义只构建一次节点, 所有 DAG 共享该节点; 每个 DAG 通过 node.DAGNode 持有自己的上下游关系和输出字段.
+// Registry 是并发安全的, 重新构建 DAG 时 Get 返回旧的 DAG, 直到新的 DAG 构建完成.
+type Registry struct {
+	locker  sync.RWMutex
+	entries map[string]*nodeDefinition // key: 入口名称
+	nodes   []*nodeDefinition
+	fields  map[string]*nodeDefinition // key: field.code, value: 产生该字段的节点定义
+
+	dagLocker sync.RWMutex
+	dags      map[string]dag.IDAG
+}
+
+// 注册的节点定义.
+type nodeDefinition struct {
+	cfg     *NodeConfig
+	options []node.Option
+	node    node.INode // 共享的节点, 不在任何 DAG 中
+	params  []string   // 变量参数依赖的字段, 包括执行条件和备用数据源的参数
+}
+
+// RegistryDAGConfig 从 Registry 中选择入口和字段构建 DAG.
+type RegistryDAGConfig struct {
+	DAGConfig
+	Entries []string // 使用的入口, 为空时使用全部入口
+	// 输出的字段, 为空时使用全部字段. 依赖的字段会被自动加入, 但不导出.
+	Fields []string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		entries: map[string]*nodeDefinition{},
+		fields:  map[string]*nodeDefinition{},
+		dags:    map[string]dag.IDAG{},
+	}
+}
+
+// RegisterEntry 注册入口的 root 定义.
+func (r *Registry) RegisterEntry(entry string, cfg *NodeConfig, options ...node.Option) error {
+	def, err := newNodeDefinition(cfg, options)
+	if err != nil {
+		return fmt.Errorf("entry [%s] error: %s", entry, err.Error())
+	}
+
+	r.locker.Lock()
+	defer r.locker.Unlock()
+	if _, ok := r.entries[entry]; ok {
+		return fmt.Errorf("entry [%s] repeat", entry)
+	}
+	r.entries[entry] = def
+	return nil
+}
+
+// RegisterNode 注册节点定义. 一个字段只能由一个节点定义产生.
+func (r *Registry) RegisterNode(cfg *NodeConfig, options ...node.Option) error {
+	def, err := newNodeDefinition(cfg, options)
+	if err != nil {
+		return err
+	}
+
+	r.locker.Lock()
+	defer r.locker.Unlock()
+	for _, field := range cfg.Fields {
+		if _, ok := r.fields[field.Code]; ok {
+			return fmt.Errorf("field [%s] repeat", field.Code)
+		}
+	}
+	// 与已注册的定义调用相同时合并为一个节点. 与 dag 合并节点相同, 使用先注册的 option.
+	for i, exist := range r.nodes {
+		if exist.node.GetID() != def.node.GetID() {
+			continue
+		}
+		mergedCfg := *exist.cfg
+		mergedCfg.Fields = append(append([]*node.Field{}, exist.cfg.Fields...), def.cfg.Fields...)
+		merged, err := newNodeDefinition(&mergedCfg, exist.options)
+		if err != nil {
+			return err
+		}
+		r.nodes[i] = merged
+		for _, field := range mergedCfg.Fields {
+			r.fields[field.Code] = merged
+		}
+		return nil
+	}
+	for _, field := range cfg.Fields {
+		r.fields[field.Code] = def
+	}
+	r.nodes = append(r.nodes, def)
+	return nil
+}
+
+// BuildDAG 按照配置构建 DAG, 构建成功后替换 Registry 中相同 ID 的 DAG.
+func (r *Registry) BuildDAG(cfg *RegistryDAGConfig, options ...dag.Option) (dag.IDAG, error) {
+	request, err := r.createDAGRequest(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("dag [%s] error: %s", cfg.ID, err.Error())
+	}
+	newDAG, err := dag.New(request, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	r.dagLocker.Lock()
+	defer r.dagLocker.Unlock()
+	r.dags[cfg.ID] = newDAG
+	return newDAG, nil
+}
+
+// Get 返回已构建的 DAG.
+func (r *Registry) Get(dagID string) (dag.IDAG, bool) {
+	r.dagLocker.RLock()
+	defer r.dagLocker.RUnlock()
+	d, ok := r.dags[dagID]
+	return d, ok
+}
+
+func (r *Registry) createDAGRequest(cfg *RegistryDAGConfig) (*dag.CreateDAGRequest, error) {
+	r.locker.RLock()
+	defer r.locker.RUnlock()
+
+	entryNames := cfg.Entries
+	if len(entryNames) == 0 {
+		for entry := range r.entries {
+			entryNames = append(entryNames, entry)
+		}
+		sort.Strings(entryNames)
+	}
+	inputs := map[string]struct{}{}
+	entries := make(map[string]node.INode, len(entryNames))
+	for _, entry := range entryNames {
+		def, ok := r.entries[entry]
+		if !ok {
+			return nil, fmt.Errorf("entry [%s] not found", entry)
+		}
+		root, err := def.dagNode(nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		entries[entry] = root
+		for _, code := range def.node.GetFieldCodes() {
+			inputs[code] = struct{}{}
+		}
+	}
+
+	fieldCodes := cfg.Fields
+	if len(fieldCodes) == 0 {
+		for _, def := range r.nodes {
+			fieldCodes = append(fieldCodes, def.node.GetFieldCodes()...)
+		}
+	}
+	exports := make(map[string]struct{}, len(fieldCodes))
+	for _, code := range fieldCodes {
+		if _, ok := r.fields[code]; !ok {
+			return nil, fmt.Errorf("field [%s] not found", code)
+		}
+		exports[code] = struct{}{}
+	}
+
+	// 加入输出字段依赖的字段. 入口和节点都不产生的参数由 dag 按照孤儿节点处理.
+	selected := map[*nodeDefinition]map[string]struct{}{}
+	for queue := append([]string{}, fieldCodes...); len(queue) > 0; queue = queue[1:] {
+		def, ok := r.fields[queue[0]]
+		if !ok {
+			continue
+		}
+		if _, ok := selected[def][queue[0]]; ok {
+			continue
+		}
+		if selected[def] == nil {
+			selected[def] = map[string]struct{}{}
+		}
+		selected[def][queue[0]] = struct{}{}
+		for _, param := range def.params {
+			if _, ok := inputs[param]; !ok {
+				queue = append(queue, param)
+			}
+		}
+	}
+
+	nodes := make([]node.INode, 0, len(selected))
+	for _, def := range r.nodes {
+		codes, ok := selected[def]
+		if !ok {
+			continue
+		}
+		cnode, err := def.dagNode(codes, exports)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, cnode)
+	}
+
+	return &dag.CreateDAGRequest{
+		Entries:        entries,
+		Nodes:          nodes,
+		ID:             cfg.ID,
+		NodeConcurrent: cfg.NodeConcurrent,
+		Logger:         cfg.Logger,
+	}, nil
+}
+
+// 构建共享的节点, 校验配置并获取参数依赖的字段.
+// 构建时修改的配置(如默认值)作用在副本上, 不会影响调用方的配置.
+func newNodeDefinition(cfg *NodeConfig, options []node.Option) (*nodeDefinition, error) {
+	def := &nodeDefinition{cfg: copyNodeConfig(cfg), options: options}
+	cnode, err := buildNode(copyNodeConfig(cfg), options...)
+	if err != nil {
+		return nil, err
+	}
+	def.node = cnode
+	for _, param := range cnode.GetParamVariables() {
+		def.params = append(def.params, param.FieldName)
+	}
+	// 备用数据源的参数不是节点的依赖, 但需要加入 DAG 才能在运行时按需取值
+	for _, field := range cnode.GetFields() {
+		for _, source := range field.Fallbacks {
+			for _, param := range source.Params {
+				if param.Kind == node.ParamVariable {
+					def.params = append(def.params, param.FieldName)
+				}
+			}
+		}
+	}
+	return def, nil
+}
+
+func copyNodeConfig(cfg *NodeConfig) *NodeConfig {
+	c := *cfg
+	c.Params = append([]node.Param{}, cfg.Params...)
+	c.Fields = make([]*node.Field, len(cfg.Fields))
+	for i, field := range cfg.Fields {
+		c.Fields[i] = field.Copy()
+	}
+	if cfg.When != nil {
+		when := *cfg.When
+		c.When = &when
+	}
+	if cfg.Map != nil {
+		policy := *cfg.Map
+		c.Map = &policy
+	}
+	return &c
+}
+
+// 使用共享节点的字段创建 DAG 内的节点. codes 为空时使用全部字段, exports 不为空时, 不在 exports 中的字段不导出.
+func (def *nodeDefinition) dagNode(codes, exports map[string]struct{}) (node.INode, error) {
+	fields := make([]*node.Field, 0, len(def.node.GetFields()))
+	for _, field := range def.node.GetFields() {
+		if _, ok := codes[field.Code]; codes != nil && !ok {
+			continue
+		}
+		f := field.Copy()
+		if _, ok := exports[f.Code]; exports != nil && !ok {
+			f.NotExport = true
+		}
+		fields = append(fields, f)
+	}
+	return node.NewDAGNode(def.node, fields)
+}
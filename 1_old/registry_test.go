@@ -0,0 +1,130 @@
+package datasupply
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"git.in.zhihu.com/antispam/datasupply/node"
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+	"git.in.zhihu.com/antispam/datasupply/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+// comment: comment_in -> content, uid
+// uid -> user_level -> user_risk; content -> text_label
+func TestRegistry(t *testing.T) {
+	registrySupplier := supplier.NewDefaultSupplier("supplier_registry", []supplier.IPlugin{
+		tests.NewTestPlugin("comment_root", []string{"comment_in"}, []string{"content", "uid"}),
+		tests.NewTestPlugin("user_func", []string{"uid"}, []string{"user_level"}),
+		tests.NewTestPlugin("risk_func", []string{"user_level"}, []string{"user_risk"}),
+		tests.NewTestPlugin("text_func", []string{"content"}, []string{"text_label"}),
+	})
+	registry := NewRegistry()
+	root := genNodeCfg("comment_root", []string{"comment_in"}, []string{"content", "uid"}, registrySupplier)
+	root[0].Fields = append(root[0].Fields, root[1].Fields...)
+	assert.NoError(t, registry.RegisterEntry("comment", root[0]))
+	assert.Error(t, registry.RegisterEntry("comment", root[0]))
+	for _, cfg := range [][]*NodeConfig{
+		genNodeCfg("user_func", []string{"uid"}, []string{"user_level"}, registrySupplier),
+		genNodeCfg("risk_func", []string{"user_level"}, []string{"user_risk"}, registrySupplier),
+		genNodeCfg("text_func", []string{"content"}, []string{"text_label"}, registrySupplier),
+	} {
+		assert.NoError(t, registry.RegisterNode(cfg[0]))
+	}
+	assert.Error(t, registry.RegisterNode(
+		genNodeCfg("text_func", []string{"content"}, []string{"text_label"}, registrySupplier)[0]))
+
+	testCases := []struct {
+		id     string
+		fields []string
+		expect []string
+	}{
+		{"all", nil, []string{"content", "uid", "user_level", "user_risk", "text_label"}},
+		// user_level 作为依赖加入, 但不导出
+		{"risk", []string{"user_risk"}, []string{"content", "uid", "user_risk"}},
+		{"text", []string{"text_label"}, []string{"content", "uid", "text_label"}},
+	}
+	for _, tcase := range testCases {
+		_, err := registry.BuildDAG(&RegistryDAGConfig{
+			DAGConfig: DAGConfig{ID: tcase.id, NodeConcurrent: 2},
+			Fields:    tcase.fields,
+		})
+		assert.NoError(t, err, tcase.id)
+	}
+	for _, tcase := range testCases {
+		d, ok := registry.Get(tcase.id)
+		assert.True(t, ok, tcase.id)
+		result := d.Supply(context.TODO(), "test", map[string]interface{}{"comment_in": "x"})
+		assert.Len(t, result.Fields, len(tcase.expect), tcase.id)
+		for _, field := range tcase.expect {
+			value, err := result.GetFieldValue(field)
+			assert.NoError(t, err, field)
+			assert.Equal(t, "x", value, field)
+		}
+	}
+	_, ok := registry.Get("unknown")
+	assert.False(t, ok)
+
+	for _, cfg := range []*RegistryDAGConfig{
+		{DAGConfig: DAGConfig{ID: "unknown_field"}, Fields: []string{"unknown"}},
+		{DAGConfig: DAGConfig{ID: "unknown_entry"}, Entries: []string{"unknown"}},
+	} {
+		_, err := registry.BuildDAG(cfg)
+		assert.Error(t, err, cfg.ID)
+	}
+}
+
+// 同一定义只构建一次节点, 在多个 DAG 中共享; 调用相同的定义合并为一个节点.
+func TestRegistrySharedNode(t *testing.T) {
+	registrySupplier := supplier.NewDefaultSupplier("supplier_registry_shared", []supplier.IPlugin{
+		tests.NewTestPlugin("comment_root", []string{"comment_in"}, []string{"uid"}),
+		tests.NewTestPlugin("user_func", []string{"uid"}, []string{"user_level", "user_tag"}),
+	})
+	var builds int32
+	countBuild := func(*node.Node) { atomic.AddInt32(&builds, 1) }
+	registry := NewRegistry()
+	assert.NoError(t, registry.RegisterEntry("comment",
+		genNodeCfg("comment_root", []string{"comment_in"}, []string{"uid"}, registrySupplier)[0]))
+	cfgs := genNodeCfg("user_func", []string{"uid"}, []string{"user_level", "user_tag"}, registrySupplier)
+	cfgs[0].Fields[0].Retry = &node.RetryPolicy{MaxAttempts: 2}
+	cfgs[0].Fields[0].Fallbacks = []*node.FieldSource{
+		{Supplier: registrySupplier, FuncName: "user_func", Params: cfgs[0].Params}}
+	for _, cfg := range cfgs {
+		assert.NoError(t, registry.RegisterNode(cfg, countBuild))
+	}
+	// 构建时的默认值不会写回调用方的配置
+	assert.Nil(t, cfgs[0].Fields[0].Retry.Jitter)
+	assert.Equal(t, "", cfgs[0].Fields[0].Fallbacks[0].FieldOfSupply)
+	assert.Equal(t, "", cfgs[0].Fields[0].ID)
+
+	registered := atomic.LoadInt32(&builds)
+	testCases := []struct {
+		id     string
+		fields []string
+		expect []string
+	}{
+		{"level", []string{"user_level"}, []string{"uid", "user_level"}},
+		{"tag", []string{"user_tag"}, []string{"uid", "user_tag"}},
+		{"all", nil, []string{"uid", "user_level", "user_tag"}},
+	}
+	for _, tcase := range testCases {
+		_, err := registry.BuildDAG(&RegistryDAGConfig{
+			DAGConfig: DAGConfig{ID: tcase.id},
+			Fields:    tcase.fields,
+		})
+		assert.NoError(t, err, tcase.id)
+	}
+	assert.Equal(t, registered, atomic.LoadInt32(&builds))
+	// 共享节点的上下游关系和输出字段属于各自的 DAG, 互不影响
+	for _, tcase := range testCases {
+		d, _ := registry.Get(tcase.id)
+		result := d.Supply(context.TODO(), "test", map[string]interface{}{"comment_in": "x"})
+		assert.Len(t, result.Fields, len(tcase.expect), tcase.id)
+		for _, field := range tcase.expect {
+			value, err := result.GetFieldValue(field)
+			assert.NoError(t, err, field)
+			assert.Equal(t, "x", value, field)
+		}
+	}
+}
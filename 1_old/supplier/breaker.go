@@ -0,0 +1,231 @@
+package supplier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/constant"
+)
+
+const (
+	DefaultBreakerWindow           = time.Second * 10
+	DefaultBreakerMinRequests      = 20
+	DefaultBreakerErrorRate        = 0.5
+	DefaultBreakerOpenDuration     = time.Second * 5
+	DefaultBreakerHalfOpenRequests = 5
+)
+
+// 熔断器状态. closed: 正常调用; open: 直接返回 constant.CircuitOpenError;
+// half_open: 放行少量探测请求, 全部成功则关闭, 任一失败则重新打开.
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+var BreakerStateNames = []string{
+	BreakerClosed:   "closed",
+	BreakerOpen:     "open",
+	BreakerHalfOpen: "half_open",
+}
+
+func (s BreakerState) String() string {
+	if int(s) < len(BreakerStateNames) {
+		return BreakerStateNames[s]
+	}
+	return "breaker_state_" + strconv.Itoa(int(s))
+}
+
+func (s BreakerState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// BreakerConfig 熔断配置, 由错误率和慢调用驱动. 慢调用视为失败.
+type BreakerConfig struct {
+	Window           time.Duration `json:"window"`             // 错误率统计窗口
+	MinRequests      int           `json:"min_requests"`       // 窗口内请求数达到该值后才判断错误率
+	ErrorRate        float64       `json:"error_rate"`         // 错误率阈值, 范围 (0,1]
+	SlowCallDuration time.Duration `json:"slow_call_duration"` // 超过该耗时的调用视为失败, 0 表示不判断
+	OpenDuration     time.Duration `json:"open_duration"`      // 打开状态持续时间, 之后进入半开状态
+	HalfOpenRequests int           `json:"half_open_requests"` // 半开状态放行的探测请求数
+}
+
+func (cfg *BreakerConfig) LoadDefault() {
+	if cfg.Window == 0 {
+		cfg.Window = DefaultBreakerWindow
+	}
+	if cfg.MinRequests == 0 {
+		cfg.MinRequests = DefaultBreakerMinRequests
+	}
+	if cfg.ErrorRate <= 0 || cfg.ErrorRate > 1 {
+		cfg.ErrorRate = DefaultBreakerErrorRate
+	}
+	if cfg.OpenDuration == 0 {
+		cfg.OpenDuration = DefaultBreakerOpenDuration
+	}
+	if cfg.HalfOpenRequests == 0 {
+		cfg.HalfOpenRequests = DefaultBreakerHalfOpenRequests
+	}
+}
+
+// CircuitBreakerPlugin 为插件添加熔断能力. 后端不可用时快速失败, 避免每次调用都等待节点超时.
+type CircuitBreakerPlugin struct {
+	plugin IPlugin
+	cfg    BreakerConfig
+
+	locker       sync.Locker
+	state        BreakerState
+	windowStart  time.Time
+	total        int
+	failures     int
+	openUntil    time.Time
+	probes       int // 半开状态已放行的请求数
+	probeSuccess int // 半开状态成功的请求数
+}
+
+var _ IWrappedPlugin = new(CircuitBreakerPlugin)
+
+func NewCircuitBreakerPlugin(plugin IPlugin, _cfg *BreakerConfig) *CircuitBreakerPlugin {
+	cfg := BreakerConfig{}
+	if _cfg != nil {
+		cfg = *_cfg
+	}
+	cfg.LoadDefault()
+	return &CircuitBreakerPlugin{
+		plugin:      plugin,
+		cfg:         cfg,
+		locker:      &sync.Mutex{},
+		state:       BreakerClosed,
+		windowStart: time.Now(),
+	}
+}
+
+func (p *CircuitBreakerPlugin) GetName() string {
+	return p.plugin.GetName()
+}
+
+// Unwrap 返回被包装的插件.
+func (p *CircuitBreakerPlugin) Unwrap() IPlugin {
+	return p.plugin
+}
+
+// State 返回熔断器当前状态.
+func (p *CircuitBreakerPlugin) State() BreakerState {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	if p.state == BreakerOpen && !time.Now().Before(p.openUntil) {
+		return BreakerHalfOpen
+	}
+	return p.state
+}
+
+func (p *CircuitBreakerPlugin) Call(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+	if !p.allow() {
+		return map[string]interface{}{}, constant.CircuitOpenError
+	}
+	start := time.Now()
+	out, err := p.plugin.Call(ctx, args...)
+	// 调用方主动取消不是后端的问题, 不计入统计
+	if errors.Is(err, context.Canceled) {
+		p.release()
+		return out, err
+	}
+	isSlow := p.cfg.SlowCallDuration > 0 && time.Since(start) > p.cfg.SlowCallDuration
+	p.record(err != nil || isSlow)
+	return out, err
+}
+
+func (p *CircuitBreakerPlugin) allow() bool {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	switch p.state {
+	case BreakerOpen:
+		if time.Now().Before(p.openUntil) {
+			return false
+		}
+		p.setState(BreakerHalfOpen)
+		fallthrough
+	case BreakerHalfOpen:
+		if p.probes >= p.cfg.HalfOpenRequests {
+			return false
+		}
+		p.probes++
+		return true
+	}
+	return true
+}
+
+// 归还半开状态的探测名额
+func (p *CircuitBreakerPlugin) release() {
+	p.locker.Lock()
+	if p.state == BreakerHalfOpen && p.probes > 0 {
+		p.probes--
+	}
+	p.locker.Unlock()
+}
+
+func (p *CircuitBreakerPlugin) record(isFailure bool) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	now := time.Now()
+	switch p.state {
+	case BreakerClosed:
+		if now.Sub(p.windowStart) > p.cfg.Window {
+			p.windowStart = now
+			p.total, p.failures = 0, 0
+		}
+		p.total++
+		if isFailure {
+			p.failures++
+		}
+		if p.total >= p.cfg.MinRequests &&
+			float64(p.failures)/float64(p.total) >= p.cfg.ErrorRate {
+			p.setState(BreakerOpen)
+		}
+	case BreakerHalfOpen:
+		if isFailure {
+			p.setState(BreakerOpen)
+			return
+		}
+		p.probeSuccess++
+		if p.probeSuccess >= p.cfg.HalfOpenRequests {
+			p.setState(BreakerClosed)
+		}
+	}
+}
+
+// 调用方需持有锁
+func (p *CircuitBreakerPlugin) setState(state BreakerState) {
+	now := time.Now()
+	p.state = state
+	p.probes, p.probeSuccess = 0, 0
+	switch state {
+	case BreakerOpen:
+		p.openUntil = now.Add(p.cfg.OpenDuration)
+	case BreakerClosed:
+		p.windowStart = now
+		p.total, p.failures = 0, 0
+	}
+}
+
+// GetBreakerStates 返回 supplier 中所有开启熔断的插件状态. key: plugin_name
+func GetBreakerStates(supplier ISupplier) map[string]BreakerState {
+	states := map[string]BreakerState{}
+	for name, plugin := range supplier.GetAllPlugin() {
+		if breaker, ok := FindPlugin(plugin, func(p IPlugin) bool {
+			_, ok := p.(*CircuitBreakerPlugin)
+			return ok
+		}); ok {
+			states[name] = breaker.(*CircuitBreakerPlugin).State()
+		}
+	}
+	return states
+}
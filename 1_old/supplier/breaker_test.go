@@ -0,0 +1,76 @@
+package supplier
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerPlugin(t *testing.T) {
+	var isDown int32 = 1
+	var calls int32
+	supplier := NewDefaultSupplier("tests", []IPlugin{
+		NewDefaultPlugin("backend", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			if atomic.LoadInt32(&isDown) == 1 {
+				return nil, errors.New("backend down")
+			}
+			return map[string]interface{}{}, nil
+		}),
+	}, SetCircuitBreaker(&BreakerConfig{
+		MinRequests:      4,
+		ErrorRate:        0.5,
+		OpenDuration:     time.Millisecond * 20,
+		HalfOpenRequests: 2,
+	}))
+
+	// 连续失败, 达到阈值后打开
+	for i := 0; i < 4; i++ {
+		_, err := supplier.Supply(context.Background(), "backend", []interface{}{})
+		assert.Error(t, err)
+		assert.False(t, errors.Is(err, constant.CircuitOpenError))
+	}
+	assert.Equal(t, BreakerOpen, GetBreakerStates(supplier)["backend"])
+
+	// 打开状态快速失败, 不再调用后端
+	_, err := supplier.Supply(context.Background(), "backend", []interface{}{})
+	assert.True(t, errors.Is(err, constant.CircuitOpenError))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+
+	// 半开状态探测失败, 重新打开
+	time.Sleep(time.Millisecond * 25)
+	assert.Equal(t, BreakerHalfOpen, GetBreakerStates(supplier)["backend"])
+	_, err = supplier.Supply(context.Background(), "backend", []interface{}{})
+	assert.False(t, errors.Is(err, constant.CircuitOpenError))
+	assert.Equal(t, BreakerOpen, GetBreakerStates(supplier)["backend"])
+
+	// 后端恢复, 半开状态探测全部成功后关闭
+	atomic.StoreInt32(&isDown, 0)
+	time.Sleep(time.Millisecond * 25)
+	for i := 0; i < 2; i++ {
+		_, err := supplier.Supply(context.Background(), "backend", []interface{}{})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, BreakerClosed, GetBreakerStates(supplier)["backend"])
+}
+
+func TestGetBreakerStatesWrapped(t *testing.T) {
+	supplier := NewDefaultSupplier("tests", []IPlugin{
+		NewDefaultPlugin("plain", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{}, nil
+		}),
+		NewDefaultPlugin("wrapped", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{}, nil
+		}),
+	},
+		SetCircuitBreaker(&BreakerConfig{}, "wrapped"),
+		SetHedgePolicy(&HedgePolicy{}, "wrapped"),
+	)
+	states := GetBreakerStates(supplier)
+	assert.Equal(t, map[string]BreakerState{"wrapped": BreakerClosed}, states)
+}
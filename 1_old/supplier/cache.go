@@ -0,0 +1,191 @@
+package supplier
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/constant"
+	"git.in.zhihu.com/antispam/datasupply/deepcopy"
+)
+
+const (
+	DefaultCacheSize = 10000
+	DefaultCacheTTL  = time.Minute
+)
+
+// ICache 插件结果缓存. 实现需要并发安全, 过期的 key 需要返回 false.
+// value 为插件的返回值, 可以序列化后保存在进程外(如 redis).
+type ICache interface {
+	Get(key string) (value map[string]interface{}, ok bool)
+	Set(key string, value map[string]interface{}, ttl time.Duration)
+}
+
+// CachePolicy 插件结果缓存配置.
+type CachePolicy struct {
+	Cache       ICache        // 为空时使用内置的内存 LRU
+	Size        int           // 内置 LRU 的容量
+	TTL         time.Duration // 成功结果的缓存时间
+	NegativeTTL time.Duration // 错误结果的缓存时间, 0 表示不缓存错误. 命中时返回 constant.NegativeCacheError
+}
+
+func (policy *CachePolicy) LoadDefault() {
+	if policy.Size <= 0 {
+		policy.Size = DefaultCacheSize
+	}
+	if policy.TTL <= 0 {
+		policy.TTL = DefaultCacheTTL
+	}
+	if policy.Cache == nil {
+		policy.Cache = NewLRUCache(policy.Size)
+	}
+}
+
+// 错误结果在缓存中的标记, 值为错误信息. 缓存中只保存插件的返回值, 不保存 error.
+const negativeCacheKey = "__negative_cache_error__"
+
+// CachedPlugin 为插件添加结果缓存, 缓存 key 为插件名称+参数的规范化编码.
+type CachedPlugin struct {
+	plugin IPlugin
+	policy CachePolicy
+}
+
+var _ IWrappedPlugin = new(CachedPlugin)
+
+func NewCachedPlugin(plugin IPlugin, _policy *CachePolicy) *CachedPlugin {
+	policy := CachePolicy{}
+	if _policy != nil {
+		policy = *_policy
+	}
+	policy.LoadDefault()
+	return &CachedPlugin{
+		plugin: plugin,
+		policy: policy,
+	}
+}
+
+func (p *CachedPlugin) GetName() string {
+	return p.plugin.GetName()
+}
+
+// Unwrap 返回被包装的插件.
+func (p *CachedPlugin) Unwrap() IPlugin {
+	return p.plugin
+}
+
+func (p *CachedPlugin) Call(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+	key, err := paramsKey(p.plugin.GetName(), args)
+	if err != nil {
+		// 参数无法编码时不缓存
+		return p.plugin.Call(ctx, args...)
+	}
+
+	if value, ok := p.policy.Cache.Get(key); ok {
+		if info, ok := GetCallInfo(ctx); ok {
+			info.SetCacheHit(true)
+		}
+		if msg, ok := value[negativeCacheKey]; ok {
+			return map[string]interface{}{}, fmt.Errorf("%w: %v", constant.NegativeCacheError, msg)
+		}
+		return copyOutput(value), nil
+	}
+
+	out, err := p.plugin.Call(ctx, args...)
+	switch {
+	case err == nil:
+		p.policy.Cache.Set(key, copyOutput(out), p.policy.TTL)
+	case p.policy.NegativeTTL > 0 && negativeCacheable(err):
+		p.policy.Cache.Set(key, map[string]interface{}{negativeCacheKey: err.Error()}, p.policy.NegativeTTL)
+	}
+	return out, err
+}
+
+// 超时/取消与调用方有关, 熔断/限流/找不到函数需要保留原始错误(节点按此设置失败原因), 都不缓存.
+func negativeCacheable(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) &&
+		!errors.Is(err, constant.CircuitOpenError) && !errors.Is(err, constant.RateLimitedError) &&
+		!errors.Is(err, constant.NotFoundError)
+}
+
+// 缓存中的结果与调用方隔离, 防止调用方修改 map 影响缓存.
+func copyOutput(out map[string]interface{}) map[string]interface{} {
+	if out == nil {
+		return nil
+	}
+	return deepcopy.Copy(out).(map[string]interface{})
+}
+
+// LRUCache 内置的内存 LRU 缓存, 容量满时淘汰最久未使用的 key.
+type LRUCache struct {
+	size   int
+	locker sync.Locker
+	ll     *list.List
+	items  map[string]*list.Element
+}
+
+type lruItem struct {
+	key      string
+	value    map[string]interface{}
+	expireAt time.Time
+}
+
+var _ ICache = new(LRUCache)
+
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{
+		size:   size,
+		locker: &sync.Mutex{},
+		ll:     list.New(),
+		items:  make(map[string]*list.Element, size),
+	}
+}
+
+func (cache *LRUCache) Get(key string) (map[string]interface{}, bool) {
+	cache.locker.Lock()
+	defer cache.locker.Unlock()
+
+	elem, ok := cache.items[key]
+	if !ok {
+		return nil, false
+	}
+	item := elem.Value.(*lruItem)
+	if time.Now().After(item.expireAt) {
+		cache.removeElement(elem)
+		return nil, false
+	}
+	cache.ll.MoveToFront(elem)
+	return item.value, true
+}
+
+func (cache *LRUCache) Set(key string, value map[string]interface{}, ttl time.Duration) {
+	cache.locker.Lock()
+	defer cache.locker.Unlock()
+
+	expireAt := time.Now().Add(ttl)
+	if elem, ok := cache.items[key]; ok {
+		item := elem.Value.(*lruItem)
+		item.value = value
+		item.expireAt = expireAt
+		cache.ll.MoveToFront(elem)
+		return
+	}
+	cache.items[key] = cache.ll.PushFront(&lruItem{key: key, value: value, expireAt: expireAt})
+	for cache.ll.Len() > cache.size {
+		cache.removeElement(cache.ll.Back())
+	}
+}
+
+func (cache *LRUCache) Len() int {
+	cache.locker.Lock()
+	defer cache.locker.Unlock()
+	return cache.ll.Len()
+}
+
+// 调用方需持有锁
+func (cache *LRUCache) removeElement(elem *list.Element) {
+	cache.ll.Remove(elem)
+	delete(cache.items, elem.Value.(*lruItem).key)
+}
@@ -0,0 +1,158 @@
+package supplier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCache(t *testing.T) {
+	cache := NewLRUCache(2)
+	cache.Set("a", map[string]interface{}{"v": 1}, time.Minute)
+	cache.Set("b", map[string]interface{}{"v": 2}, time.Minute)
+	// 访问 a, 使 b 成为最久未使用的 key
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+	cache.Set("c", map[string]interface{}{"v": 3}, time.Minute)
+
+	_, ok = cache.Get("b")
+	assert.False(t, ok, "b should be evicted")
+	value, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"v": 1}, value)
+	assert.Equal(t, 2, cache.Len())
+
+	cache.Set("d", map[string]interface{}{"v": 4}, time.Millisecond)
+	time.Sleep(time.Millisecond * 5)
+	_, ok = cache.Get("d")
+	assert.False(t, ok, "d should be expired")
+}
+
+func TestCachedPlugin(t *testing.T) {
+	var calls int32
+	supplier := NewDefaultSupplier("tests", []IPlugin{
+		NewDefaultPlugin("user_level", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			if args[0] == "bad" {
+				return map[string]interface{}{}, errors.New("user not found")
+			}
+			return map[string]interface{}{"level": 3}, nil
+		}),
+	}, SetCachePolicy(&CachePolicy{TTL: time.Minute, NegativeTTL: time.Minute}))
+
+	t.Run("hit", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		for i, expectHit := range []bool{false, true} {
+			ctx, info := WithCallInfo(context.Background())
+			out, err := supplier.Supply(ctx, "user_level", []interface{}{"good"})
+			assert.NoError(t, err)
+			assert.Equal(t, 3, out["level"])
+			assert.Equal(t, expectHit, info.IsCacheHit(), "call %d", i)
+			// 修改返回值不影响缓存
+			out["level"] = 0
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		for i := 0; i < 2; i++ {
+			_, err := supplier.Supply(context.Background(), "user_level", []interface{}{"bad"})
+			assert.Error(t, err)
+			assert.Equal(t, i == 1, errors.Is(err, constant.NegativeCacheError), "call %d", i)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	// 熔断/限流/找不到函数的错误不缓存, 保留原始错误
+	t.Run("negative_skip", func(t *testing.T) {
+		for _, expect := range []error{constant.CircuitOpenError, constant.RateLimitedError, constant.NotFoundError} {
+			calls := 0
+			supplier := NewDefaultSupplier("tests", []IPlugin{
+				NewDefaultPlugin("user_level", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+					calls++
+					return nil, expect
+				}),
+			}, SetCachePolicy(&CachePolicy{TTL: time.Minute, NegativeTTL: time.Minute}))
+			for i := 0; i < 2; i++ {
+				_, err := supplier.Supply(context.Background(), "user_level", []interface{}{"bad"})
+				assert.ErrorIs(t, err, expect)
+				assert.NotErrorIs(t, err, constant.NegativeCacheError)
+			}
+			assert.Equal(t, 2, calls, expect.Error())
+		}
+	})
+
+	// 缓存中只保存可以序列化的插件返回值
+	t.Run("serializable", func(t *testing.T) {
+		cache := &jsonCache{data: map[string][]byte{}}
+		supplier := NewDefaultSupplier("tests", []IPlugin{
+			NewDefaultPlugin("user_level", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+				if args[0] == "bad" {
+					return nil, errors.New("user not found")
+				}
+				return map[string]interface{}{"level": "3"}, nil
+			}),
+		}, SetCachePolicy(&CachePolicy{Cache: cache, NegativeTTL: time.Minute}))
+		for i := 0; i < 2; i++ {
+			out, err := supplier.Supply(context.Background(), "user_level", []interface{}{"good"})
+			assert.NoError(t, err)
+			assert.Equal(t, "3", out["level"])
+			_, err = supplier.Supply(context.Background(), "user_level", []interface{}{"bad"})
+			assert.ErrorContains(t, err, "user not found")
+		}
+		assert.Len(t, cache.data, 2)
+	})
+
+	// singleflight 共享调用的调用方都可以拿到命中缓存的信息
+	t.Run("singleflight", func(t *testing.T) {
+		supplier := NewDefaultSupplier("tests", []IPlugin{
+			NewDefaultPlugin("user_level", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+				return map[string]interface{}{"level": 3}, nil
+			}),
+		}, SetCachePolicy(nil), SetSingleflight())
+		for i, expectHit := range []bool{false, true} {
+			ctx, info := WithCallInfo(context.Background())
+			_, err := supplier.Supply(ctx, "user_level", []interface{}{"good"})
+			assert.NoError(t, err)
+			assert.Equal(t, expectHit, info.IsCacheHit(), "call %d", i)
+		}
+	})
+}
+
+// 使用 json 序列化保存值的缓存, 模拟进程外缓存.
+type jsonCache struct {
+	locker sync.Mutex
+	data   map[string][]byte
+}
+
+func (cache *jsonCache) Get(key string) (map[string]interface{}, bool) {
+	cache.locker.Lock()
+	defer cache.locker.Unlock()
+	data, ok := cache.data[key]
+	if !ok {
+		return nil, false
+	}
+	var value map[string]interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+func (cache *jsonCache) Set(key string, value map[string]interface{}, ttl time.Duration) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	cache.locker.Lock()
+	cache.data[key] = data
+	cache.locker.Unlock()
+}
@@ -0,0 +1,78 @@
+package supplier
+
+import (
+	"context"
+	"sync"
+)
+
+type callInfoKey struct{}
+
+// CallInfo 记录一次 Supply 调用过程中产生的信息, 如是否命中缓存.
+// 由调用方(如 node)通过 WithCallInfo 放入 ctx, supplier 和 plugin 负责填充. 并发安全.
+type CallInfo struct {
+	locker   sync.Mutex
+	cacheHit bool
+	outputs  map[string]OutputMeta
+}
+
+// OutputMeta 插件单个返回值的元数据. 返回值来自嵌套的数据源(如 dag.Plugin 内部 DAG 的字段)时,
+// 插件通过 CallInfo.SetOutputMeta 提供, 调用方使用这些信息代替本次调用的信息.
+type OutputMeta struct {
+	FailReason string // 不为空且没有返回值时, 调用方按照该失败原因处理
+	Attempts   int
+	CacheHit   bool
+	Source     string
+}
+
+func WithCallInfo(ctx context.Context) (context.Context, *CallInfo) {
+	info := &CallInfo{}
+	return context.WithValue(ctx, callInfoKey{}, info), info
+}
+
+// GetCallInfo 获取 ctx 中的 CallInfo, 调用方未设置时返回 false.
+func GetCallInfo(ctx context.Context) (*CallInfo, bool) {
+	info, ok := ctx.Value(callInfoKey{}).(*CallInfo)
+	return info, ok
+}
+
+func (info *CallInfo) SetCacheHit(hit bool) {
+	info.locker.Lock()
+	info.cacheHit = hit
+	info.locker.Unlock()
+}
+
+func (info *CallInfo) IsCacheHit() bool {
+	info.locker.Lock()
+	defer info.locker.Unlock()
+	return info.cacheHit
+}
+
+// SetOutputMeta 设置返回值 key 的元数据.
+func (info *CallInfo) SetOutputMeta(key string, meta OutputMeta) {
+	info.locker.Lock()
+	defer info.locker.Unlock()
+	if info.outputs == nil {
+		info.outputs = map[string]OutputMeta{}
+	}
+	info.outputs[key] = meta
+}
+
+// GetOutputMetas 返回所有返回值的元数据, 返回值为副本.
+func (info *CallInfo) GetOutputMetas() map[string]OutputMeta {
+	info.locker.Lock()
+	defer info.locker.Unlock()
+	outputs := make(map[string]OutputMeta, len(info.outputs))
+	for key, meta := range info.outputs {
+		outputs[key] = meta
+	}
+	return outputs
+}
+
+// 使用 other 的信息覆盖 info, 用于共享调用(singleflight)的调用方获取调用信息.
+func (info *CallInfo) copyFrom(other *CallInfo) {
+	cacheHit, outputs := other.IsCacheHit(), other.GetOutputMetas()
+	info.locker.Lock()
+	defer info.locker.Unlock()
+	info.cacheHit = cacheHit
+	info.outputs = outputs
+}
@@ -0,0 +1,271 @@
+package grpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// 按点号分隔的字段路径查找字段, 如 user.address.city. 除最后一个字段外都必须是非 repeated 的 message.
+func resolvePath(desc protoreflect.MessageDescriptor, path string) ([]protoreflect.FieldDescriptor, error) {
+	names := strings.Split(path, ".")
+	fields := make([]protoreflect.FieldDescriptor, len(names))
+	for i, name := range names {
+		if desc == nil {
+			return nil, fmt.Errorf("field path %s: %s is not a message", path, names[i-1])
+		}
+		fd := desc.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return nil, fmt.Errorf("field path %s: %s not found in %s", path, name, desc.FullName())
+		}
+		fields[i] = fd
+		desc = nil
+		if isMessageField(fd) {
+			desc = fd.Message()
+		}
+	}
+	return fields, nil
+}
+
+// 字段对应的 dtype, 用于插件描述. 无法对应时返回零值.
+func fieldDType(fd protoreflect.FieldDescriptor) dtype.DType {
+	if fd.IsMap() {
+		return dtype.Map
+	}
+	if fd.IsList() {
+		switch fd.Kind() {
+		case protoreflect.StringKind:
+			return dtype.ArrayString
+		case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+			protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+			protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.EnumKind:
+			return dtype.ArrayInt64
+		}
+		return 0
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return dtype.Bool
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.EnumKind:
+		return dtype.Int64
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return dtype.Uint64
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return dtype.Float64
+	case protoreflect.StringKind:
+		return dtype.String
+	case protoreflect.BytesKind:
+		return dtype.ArrayByte
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return dtype.Map
+	}
+	return 0
+}
+
+// 将参数值写入 msg 中 path 对应的字段, 中间的 message 字段会被自动创建.
+func setPath(msg protoreflect.Message, path []protoreflect.FieldDescriptor, value interface{}) error {
+	for _, fd := range path[:len(path)-1] {
+		msg = msg.Mutable(fd).Message()
+	}
+	fd := path[len(path)-1]
+	switch {
+	case fd.IsMap():
+		return fmt.Errorf("field %s: map field is not supported as param", fd.Name())
+	case fd.IsList():
+		items, err := toList(value)
+		if err != nil {
+			return fmt.Errorf("field %s: %s", fd.Name(), err.Error())
+		}
+		list := msg.Mutable(fd).List()
+		for _, item := range items {
+			v, err := toProtoValue(fd, item, list.NewElement)
+			if err != nil {
+				return err
+			}
+			list.Append(v)
+		}
+		return nil
+	}
+	v, err := toProtoValue(fd, value, func() protoreflect.Value { return msg.NewField(fd) })
+	if err != nil {
+		return err
+	}
+	msg.Set(fd, v)
+	return nil
+}
+
+func toList(value interface{}) ([]interface{}, error) {
+	if value == nil {
+		return []interface{}{}, nil
+	}
+	if s, ok := value.(string); ok {
+		return dtype.ToArray(s)
+	}
+	rvalue := reflect.ValueOf(value)
+	if rvalue.Kind() != reflect.Slice && rvalue.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expect array, got %T", value)
+	}
+	items := make([]interface{}, rvalue.Len())
+	for i := range items {
+		items[i] = rvalue.Index(i).Interface()
+	}
+	return items, nil
+}
+
+// 将单个值转换为字段类型的 proto 值. message 类型的值通过 JSON 转换.
+func toProtoValue(fd protoreflect.FieldDescriptor, value interface{},
+	newMessage func() protoreflect.Value) (protoreflect.Value, error) {
+	var err error
+	var v protoreflect.Value
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		var b bool
+		b, err = dtype.ToBool(value)
+		v = protoreflect.ValueOfBool(b)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		var i int64
+		i, err = dtype.ToInt64(value)
+		v = protoreflect.ValueOfInt32(int32(i))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		var i int64
+		i, err = dtype.ToInt64(value)
+		v = protoreflect.ValueOfInt64(i)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		var i int64
+		i, err = dtype.ToInt64(value)
+		v = protoreflect.ValueOfUint32(uint32(i))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		var i int64
+		i, err = dtype.ToInt64(value)
+		v = protoreflect.ValueOfUint64(uint64(i))
+	case protoreflect.FloatKind:
+		var f float64
+		f, err = dtype.ToFloat64(value)
+		v = protoreflect.ValueOfFloat32(float32(f))
+	case protoreflect.DoubleKind:
+		var f float64
+		f, err = dtype.ToFloat64(value)
+		v = protoreflect.ValueOfFloat64(f)
+	case protoreflect.StringKind:
+		v = protoreflect.ValueOfString(dtype.ToString(value))
+	case protoreflect.BytesKind:
+		v = protoreflect.ValueOfBytes(dtype.ToBytes(value))
+	case protoreflect.EnumKind:
+		// 支持枚举名称和枚举值
+		if name, ok := value.(string); ok {
+			if ev := fd.Enum().Values().ByName(protoreflect.Name(name)); ev != nil {
+				return protoreflect.ValueOfEnum(ev.Number()), nil
+			}
+		}
+		var i int64
+		i, err = dtype.ToInt64(value)
+		v = protoreflect.ValueOfEnum(protoreflect.EnumNumber(i))
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		var b []byte
+		if b, err = json.Marshal(value); err == nil {
+			v = newMessage()
+			err = protojson.Unmarshal(b, v.Message().Interface())
+		}
+	default:
+		err = fmt.Errorf("kind %s is not supported", fd.Kind())
+	}
+	if err != nil {
+		return protoreflect.Value{}, fmt.Errorf("field %s: %s", fd.Name(), err.Error())
+	}
+	return v, nil
+}
+
+// 读取 msg 中 path 对应的字段, 路径中的 message 字段未设置时返回 false.
+func getPath(msg protoreflect.Message, path []protoreflect.FieldDescriptor) (interface{}, bool) {
+	for _, fd := range path[:len(path)-1] {
+		if !msg.Has(fd) {
+			return nil, false
+		}
+		msg = msg.Get(fd).Message()
+	}
+	fd := path[len(path)-1]
+	if isMessageField(fd) && !msg.Has(fd) {
+		return nil, false
+	}
+	return fromProtoField(fd, msg.Get(fd)), true
+}
+
+// 非 repeated 的 message 字段
+func isMessageField(fd protoreflect.FieldDescriptor) bool {
+	return fd.Kind() == protoreflect.MessageKind && !fd.IsList() && !fd.IsMap()
+}
+
+// 将 proto 字段值转换为 fieldDType 对应的 go 类型.
+func fromProtoField(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch {
+	case fd.IsMap():
+		m := make(map[string]interface{}, v.Map().Len())
+		v.Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+			m[key.String()] = fromProtoValue(fd.MapValue(), value)
+			return true
+		})
+		return m
+	case fd.IsList():
+		list := v.List()
+		switch fieldDType(fd) {
+		case dtype.ArrayString:
+			result := make([]string, list.Len())
+			for i := range result {
+				result[i] = list.Get(i).String()
+			}
+			return result
+		case dtype.ArrayInt64:
+			result := make([]int64, list.Len())
+			for i := range result {
+				result[i] = fromProtoValue(fd, list.Get(i)).(int64)
+			}
+			return result
+		}
+		result := make([]interface{}, list.Len())
+		for i := range result {
+			result[i] = fromProtoValue(fd, list.Get(i))
+		}
+		return result
+	}
+	return fromProtoValue(fd, v)
+}
+
+func fromProtoValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return v.Int()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return int64(v.Uint())
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return v.Uint()
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float()
+	case protoreflect.EnumKind:
+		return int64(v.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageToMap(v.Message())
+	}
+	return v.Interface()
+}
+
+// message 转换为 map, key 为字段名. 未设置的 message 字段不输出.
+func messageToMap(msg protoreflect.Message) map[string]interface{} {
+	fields := msg.Descriptor().Fields()
+	m := make(map[string]interface{}, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if isMessageField(fd) && !msg.Has(fd) {
+			continue
+		}
+		m[string(fd.Name())] = fromProtoField(fd, msg.Get(fd))
+	}
+	return m
+}
@@ -0,0 +1,194 @@
+// package grpc 是 gRPC 供应商. 通过启动时加载的 proto 描述信息动态调用 unary 方法, 不需要为每个服务生成代码.
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// 查找 proto 描述信息, protoregistry.GlobalFiles 和 LoadDescriptorSet 的返回值都满足该接口.
+type DescriptorResolver interface {
+	FindDescriptorByName(protoreflect.FullName) (protoreflect.Descriptor, error)
+}
+
+// LoadDescriptorSet 加载 `protoc --include_imports --descriptor_set_out` 生成的描述文件.
+func LoadDescriptorSet(b []byte) (*protoregistry.Files, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, set); err != nil {
+		return nil, err
+	}
+	return protodesc.NewFiles(set)
+}
+
+// PluginConfig gRPC 插件配置. 超时时间由调用方(node)的 context 决定.
+type PluginConfig struct {
+	Name   string `json:"name"`
+	Target string `json:"target"` // 服务地址, 同一地址的插件共享连接
+	Method string `json:"method"` // 完整方法名, 如 package.Service/Method
+
+	// request 字段路径, 与调用时的参数按顺序对应. 嵌套字段使用点号分隔, 如 user.id
+	Params []string `json:"params"`
+	// 返回值. key: 输出的 key, value: response 字段路径. 为空时输出 response 的所有顶层字段.
+	Outputs map[string]string `json:"outputs"`
+}
+
+func (cfg *PluginConfig) Validate() error {
+	if cfg.Name == "" {
+		return errors.New("grpc plugin must have name")
+	}
+	if cfg.Target == "" {
+		return fmt.Errorf("grpc plugin %s must have target", cfg.Name)
+	}
+	if strings.Count(strings.TrimPrefix(cfg.Method, "/"), "/") != 1 {
+		return fmt.Errorf("grpc plugin %s method must be package.Service/Method, got %s", cfg.Name, cfg.Method)
+	}
+	return nil
+}
+
+type output struct {
+	key  string
+	path []protoreflect.FieldDescriptor
+}
+
+// Plugin 通过 dynamicpb 调用 gRPC unary 方法的插件.
+type Plugin struct {
+	cfg        PluginConfig
+	conn       gogrpc.ClientConnInterface
+	fullMethod string // /package.Service/Method
+	input      protoreflect.MessageDescriptor
+	output     protoreflect.MessageDescriptor
+	params     [][]protoreflect.FieldDescriptor
+	outputs    []output
+}
+
+var _ supplier.IDescribedPlugin = new(Plugin)
+
+// NewPlugin 创建 gRPC 插件. 方法或字段在描述信息中不存在时返回错误.
+func NewPlugin(_cfg *PluginConfig, resolver DescriptorResolver, pool *ConnPool) (*Plugin, error) {
+	cfg := *_cfg
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	method, err := findMethod(resolver, cfg.Method)
+	if err != nil {
+		return nil, fmt.Errorf("grpc plugin %s: %s", cfg.Name, err.Error())
+	}
+	conn, err := pool.Get(cfg.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	plugin := &Plugin{
+		cfg:        cfg,
+		conn:       conn,
+		fullMethod: fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name()),
+		input:      method.Input(),
+		output:     method.Output(),
+		params:     make([][]protoreflect.FieldDescriptor, len(cfg.Params)),
+	}
+	for i, param := range cfg.Params {
+		if plugin.params[i], err = resolvePath(method.Input(), param); err != nil {
+			return nil, fmt.Errorf("grpc plugin %s param: %s", cfg.Name, err.Error())
+		}
+	}
+	if len(cfg.Outputs) == 0 {
+		fields := method.Output().Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			plugin.outputs = append(plugin.outputs, output{key: string(fd.Name()), path: []protoreflect.FieldDescriptor{fd}})
+		}
+	}
+	for key, path := range cfg.Outputs {
+		fds, err := resolvePath(method.Output(), path)
+		if err != nil {
+			return nil, fmt.Errorf("grpc plugin %s output: %s", cfg.Name, err.Error())
+		}
+		plugin.outputs = append(plugin.outputs, output{key: key, path: fds})
+	}
+	return plugin, nil
+}
+
+func findMethod(resolver DescriptorResolver, method string) (protoreflect.MethodDescriptor, error) {
+	i := strings.LastIndex(method, "/")
+	serviceName := strings.TrimPrefix(method[:i], "/")
+	desc, err := resolver.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found: %s", serviceName, err.Error())
+	}
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", serviceName)
+	}
+	md := service.Methods().ByName(protoreflect.Name(method[i+1:]))
+	if md == nil {
+		return nil, fmt.Errorf("method %s not found", method)
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("method %s is streaming, only unary method is supported", method)
+	}
+	return md, nil
+}
+
+func (p *Plugin) GetName() string {
+	return p.cfg.Name
+}
+
+func (p *Plugin) Describe() *supplier.PluginSchema {
+	schema := &supplier.PluginSchema{
+		Name:    p.cfg.Name,
+		Params:  make([]supplier.ParamSchema, len(p.params)),
+		Outputs: make([]supplier.OutputSchema, len(p.outputs)),
+	}
+	for i, path := range p.params {
+		schema.Params[i] = supplier.ParamSchema{
+			Name:     p.cfg.Params[i],
+			Type:     fieldDType(path[len(path)-1]),
+			Required: true,
+		}
+	}
+	for i, output := range p.outputs {
+		schema.Outputs[i] = supplier.OutputSchema{
+			Name: output.key,
+			Type: fieldDType(output.path[len(output.path)-1]),
+		}
+	}
+	return schema
+}
+
+func (p *Plugin) Call(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+	if len(args) != len(p.params) {
+		return map[string]interface{}{}, fmt.Errorf("grpc plugin %s expects %d params, got %d",
+			p.cfg.Name, len(p.params), len(args))
+	}
+	req := dynamicpb.NewMessage(p.input)
+	for i, arg := range args {
+		if err := setPath(req, p.params[i], arg); err != nil {
+			return map[string]interface{}{}, fmt.Errorf("grpc plugin %s param %s: %s",
+				p.cfg.Name, p.cfg.Params[i], err.Error())
+		}
+	}
+
+	resp := dynamicpb.NewMessage(p.output)
+	if err := p.conn.Invoke(ctx, p.fullMethod, req, resp); err != nil {
+		return map[string]interface{}{}, err
+	}
+
+	out := make(map[string]interface{}, len(p.outputs))
+	for _, output := range p.outputs {
+		if value, ok := getPath(resp, output.path); ok {
+			out[output.key] = value
+		}
+	}
+	return out, nil
+}
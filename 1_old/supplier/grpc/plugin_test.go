@@ -0,0 +1,252 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+	"github.com/stretchr/testify/assert"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+/*
+package test;
+enum Status { UNKNOWN = 0; ACTIVE = 1; }
+message Address { string city = 1; }
+message GetUserRequest { int64 id = 1; repeated string fields = 2; Address address = 3; Status status = 4; }
+message User { int64 id = 1; string name = 2; repeated string tags = 3; Status status = 4; Address address = 5; int32 age = 6; }
+service UserService { rpc GetUser(GetUserRequest) returns (User); }
+*/
+func newTestFiles(t *testing.T) *protoregistry.Files {
+	field := func(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type, typeName string,
+		repeated bool) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Type:   kind.Enum(),
+			Label:  label.Enum(),
+		}
+		if typeName != "" {
+			fd.TypeName = proto.String(typeName)
+		}
+		return fd
+	}
+	const (
+		int64Kind   = descriptorpb.FieldDescriptorProto_TYPE_INT64
+		int32Kind   = descriptorpb.FieldDescriptorProto_TYPE_INT32
+		stringKind  = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		enumKind    = descriptorpb.FieldDescriptorProto_TYPE_ENUM
+		messageKind = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/user.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("UNKNOWN"), Number: proto.Int32(0)},
+				{Name: proto.String("ACTIVE"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Address"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("city", 1, stringKind, "", false),
+			}},
+			{Name: proto.String("GetUserRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, int64Kind, "", false),
+				field("fields", 2, stringKind, "", true),
+				field("address", 3, messageKind, ".test.Address", false),
+				field("status", 4, enumKind, ".test.Status", false),
+			}},
+			{Name: proto.String("User"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, int64Kind, "", false),
+				field("name", 2, stringKind, "", false),
+				field("tags", 3, stringKind, "", true),
+				field("status", 4, enumKind, ".test.Status", false),
+				field("address", 5, messageKind, ".test.Address", false),
+				field("age", 6, int32Kind, "", false),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("UserService"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("GetUser"),
+				InputType:  proto.String(".test.GetUserRequest"),
+				OutputType: proto.String(".test.User"),
+			}},
+		}},
+	}
+	b, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}})
+	assert.NoError(t, err)
+	files, err := LoadDescriptorSet(b)
+	assert.NoError(t, err)
+	return files
+}
+
+// 回显请求的服务. id=0 时返回错误, address 为空时不返回 address.
+func newTestServer(t *testing.T, files *protoregistry.Files) *bufconn.Listener {
+	desc, err := files.FindDescriptorByName("test.UserService")
+	assert.NoError(t, err)
+	method := desc.(protoreflect.ServiceDescriptor).Methods().ByName("GetUser")
+	handler := func(srv interface{}, ctx context.Context, dec func(interface{}) error,
+		_ gogrpc.UnaryServerInterceptor) (interface{}, error) {
+		req := dynamicpb.NewMessage(method.Input())
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		get := func(msg protoreflect.Message, name string) protoreflect.Value {
+			return msg.Get(msg.Descriptor().Fields().ByName(protoreflect.Name(name)))
+		}
+		if get(req, "id").Int() == 0 {
+			return nil, errors.New("user not found")
+		}
+		resp := dynamicpb.NewMessage(method.Output())
+		set := func(name string, v protoreflect.Value) {
+			resp.Set(resp.Descriptor().Fields().ByName(protoreflect.Name(name)), v)
+		}
+		set("id", get(req, "id"))
+		set("name", protoreflect.ValueOfString("alice"))
+		tags := resp.Mutable(resp.Descriptor().Fields().ByName("tags")).List()
+		for fields, i := get(req, "fields").List(), 0; i < fields.Len(); i++ {
+			tags.Append(fields.Get(i))
+		}
+		set("status", get(req, "status"))
+		set("age", protoreflect.ValueOfInt32(18))
+		if city := get(get(req, "address").Message(), "city"); city.String() != "" {
+			address := resp.Mutable(resp.Descriptor().Fields().ByName("address")).Message()
+			address.Set(address.Descriptor().Fields().ByName("city"), city)
+		}
+		return resp, nil
+	}
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := gogrpc.NewServer()
+	server.RegisterService(&gogrpc.ServiceDesc{
+		ServiceName: "test.UserService",
+		HandlerType: (*interface{})(nil),
+		Methods:     []gogrpc.MethodDesc{{MethodName: "GetUser", Handler: handler}},
+	}, struct{}{})
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+	return listener
+}
+
+func TestPlugin(t *testing.T) {
+	files := newTestFiles(t)
+	listener := newTestServer(t, files)
+	pool := NewConnPool(
+		gogrpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		gogrpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	defer pool.Close()
+
+	grpcSupplier, err := NewSupplier([]*PluginConfig{
+		{
+			Name:    "GetUser",
+			Target:  "bufnet",
+			Method:  "test.UserService/GetUser",
+			Params:  []string{"id", "fields", "address.city", "status"},
+			Outputs: map[string]string{"user_id": "id", "tags": "tags", "city": "address.city", "status": "status"},
+		},
+		{
+			Name:   "GetUserAll",
+			Target: "bufnet",
+			Method: "/test.UserService/GetUser",
+			Params: []string{"id"},
+		},
+	}, files, pool)
+	assert.NoError(t, err)
+
+	t.Run("call", func(t *testing.T) {
+		out, err := grpcSupplier.Supply(context.Background(), "GetUser",
+			[]interface{}{"42", []interface{}{"a", "b"}, "beijing", "ACTIVE"})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"user_id": int64(42),
+			"tags":    []string{"a", "b"},
+			"city":    "beijing",
+			"status":  int64(1),
+		}, out)
+	})
+
+	t.Run("all_outputs", func(t *testing.T) {
+		out, err := grpcSupplier.Supply(context.Background(), "GetUserAll", []interface{}{int64(7)})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), out["id"])
+		assert.Equal(t, "alice", out["name"])
+		assert.Equal(t, int64(18), out["age"])
+		// 未设置的 message 字段不输出
+		_, ok := out["address"]
+		assert.False(t, ok)
+	})
+
+	t.Run("server_error", func(t *testing.T) {
+		_, err := grpcSupplier.Supply(context.Background(), "GetUserAll", []interface{}{0})
+		assert.Error(t, err)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := grpcSupplier.Supply(ctx, "GetUserAll", []interface{}{1})
+		assert.Error(t, err)
+	})
+
+	t.Run("describe", func(t *testing.T) {
+		schema, ok := supplier.DescribePlugin(mustGetPlugin(t, grpcSupplier, "GetUser"))
+		assert.True(t, ok)
+		assert.Equal(t, supplier.ParamSchema{Name: "fields", Type: dtype.ArrayString, Required: true}, schema.Params[1])
+		output, ok := schema.GetOutput("user_id")
+		assert.True(t, ok)
+		assert.Equal(t, dtype.Int64, output.Type)
+	})
+}
+
+func mustGetPlugin(t *testing.T, s supplier.ISupplier, name string) supplier.IPlugin {
+	plugin, ok := s.GetPlugin(name)
+	assert.True(t, ok)
+	return plugin
+}
+
+func TestNewPlugin(t *testing.T) {
+	files := newTestFiles(t)
+	pool := NewConnPool(gogrpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer pool.Close()
+
+	cases := []struct {
+		name  string
+		cfg   *PluginConfig
+		isErr bool
+	}{
+		{"ok", &PluginConfig{Name: "ok", Target: "localhost:1", Method: "test.UserService/GetUser"}, false},
+		{"no_target", &PluginConfig{Name: "no_target", Method: "test.UserService/GetUser"}, true},
+		{"bad_method", &PluginConfig{Name: "bad", Target: "localhost:1", Method: "GetUser"}, true},
+		{"service_not_found", &PluginConfig{Name: "svc", Target: "localhost:1", Method: "test.Missing/GetUser"}, true},
+		{"method_not_found", &PluginConfig{Name: "m", Target: "localhost:1", Method: "test.UserService/Missing"}, true},
+		{"param_not_found", &PluginConfig{Name: "p", Target: "localhost:1", Method: "test.UserService/GetUser",
+			Params: []string{"address.country"}}, true},
+		{"output_not_found", &PluginConfig{Name: "o", Target: "localhost:1", Method: "test.UserService/GetUser",
+			Outputs: map[string]string{"x": "name.first"}}, true},
+	}
+	for _, c := range cases {
+		_, err := NewPlugin(c.cfg, files, pool)
+		assert.Equal(t, c.isErr, err != nil, c.name)
+	}
+}
@@ -0,0 +1,53 @@
+package grpc
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	gogrpc "google.golang.org/grpc"
+)
+
+// ConnPool 按 target 复用 grpc 连接, 同一个 target 的插件共享一个 ClientConn.
+// ClientConn 内部会维护连接和负载均衡, 所以每个 target 只需要一个连接.
+type ConnPool struct {
+	options []gogrpc.DialOption
+	locker  sync.Locker
+	conns   map[string]*gogrpc.ClientConn
+}
+
+func NewConnPool(options ...gogrpc.DialOption) *ConnPool {
+	return &ConnPool{
+		options: options,
+		locker:  &sync.Mutex{},
+		conns:   map[string]*gogrpc.ClientConn{},
+	}
+}
+
+// Get 返回 target 对应的连接, 不存在时创建. 创建连接是非阻塞的, 不会等待连接建立.
+func (pool *ConnPool) Get(target string) (*gogrpc.ClientConn, error) {
+	pool.locker.Lock()
+	defer pool.locker.Unlock()
+	if conn, ok := pool.conns[target]; ok {
+		return conn, nil
+	}
+	conn, err := gogrpc.Dial(target, pool.options...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "grpc dial %s error", target)
+	}
+	pool.conns[target] = conn
+	return conn, nil
+}
+
+// Close 关闭所有连接.
+func (pool *ConnPool) Close() error {
+	pool.locker.Lock()
+	defer pool.locker.Unlock()
+	var lastErr error
+	for target, conn := range pool.conns {
+		if err := conn.Close(); err != nil {
+			lastErr = err
+		}
+		delete(pool.conns, target)
+	}
+	return lastErr
+}
@@ -0,0 +1,21 @@
+package grpc
+
+import (
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+)
+
+const SupplierName = "grpc"
+
+// NewSupplier 根据插件配置创建 gRPC 供应商. 连接由 pool 管理, 关闭 supplier 时需要调用 pool.Close.
+func NewSupplier(cfgs []*PluginConfig, resolver DescriptorResolver, pool *ConnPool,
+	options ...supplier.Option) (*supplier.DefaultSupplier, error) {
+	plugins := make([]supplier.IPlugin, len(cfgs))
+	for i, cfg := range cfgs {
+		plugin, err := NewPlugin(cfg, resolver, pool)
+		if err != nil {
+			return nil, err
+		}
+		plugins[i] = plugin
+	}
+	return supplier.NewDefaultSupplier(SupplierName, plugins, options...), nil
+}
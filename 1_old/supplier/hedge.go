@@ -0,0 +1,243 @@
+package supplier
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/constant"
+	"git.in.zhihu.com/antispam/datasupply/statsd"
+	"git.in.zhihu.com/antispam/datasupply/utils"
+)
+
+const (
+	DefaultHedgePercentile  = 0.95
+	DefaultHedgeMinDelay    = time.Millisecond * 10
+	DefaultHedgeMaxDelay    = time.Second
+	DefaultHedgeBudgetRatio = 0.05
+
+	hedgeLatencyWindowSize = 256 // 计算分位数的样本数量
+	hedgeRecomputeInterval = 32  // 每记录多少个样本重新计算一次分位数
+	hedgeBudgetBurst       = 10  // 预算桶的容量, 允许短时间内的突发对冲
+)
+
+// HedgePolicy 对冲请求配置. 当插件调用在 Percentile 分位延迟后仍未返回时, 发起一次相同的调用,
+// 取先返回的结果, 并通过 context 取消另一个调用.
+type HedgePolicy struct {
+	Percentile  float64        // 触发对冲的延迟分位数, 范围 (0,1)
+	MinDelay    time.Duration  // 对冲延迟下限, 样本不足时使用该值
+	MaxDelay    time.Duration  // 对冲延迟上限
+	BudgetRatio float64        // 对冲请求占总请求的比例上限, 用于限制额外负载
+	Statsd      statsd.IStatsd // 上报对冲次数
+}
+
+func (policy *HedgePolicy) LoadDefault() {
+	if policy.Percentile <= 0 || policy.Percentile >= 1 {
+		policy.Percentile = DefaultHedgePercentile
+	}
+	if policy.MinDelay == 0 {
+		policy.MinDelay = DefaultHedgeMinDelay
+	}
+	if policy.MaxDelay < policy.MinDelay {
+		policy.MaxDelay = DefaultHedgeMaxDelay
+		if policy.MaxDelay < policy.MinDelay {
+			policy.MaxDelay = policy.MinDelay
+		}
+	}
+	if policy.BudgetRatio <= 0 {
+		policy.BudgetRatio = DefaultHedgeBudgetRatio
+	}
+	if policy.Statsd == nil {
+		policy.Statsd = &statsd.EmptyStatsd{}
+	}
+}
+
+// HedgedPlugin 为插件添加对冲请求能力.
+type HedgedPlugin struct {
+	plugin  IPlugin
+	policy  HedgePolicy
+	latency *latencyWindow
+	budget  *hedgeBudget
+
+	hedgeCount           string
+	hedgeWin             string
+	hedgeBudgetExhausted string
+}
+
+var _ IWrappedPlugin = new(HedgedPlugin)
+
+func NewHedgedPlugin(plugin IPlugin, _policy *HedgePolicy) *HedgedPlugin {
+	policy := HedgePolicy{}
+	if _policy != nil {
+		policy = *_policy
+	}
+	policy.LoadDefault()
+	return &HedgedPlugin{
+		plugin:  plugin,
+		policy:  policy,
+		latency: newLatencyWindow(policy.Percentile, policy.MinDelay, policy.MaxDelay),
+		budget:  newHedgeBudget(policy.BudgetRatio),
+
+		hedgeCount:           fmt.Sprintf(constant.PluginHedgeCount, plugin.GetName()),
+		hedgeWin:             fmt.Sprintf(constant.PluginHedgeWin, plugin.GetName()),
+		hedgeBudgetExhausted: fmt.Sprintf(constant.PluginHedgeBudgetExhausted, plugin.GetName()),
+	}
+}
+
+func (p *HedgedPlugin) GetName() string {
+	return p.plugin.GetName()
+}
+
+// Unwrap 返回被包装的插件.
+func (p *HedgedPlugin) Unwrap() IPlugin {
+	return p.plugin
+}
+
+type hedgeResult struct {
+	out    map[string]interface{}
+	err    error
+	hedged bool
+}
+
+func (p *HedgedPlugin) Call(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+	// 返回时取消仍在运行的调用
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	p.budget.Deposit()
+	// 容量为 2, 保证落后的调用返回时不会阻塞
+	results := make(chan hedgeResult, 2)
+	call := func(hedged bool) {
+		utils.SafelyGo(
+			func() {
+				start := time.Now()
+				out, err := p.plugin.Call(ctx, args...)
+				if err == nil {
+					p.latency.Record(time.Since(start))
+				}
+				results <- hedgeResult{out: out, err: err, hedged: hedged}
+			},
+			func(err error) {
+				results <- hedgeResult{err: err, hedged: hedged}
+			})
+	}
+
+	call(false)
+	inflight := 1
+	timer := time.NewTimer(p.latency.Delay())
+	defer timer.Stop()
+	for {
+		select {
+		case r := <-results:
+			inflight--
+			// 有调用失败时, 如果还有未返回的调用, 继续等待
+			if r.err == nil || inflight == 0 {
+				if r.hedged && r.err == nil {
+					p.policy.Statsd.Increment(p.hedgeWin)
+				}
+				return r.out, r.err
+			}
+		case <-timer.C:
+			if !p.budget.Withdraw() {
+				p.policy.Statsd.Increment(p.hedgeBudgetExhausted)
+				continue
+			}
+			p.policy.Statsd.Increment(p.hedgeCount)
+			call(true)
+			inflight++
+		case <-ctx.Done():
+			return map[string]interface{}{}, ctx.Err()
+		}
+	}
+}
+
+// 滑动窗口记录最近的调用延迟, 用于计算对冲延迟.
+type latencyWindow struct {
+	percentile float64
+	minDelay   time.Duration
+	maxDelay   time.Duration
+
+	locker  sync.Locker
+	samples []time.Duration
+	next    int
+	count   int
+	delay   int64 // time.Duration, 原子读写
+}
+
+func newLatencyWindow(percentile float64, minDelay, maxDelay time.Duration) *latencyWindow {
+	return &latencyWindow{
+		percentile: percentile,
+		minDelay:   minDelay,
+		maxDelay:   maxDelay,
+		locker:     &sync.Mutex{},
+		samples:    make([]time.Duration, hedgeLatencyWindowSize),
+		delay:      int64(minDelay),
+	}
+}
+
+func (window *latencyWindow) Record(latency time.Duration) {
+	window.locker.Lock()
+	defer window.locker.Unlock()
+
+	window.samples[window.next] = latency
+	window.next = (window.next + 1) % len(window.samples)
+	window.count++
+	if window.count%hedgeRecomputeInterval != 0 {
+		return
+	}
+
+	size := window.count
+	if size > len(window.samples) {
+		size = len(window.samples)
+	}
+	sorted := make([]time.Duration, size)
+	copy(sorted, window.samples[:size])
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	delay := sorted[int(float64(size-1)*window.percentile)]
+	if delay < window.minDelay {
+		delay = window.minDelay
+	} else if delay > window.maxDelay {
+		delay = window.maxDelay
+	}
+	atomic.StoreInt64(&window.delay, int64(delay))
+}
+
+func (window *latencyWindow) Delay() time.Duration {
+	return time.Duration(atomic.LoadInt64(&window.delay))
+}
+
+// 对冲预算. 每次调用存入 ratio 个令牌, 每次对冲消耗一个令牌, 从而限制对冲请求的比例.
+type hedgeBudget struct {
+	ratio  float64
+	locker sync.Locker
+	tokens float64
+}
+
+func newHedgeBudget(ratio float64) *hedgeBudget {
+	return &hedgeBudget{
+		ratio:  ratio,
+		locker: &sync.Mutex{},
+	}
+}
+
+func (budget *hedgeBudget) Deposit() {
+	budget.locker.Lock()
+	budget.tokens += budget.ratio
+	if budget.tokens > hedgeBudgetBurst {
+		budget.tokens = hedgeBudgetBurst
+	}
+	budget.locker.Unlock()
+}
+
+func (budget *hedgeBudget) Withdraw() bool {
+	budget.locker.Lock()
+	defer budget.locker.Unlock()
+	if budget.tokens < 1 {
+		return false
+	}
+	budget.tokens--
+	return true
+}
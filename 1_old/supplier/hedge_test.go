@@ -0,0 +1,94 @@
+package supplier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/constant"
+	"git.in.zhihu.com/antispam/datasupply/statsd"
+	"github.com/stretchr/testify/assert"
+)
+
+type countStatsd struct {
+	statsd.EmptyStatsd
+	locker sync.Mutex
+	counts map[string]int64
+}
+
+func newCountStatsd() *countStatsd {
+	return &countStatsd{counts: map[string]int64{}}
+}
+
+func (s *countStatsd) Increment(metrics string) {
+	s.locker.Lock()
+	s.counts[metrics]++
+	s.locker.Unlock()
+}
+
+func (s *countStatsd) Get(metrics string) int64 {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	return s.counts[metrics]
+}
+
+func TestHedgedPlugin(t *testing.T) {
+	// 第一次调用一直阻塞直到被取消, 之后的调用立即返回
+	newSlowPlugin := func(canceled *int32) IPlugin {
+		var calls int32
+		return NewDefaultPlugin("slow", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				<-ctx.Done()
+				atomic.StoreInt32(canceled, 1)
+				return nil, ctx.Err()
+			}
+			return map[string]interface{}{"out": args[0]}, nil
+		})
+	}
+
+	t.Run("hedge_win", func(t *testing.T) {
+		var canceled int32
+		counter := newCountStatsd()
+		supplier := NewDefaultSupplier("tests", []IPlugin{newSlowPlugin(&canceled)},
+			SetHedgePolicy(&HedgePolicy{MinDelay: time.Millisecond, BudgetRatio: 1, Statsd: counter}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		out, err := supplier.Supply(ctx, "slow", []interface{}{"x"})
+		assert.NoError(t, err)
+		assert.Equal(t, "x", out["out"])
+		assert.Equal(t, int64(1), counter.Get(fmt.Sprintf(constant.PluginHedgeCount, "slow")))
+		assert.Equal(t, int64(1), counter.Get(fmt.Sprintf(constant.PluginHedgeWin, "slow")))
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&canceled) == 1 },
+			time.Second, time.Millisecond)
+	})
+
+	t.Run("budget_exhausted", func(t *testing.T) {
+		var canceled int32
+		counter := newCountStatsd()
+		supplier := NewDefaultSupplier("tests", []IPlugin{newSlowPlugin(&canceled)},
+			SetHedgePolicy(&HedgePolicy{MinDelay: time.Millisecond, BudgetRatio: 0.01, Statsd: counter}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+		defer cancel()
+		_, err := supplier.Supply(ctx, "slow", []interface{}{"x"})
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Equal(t, int64(0), counter.Get(fmt.Sprintf(constant.PluginHedgeCount, "slow")))
+		assert.Equal(t, int64(1), counter.Get(fmt.Sprintf(constant.PluginHedgeBudgetExhausted, "slow")))
+	})
+
+	t.Run("not_hedged_plugin", func(t *testing.T) {
+		supplier := NewDefaultSupplier("tests", []IPlugin{
+			NewDefaultPlugin("fast", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+				return map[string]interface{}{}, nil
+			}),
+		}, SetHedgePolicy(&HedgePolicy{}, "slow"))
+		plugin, _ := supplier.GetPlugin("fast")
+		_, ok := plugin.(*HedgedPlugin)
+		assert.False(t, ok)
+	})
+}
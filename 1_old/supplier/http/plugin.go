@@ -0,0 +1,327 @@
+// package http 是 HTTP/JSON 供应商, 通过配置声明式地调用 REST 接口, 不需要为每个接口编写 PluginFunc.
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	DefaultTimeout     = time.Second
+	DefaultMaxBodySize = 4 << 20 // 响应体的默认大小上限, 4MB
+
+	maxErrorBodyLen = 256 // 错误信息中保留的响应体长度
+)
+
+// PluginConfig HTTP 插件配置.
+//
+// URL/Header/Body 均为 text/template 模板, 模板数据为 参数名->参数值, 如 `/users/{{.user_id}}`.
+// 除内置函数外, 模板中可以使用 json 函数将参数编码为 JSON, 如 `{"tags": {{json .tags}}}`.
+//
+// URL 中的参数值默认按所在位置转义: ? 之前使用 pathescape(url.PathEscape), 之后使用 queryescape(url.QueryEscape).
+// 已经使用 pathescape, queryescape, urlquery 或 raw 的参数不再转义, raw 表示不转义, 如 `{{raw .base_url}}/users`.
+type PluginConfig struct {
+	Name    string            `json:"name"`
+	Method  string            `json:"method"`  // 默认 GET
+	URL     string            `json:"url"`     // URL 模板
+	Header  map[string]string `json:"header"`  // header 模板
+	Body    string            `json:"body"`    // body 模板, 为空时不发送 body
+	Timeout time.Duration     `json:"timeout"` // 单次请求的超时时间
+	// 响应体的大小上限, 单位字节, 超过时返回错误. 为 0 时使用 DefaultMaxBodySize.
+	MaxBodySize int64    `json:"max_body_size"`
+	Params      []string `json:"params"` // 参数名称, 与调用时的参数按顺序对应
+
+	// 返回值. key: 输出的 key, value: gjson path, 与 local.Extract 相同返回 gjson.Result.
+	Outputs map[string]string `json:"outputs"`
+	// 不为空时, 将整个响应体([]byte)以该 key 输出.
+	BodyKey string `json:"body_key"`
+}
+
+func (cfg *PluginConfig) LoadDefault() {
+	if cfg.Method == "" {
+		cfg.Method = nethttp.MethodGet
+	}
+	cfg.Method = strings.ToUpper(cfg.Method)
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxBodySize == 0 {
+		cfg.MaxBodySize = DefaultMaxBodySize
+	}
+}
+
+func (cfg *PluginConfig) Validate() error {
+	if cfg.Name == "" {
+		return errors.New("http plugin must have name")
+	}
+	if cfg.URL == "" {
+		return fmt.Errorf("http plugin %s must have url", cfg.Name)
+	}
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("http plugin %s timeout can not be negative", cfg.Name)
+	}
+	if cfg.MaxBodySize < 0 {
+		return fmt.Errorf("http plugin %s max_body_size can not be negative", cfg.Name)
+	}
+	paramSet := make(map[string]struct{}, len(cfg.Params))
+	for _, param := range cfg.Params {
+		if _, ok := paramSet[param]; ok {
+			return fmt.Errorf("http plugin %s param repeat: %s", cfg.Name, param)
+		}
+		paramSet[param] = struct{}{}
+	}
+	if _, ok := cfg.Outputs[cfg.BodyKey]; ok && cfg.BodyKey != "" {
+		return fmt.Errorf("http plugin %s body_key conflicts with outputs: %s", cfg.Name, cfg.BodyKey)
+	}
+	return nil
+}
+
+const (
+	escapePath  = "pathescape"
+	escapeQuery = "queryescape"
+	escapeRaw   = "raw"
+)
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	escapePath: func(v interface{}) string {
+		return url.PathEscape(fmt.Sprint(v))
+	},
+	escapeQuery: func(v interface{}) string {
+		return url.QueryEscape(fmt.Sprint(v))
+	},
+	escapeRaw: func(v interface{}) string {
+		return fmt.Sprint(v)
+	},
+}
+
+// URL 模板中已经转义(或指定不转义)的函数.
+var urlEscapers = map[string]struct{}{escapePath: {}, escapeQuery: {}, escapeRaw: {}, "urlquery": {}}
+
+// Plugin 根据 PluginConfig 发起 HTTP 请求的插件.
+type Plugin struct {
+	cfg    PluginConfig
+	client *nethttp.Client
+
+	url    *template.Template
+	header map[string]*template.Template
+	body   *template.Template // 为空时不发送 body
+}
+
+var _ supplier.IDescribedPlugin = new(Plugin)
+
+// NewPlugin 创建 HTTP 插件, 配置或模板错误时返回错误. client 为空时使用 http.DefaultClient.
+func NewPlugin(_cfg *PluginConfig, client *nethttp.Client) (*Plugin, error) {
+	cfg := *_cfg
+	cfg.LoadDefault()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = nethttp.DefaultClient
+	}
+
+	plugin := &Plugin{
+		cfg:    cfg,
+		client: client,
+		header: make(map[string]*template.Template, len(cfg.Header)),
+	}
+	var err error
+	if plugin.url, err = parseTemplate(cfg.Name+".url", cfg.URL); err != nil {
+		return nil, err
+	}
+	escapeURLNodes(plugin.url.Tree, plugin.url.Tree.Root, false)
+	for key, value := range cfg.Header {
+		if plugin.header[key], err = parseTemplate(cfg.Name+".header."+key, value); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Body != "" {
+		if plugin.body, err = parseTemplate(cfg.Name+".body", cfg.Body); err != nil {
+			return nil, err
+		}
+	}
+	return plugin, nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("http plugin template %s parse error: %s", name, err.Error())
+	}
+	return tmpl, nil
+}
+
+// 为 URL 模板中的参数添加转义函数, 返回处理完 list 后是否已经进入 query 部分.
+func escapeURLNodes(tree *parse.Tree, list *parse.ListNode, inQuery bool) bool {
+	if list == nil {
+		return inQuery
+	}
+	for _, n := range list.Nodes {
+		switch n := n.(type) {
+		case *parse.TextNode:
+			inQuery = inQuery || bytes.ContainsRune(n.Text, '?')
+		case *parse.ActionNode:
+			escapeURLAction(tree, n, inQuery)
+		case *parse.IfNode:
+			inQuery = escapeURLBranch(tree, &n.BranchNode, inQuery)
+		case *parse.RangeNode:
+			inQuery = escapeURLBranch(tree, &n.BranchNode, inQuery)
+		case *parse.WithNode:
+			inQuery = escapeURLBranch(tree, &n.BranchNode, inQuery)
+		}
+	}
+	return inQuery
+}
+
+func escapeURLBranch(tree *parse.Tree, branch *parse.BranchNode, inQuery bool) bool {
+	listInQuery := escapeURLNodes(tree, branch.List, inQuery)
+	elseInQuery := escapeURLNodes(tree, branch.ElseList, inQuery)
+	return listInQuery || elseInQuery
+}
+
+func escapeURLAction(tree *parse.Tree, action *parse.ActionNode, inQuery bool) {
+	pipe := action.Pipe
+	// 变量声明没有输出
+	if len(pipe.Decl) > 0 || len(pipe.Cmds) == 0 {
+		return
+	}
+	last := pipe.Cmds[len(pipe.Cmds)-1]
+	if ident, ok := last.Args[0].(*parse.IdentifierNode); ok {
+		if _, ok := urlEscapers[ident.Ident]; ok {
+			return
+		}
+	}
+	escaper := escapePath
+	if inQuery {
+		escaper = escapeQuery
+	}
+	pipe.Cmds = append(pipe.Cmds, &parse.CommandNode{
+		NodeType: parse.NodeCommand,
+		Pos:      action.Pos,
+		Args:     []parse.Node{parse.NewIdentifier(escaper).SetTree(tree).SetPos(action.Pos)},
+	})
+}
+
+func (p *Plugin) GetName() string {
+	return p.cfg.Name
+}
+
+func (p *Plugin) Describe() *supplier.PluginSchema {
+	schema := &supplier.PluginSchema{
+		Name:    p.cfg.Name,
+		Params:  make([]supplier.ParamSchema, len(p.cfg.Params)),
+		Outputs: make([]supplier.OutputSchema, 0, len(p.cfg.Outputs)+1),
+	}
+	for i, param := range p.cfg.Params {
+		schema.Params[i] = supplier.ParamSchema{Name: param, Required: true}
+	}
+	for key := range p.cfg.Outputs {
+		schema.Outputs = append(schema.Outputs, supplier.OutputSchema{Name: key})
+	}
+	if p.cfg.BodyKey != "" {
+		schema.Outputs = append(schema.Outputs, supplier.OutputSchema{Name: p.cfg.BodyKey})
+	}
+	return schema
+}
+
+func (p *Plugin) Call(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+	if len(args) != len(p.cfg.Params) {
+		return map[string]interface{}{}, fmt.Errorf("http plugin %s expects %d params, got %d",
+			p.cfg.Name, len(p.cfg.Params), len(args))
+	}
+	data := make(map[string]interface{}, len(args))
+	for i, param := range p.cfg.Params {
+		data[param] = args[i]
+	}
+
+	req, err := p.newRequest(ctx, data)
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, p.cfg.MaxBodySize+1))
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+	if int64(len(body)) > p.cfg.MaxBodySize {
+		return map[string]interface{}{}, fmt.Errorf("http plugin %s response body exceeds %d bytes",
+			p.cfg.Name, p.cfg.MaxBodySize)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if len(body) > maxErrorBodyLen {
+			body = body[:maxErrorBodyLen]
+		}
+		return map[string]interface{}{}, fmt.Errorf("http plugin %s status %d, body: %s",
+			p.cfg.Name, resp.StatusCode, body)
+	}
+
+	out := make(map[string]interface{}, len(p.cfg.Outputs)+1)
+	for key, path := range p.cfg.Outputs {
+		// 路径不存在时不输出该 key, 由 node 处理为 field_not_found_in_supply_response
+		if result := gjson.GetBytes(body, path); result.Exists() {
+			out[key] = result
+		}
+	}
+	if p.cfg.BodyKey != "" {
+		out[p.cfg.BodyKey] = body
+	}
+	return out, nil
+}
+
+func (p *Plugin) newRequest(ctx context.Context, data map[string]interface{}) (*nethttp.Request, error) {
+	reqURL, err := execute(p.url, data)
+	if err != nil {
+		return nil, err
+	}
+	var body io.Reader
+	if p.body != nil {
+		b, err := execute(p.body, data)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(b)
+	}
+	req, err := nethttp.NewRequestWithContext(ctx, p.cfg.Method, reqURL, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, tmpl := range p.header {
+		value, err := execute(tmpl, data)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(key, value)
+	}
+	return req, nil
+}
+
+func execute(tmpl *template.Template, data map[string]interface{}) (string, error) {
+	buf := bytes.Buffer{}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,157 @@
+package http
+
+import (
+	"context"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer() *httptest.Server {
+	mux := nethttp.NewServeMux()
+	mux.HandleFunc("/users/42", func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":42,"name":"alice","token":"`+r.Header.Get("X-Token")+`","tags":["a","b"]}`)
+	})
+	mux.HandleFunc("/echo", func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	})
+	mux.HandleFunc("/slow", func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	mux.HandleFunc("/large", func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		_, _ = io.WriteString(w, `{"data":"0123456789"}`)
+	})
+	mux.HandleFunc("/error", func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(nethttp.StatusInternalServerError)
+		_, _ = io.WriteString(w, "boom")
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestPlugin(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	httpSupplier, err := NewSupplier([]*PluginConfig{
+		{
+			Name:    "GetUser",
+			URL:     server.URL + "/users/{{.user_id}}",
+			Header:  map[string]string{"X-Token": "token-{{.user_id}}"},
+			Params:  []string{"user_id"},
+			Outputs: map[string]string{"name": "name", "token": "token", "tags": "tags", "missing": "not_exist"},
+		},
+		{
+			Name:    "Echo",
+			Method:  "post",
+			URL:     server.URL + "/echo",
+			Body:    `{"id":{{.id}},"tags":{{json .tags}}}`,
+			Params:  []string{"id", "tags"},
+			Outputs: map[string]string{"first_tag": "tags.0"},
+			BodyKey: "body",
+		},
+		{Name: "Large", URL: server.URL + "/large", MaxBodySize: 8, BodyKey: "body"},
+		{Name: "Slow", URL: server.URL + "/slow", Timeout: time.Millisecond * 10},
+		{Name: "Error", URL: server.URL + "/error"},
+	}, server.Client())
+	assert.NoError(t, err)
+
+	t.Run("get", func(t *testing.T) {
+		out, err := httpSupplier.Supply(context.Background(), "GetUser", []interface{}{int64(42)})
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", dtype.ToString(out["name"]))
+		assert.Equal(t, "token-42", dtype.ToString(out["token"]))
+		tags, err := dtype.Convert(out["tags"], dtype.ArrayString)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, tags)
+		_, ok := out["missing"]
+		assert.False(t, ok)
+	})
+
+	t.Run("post", func(t *testing.T) {
+		out, err := httpSupplier.Supply(context.Background(), "Echo", []interface{}{1, []string{"x", "y"}})
+		assert.NoError(t, err)
+		assert.Equal(t, "x", dtype.ToString(out["first_tag"]))
+		assert.Equal(t, []byte(`{"id":1,"tags":["x","y"]}`), out["body"])
+	})
+
+	// URL 中的参数默认转义, 不能改写路径和 query
+	t.Run("escape", func(t *testing.T) {
+		// 不使用 ServeMux, 它会清理路径中的 ..
+		searchServer := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			_, _ = io.WriteString(w, `{"path":"`+r.URL.EscapedPath()+`","q":"`+r.URL.Query().Get("q")+
+				`","page":"`+r.URL.Query().Get("page")+`"}`)
+		}))
+		defer searchServer.Close()
+		plugin, err := NewPlugin(&PluginConfig{
+			Name:    "Search",
+			URL:     searchServer.URL + "/search/{{.name}}?q={{.q}}&page={{raw .page}}",
+			Params:  []string{"name", "q", "page"},
+			Outputs: map[string]string{"path": "path", "q": "q", "page": "page"},
+		}, searchServer.Client())
+		assert.NoError(t, err)
+		out, err := plugin.Call(context.Background(), "1/../admin?x=", "a&page=9 b", "2")
+		assert.NoError(t, err)
+		assert.Equal(t, "/search/1%2F..%2Fadmin%3Fx=", dtype.ToString(out["path"]))
+		assert.Equal(t, "a&page=9 b", dtype.ToString(out["q"]))
+		assert.Equal(t, "2", dtype.ToString(out["page"]))
+	})
+
+	t.Run("max_body_size", func(t *testing.T) {
+		_, err := httpSupplier.Supply(context.Background(), "Large", []interface{}{})
+		assert.EqualError(t, err, "http plugin Large response body exceeds 8 bytes")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		_, err := httpSupplier.Supply(context.Background(), "Slow", []interface{}{})
+		assert.Error(t, err)
+	})
+
+	t.Run("status_error", func(t *testing.T) {
+		_, err := httpSupplier.Supply(context.Background(), "Error", []interface{}{})
+		assert.EqualError(t, err, "http plugin Error status 500, body: boom")
+	})
+
+	t.Run("param_count", func(t *testing.T) {
+		_, err := httpSupplier.Supply(context.Background(), "GetUser", []interface{}{})
+		assert.Error(t, err)
+	})
+
+	t.Run("describe", func(t *testing.T) {
+		schemas := supplier.DescribePlugins(httpSupplier)
+		assert.Equal(t, []supplier.ParamSchema{{Name: "user_id", Required: true}}, schemas["GetUser"].Params)
+		_, ok := schemas["Echo"].GetOutput("body")
+		assert.True(t, ok)
+	})
+}
+
+func TestNewPlugin(t *testing.T) {
+	cases := []struct {
+		name  string
+		cfg   *PluginConfig
+		isErr bool
+	}{
+		{"ok", &PluginConfig{Name: "ok", URL: "http://localhost/{{.id}}", Params: []string{"id"}}, false},
+		{"no_name", &PluginConfig{URL: "http://localhost"}, true},
+		{"no_url", &PluginConfig{Name: "no_url"}, true},
+		{"param_repeat", &PluginConfig{Name: "repeat", URL: "http://localhost", Params: []string{"id", "id"}}, true},
+		{"bad_template", &PluginConfig{Name: "bad", URL: "http://localhost/{{.id"}, true},
+		{"max_body_size", &PluginConfig{Name: "size", URL: "http://localhost", MaxBodySize: -1}, true},
+		{"body_key_conflict", &PluginConfig{Name: "conflict", URL: "http://localhost",
+			Outputs: map[string]string{"body": "a"}, BodyKey: "body"}, true},
+	}
+	for _, c := range cases {
+		_, err := NewPlugin(c.cfg, nil)
+		assert.Equal(t, c.isErr, err != nil, c.name)
+	}
+}
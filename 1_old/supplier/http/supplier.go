@@ -0,0 +1,22 @@
+package http
+
+import (
+	nethttp "net/http"
+
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+)
+
+const SupplierName = "http"
+
+// NewSupplier 根据插件配置创建 HTTP 供应商, 所有插件共享 client. client 为空时使用 http.DefaultClient.
+func NewSupplier(cfgs []*PluginConfig, client *nethttp.Client, options ...supplier.Option) (*supplier.DefaultSupplier, error) {
+	plugins := make([]supplier.IPlugin, len(cfgs))
+	for i, cfg := range cfgs {
+		plugin, err := NewPlugin(cfg, client)
+		if err != nil {
+			return nil, err
+		}
+		plugins[i] = plugin
+	}
+	return supplier.NewDefaultSupplier(SupplierName, plugins, options...), nil
+}
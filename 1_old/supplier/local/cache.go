@@ -0,0 +1,68 @@
+package local
+
+import (
+	"container/list"
+	"sync"
+)
+
+// 解析结果缓存的容量. 参数一般为常量, 但也可能来自上游字段, 所以限制容量, 满时淘汰最久未使用的 key.
+const (
+	regexCacheSize    = 1024
+	cidrCacheSize     = 1024
+	locationCacheSize = 256
+)
+
+// lruCache 容量固定的 LRU 缓存, 用于缓存解析后的参数, 不过期.
+type lruCache struct {
+	size   int
+	locker sync.Locker
+	ll     *list.List
+	items  map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value interface{}
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:   size,
+		locker: &sync.Mutex{},
+		ll:     list.New(),
+		items:  make(map[string]*list.Element, size),
+	}
+}
+
+func (cache *lruCache) Load(key string) (interface{}, bool) {
+	cache.locker.Lock()
+	defer cache.locker.Unlock()
+	elem, ok := cache.items[key]
+	if !ok {
+		return nil, false
+	}
+	cache.ll.MoveToFront(elem)
+	return elem.Value.(*lruEntry).value, true
+}
+
+func (cache *lruCache) Store(key string, value interface{}) {
+	cache.locker.Lock()
+	defer cache.locker.Unlock()
+	if elem, ok := cache.items[key]; ok {
+		elem.Value.(*lruEntry).value = value
+		cache.ll.MoveToFront(elem)
+		return
+	}
+	cache.items[key] = cache.ll.PushFront(&lruEntry{key: key, value: value})
+	for cache.ll.Len() > cache.size {
+		elem := cache.ll.Back()
+		cache.ll.Remove(elem)
+		delete(cache.items, elem.Value.(*lruEntry).key)
+	}
+}
+
+func (cache *lruCache) Len() int {
+	cache.locker.Lock()
+	defer cache.locker.Unlock()
+	return cache.ll.Len()
+}
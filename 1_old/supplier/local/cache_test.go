@@ -0,0 +1,36 @@
+package local
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCache(t *testing.T) {
+	cache := newLRUCache(2)
+	cache.Store("a", 1)
+	cache.Store("b", 2)
+	_, ok := cache.Load("a")
+	assert.True(t, ok)
+	// b 最久未使用, 被淘汰
+	cache.Store("c", 3)
+	_, ok = cache.Load("b")
+	assert.False(t, ok)
+	value, ok := cache.Load("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	cache.Store("a", 4)
+	value, _ = cache.Load("a")
+	assert.Equal(t, 4, value)
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestParseCacheBounded(t *testing.T) {
+	for i := 0; i < regexCacheSize+10; i++ {
+		_, err := Regex(context.Background(), "text", "t"+strconv.Itoa(i))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, regexCacheSize, regexCache.Len())
+}
@@ -0,0 +1,69 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"git.in.zhihu.com/antispam/datasupply/expr"
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+)
+
+const (
+	ExprPlugin = "Expr"
+	// Expr 插件输出结果的 key
+	ExprOutput = ValueOutput
+)
+
+// NewExprPlugin 表达式插件, 用于 `ratio = a / b` 这类简单的派生字段.
+// 第一个参数为编译后的表达式(*expr.Expr, 常量参数), 其余参数依次为表达式变量的值.
+func NewExprPlugin() supplier.IPlugin {
+	return supplier.NewDescribedPlugin(supplier.NewDefaultPlugin(ExprPlugin, Expr),
+		&supplier.PluginSchema{
+			Params: []supplier.ParamSchema{
+				{Name: "expr", Required: true},
+				{Name: "vars"},
+			},
+			Variadic:    true,
+			Outputs:     []supplier.OutputSchema{{Name: ExprOutput}},
+			CheckParams: checkExprParams,
+		})
+}
+
+// 构建节点时校验表达式为常量, 且变量的数量和类型与参数一致.
+func checkExprParams(values []interface{}, paramTypes []dtype.DType) error {
+	e, ok := values[0].(*expr.Expr)
+	if !ok {
+		return fmt.Errorf("expr func first param must be constant expr(*expr.Expr), got %T", values[0])
+	}
+	vars := e.Vars()
+	if len(vars) != len(values)-1 {
+		return fmt.Errorf("expr [%s] expects %d variables, got %d", e.Source(), len(vars), len(values)-1)
+	}
+	for i, v := range vars {
+		// 变量值在求值时会转换为声明的类型
+		param := supplier.ParamSchema{Type: v.Type, Convertible: true}
+		if paramType := paramTypes[i+1]; !param.Accept(paramType) {
+			return fmt.Errorf("expr [%s] variable %s type %s can not convert to %s", e.Source(), v.Name, paramType, v.Type)
+		}
+	}
+	return nil
+}
+
+func Expr(_ context.Context, params ...interface{}) (map[string]interface{}, error) {
+	if len(params) < 1 {
+		return map[string]interface{}{},
+			errors.New("expr func at least one param: expr(*expr.Expr),vars(...interface{})")
+	}
+	e, ok := params[0].(*expr.Expr)
+	if !ok {
+		return map[string]interface{}{},
+			fmt.Errorf("expr func first param must be expr(*expr.Expr), got %T", params[0])
+	}
+	value, err := e.Eval(params[1:]...)
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+	return map[string]interface{}{ExprOutput: value}, nil
+}
@@ -0,0 +1,40 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"git.in.zhihu.com/antispam/datasupply/expr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpr(t *testing.T) {
+	ratio := expr.MustCompile("b == 0 ? null : a / b", []expr.Var{
+		{Name: "a", Type: dtype.Int64},
+		{Name: "b", Type: dtype.Int64},
+	})
+	testCases := []struct {
+		name        string
+		params      []interface{}
+		expectValue interface{}
+		expectErr   bool
+	}{
+		{"ratio", []interface{}{ratio, int64(3), int64(4)}, 0.75, false},
+		{"convert", []interface{}{ratio, "3", 4}, 0.75, false},
+		{"zero", []interface{}{ratio, int64(3), int64(0)}, nil, false},
+		{"null", []interface{}{ratio, nil, int64(2)}, nil, false},
+		{"not_expr", []interface{}{"a / b", int64(3), int64(4)}, nil, true},
+		{"vars_not_match", []interface{}{ratio, int64(3)}, nil, true},
+		{"no_params", []interface{}{}, nil, true},
+	}
+	for _, tcase := range testCases {
+		result, err := Expr(context.Background(), tcase.params...)
+		if tcase.expectErr {
+			assert.Error(t, err, tcase.name)
+			continue
+		}
+		assert.NoError(t, err, tcase.name)
+		assert.Equal(t, tcase.expectValue, result[ExprOutput], tcase.name)
+	}
+}
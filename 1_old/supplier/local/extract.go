@@ -4,12 +4,20 @@ import (
 	"context"
 	"errors"
 
+	"git.in.zhihu.com/antispam/datasupply/dtype"
 	"git.in.zhihu.com/antispam/datasupply/supplier"
 	"github.com/tidwall/gjson"
 )
 
 func NewExtractPlugin() supplier.IPlugin {
-	return supplier.NewDefaultPlugin("Extract", Extract)
+	return supplier.NewDescribedPlugin(supplier.NewDefaultPlugin("Extract", Extract),
+		&supplier.PluginSchema{
+			Params: []supplier.ParamSchema{
+				{Name: "payload", Type: dtype.ArrayByte, Required: true},
+				{Name: "paths", Type: dtype.String, Required: true},
+			},
+			Variadic: true,
+		})
 }
 
 func Extract(_ context.Context, params ...interface{}) (map[string]interface{}, error) {
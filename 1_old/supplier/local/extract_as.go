@@ -0,0 +1,60 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+)
+
+const ExtractAsPlugin = "ExtractAs"
+
+// NewExtractAsPlugin 按指定格式解析 payload, 并按路径取值. Extract 只支持 json, 返回 gjson.Result.
+// 参数: format(string 或 ExtractFormat, 常量, 见 ExtractFormats), payload([]byte), paths(...string).
+// 返回值: key 为 path, value 为 go 原生类型的值(见 ExtractFormat), 路径不存在时为 nil.
+func NewExtractAsPlugin() supplier.IPlugin {
+	return supplier.NewDescribedPlugin(supplier.NewDefaultPlugin(ExtractAsPlugin, ExtractAs),
+		&supplier.PluginSchema{
+			Params: []supplier.ParamSchema{
+				{Name: "format", Required: true},
+				{Name: "payload", Type: dtype.ArrayByte, Required: true},
+				{Name: "paths", Type: dtype.String, Required: true},
+			},
+			Variadic: true,
+		})
+}
+
+func ExtractAs(_ context.Context, params ...interface{}) (map[string]interface{}, error) {
+	if len(params) < 3 {
+		return map[string]interface{}{},
+			errors.New("extract_as func at least three param: format(string),payload([]byte),paths(...string)")
+	}
+	format, err := getExtractFormat(params[0])
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+	payload, ok := params[1].([]byte)
+	if !ok {
+		return map[string]interface{}{},
+			errors.New("extract_as func second param must be payload([]byte)")
+	}
+	paths := make([]string, len(params)-2)
+	for i := 2; i < len(params); i++ {
+		if paths[i-2], ok = params[i].(string); !ok {
+			return map[string]interface{}{},
+				fmt.Errorf("extract_as func param %d must be path(string)", i+1)
+		}
+	}
+
+	values, err := format.Extract(payload, paths)
+	if err != nil {
+		return map[string]interface{}{}, fmt.Errorf("extract %s error: %s", format, err.Error())
+	}
+	out := make(map[string]interface{}, len(paths))
+	for i, path := range paths {
+		out[path] = values[i]
+	}
+	return out, nil
+}
@@ -7,6 +7,10 @@ import (
 
 	"git.in.zhihu.com/antispam/datasupply/dtype"
 	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+	"github.com/tinylib/msgp/msgp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 func TestExtract(t *testing.T) {
@@ -65,3 +69,117 @@ func TestExtract(t *testing.T) {
 		}
 	})
 }
+
+func TestExtractAs(t *testing.T) {
+	msgpackPayload := msgp.AppendMapHeader(nil, 3)
+	msgpackPayload = msgp.AppendString(msgpackPayload, "uid")
+	msgpackPayload = msgp.AppendUint8(msgpackPayload, 12)
+	msgpackPayload = msgp.AppendString(msgpackPayload, "score")
+	msgpackPayload = msgp.AppendFloat32(msgpackPayload, 0.5)
+	msgpackPayload = msgp.AppendString(msgpackPayload, "user")
+	msgpackPayload = msgp.AppendMapHeader(msgpackPayload, 1)
+	msgpackPayload = msgp.AppendString(msgpackPayload, "tags")
+	msgpackPayload = msgp.AppendArrayHeader(msgpackPayload, 2)
+	msgpackPayload = msgp.AppendString(msgpackPayload, "a")
+	msgpackPayload = msgp.AppendString(msgpackPayload, "b")
+
+	protoPayload, err := proto.Marshal(&descriptorpb.DescriptorProto{
+		Name: proto.String("User"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			{Name: proto.String("id"), Number: proto.Int32(1)},
+		},
+		ReservedName: []string{"a", "b"},
+	})
+	assert.NoError(t, err)
+	protoFormat := NewProtoFormat((&descriptorpb.DescriptorProto{}).ProtoReflect().Descriptor())
+
+	testCases := []struct {
+		name    string
+		payload []byte
+		format  interface{}
+		expect  map[string]interface{}
+	}{
+		{"json", []byte(`{"uid":12,"score":0.5,"user":{"tags":["a","b"]},"a.b":true}`), "json",
+			map[string]interface{}{
+				"uid":         int64(12),
+				"score":       0.5,
+				"user.tags":   []interface{}{"a", "b"},
+				"user.tags.1": "b",
+				"user.tags.#": int64(2),
+				`a\.b`:        true,
+				"not_exist":   nil,
+			}},
+		{"msgpack", msgpackPayload, "msgpack",
+			map[string]interface{}{
+				"uid":         int64(12),
+				"score":       0.5,
+				"user":        map[string]interface{}{"tags": []interface{}{"a", "b"}},
+				"user.tags.1": "b",
+				"user.tags.#": int64(2),
+				"user.tags.2": nil,
+				"uid.x":       nil,
+			}},
+		{"form", []byte("uid=12&tag=a&tag=b&a.b=1"), "form",
+			map[string]interface{}{
+				"uid":       "12",
+				"tag":       []interface{}{"a", "b"},
+				"tag.0":     "a",
+				`a\.b`:      "1",
+				"not_exist": nil,
+			}},
+		{"xml", []byte(`<event type="login"><uid>12</uid><tag>a</tag><tag>b</tag><user vip="1">x</user></event>`), "xml",
+			map[string]interface{}{
+				"event.@type":      "login",
+				"event.uid":        "12",
+				"event.tag.#":      int64(2),
+				"event.tag.1":      "b",
+				"event.user.@vip":  "1",
+				"event.user.#text": "x",
+				"event.not_exist":  nil,
+			}},
+		{"protobuf", protoPayload, protoFormat,
+			map[string]interface{}{
+				"name":              "User",
+				"field.#":           int64(1),
+				"field.0.name":      "id",
+				"field.0.number":    int64(1),
+				"field.0.type_name": "",
+				"reserved_name.1":   "b",
+				"options":           nil,
+			}},
+	}
+	for _, tcase := range testCases {
+		params := []interface{}{tcase.format, tcase.payload}
+		for path := range tcase.expect {
+			params = append(params, path)
+		}
+		result, err := ExtractAs(context.Background(), params...)
+		assert.NoError(t, err, tcase.name)
+		assert.Equal(t, tcase.expect, result, tcase.name)
+	}
+
+	errorCases := []struct {
+		name    string
+		payload []byte
+		format  interface{}
+	}{
+		{"format_type", []byte(`{}`), 1},
+		{"unknown_format", []byte(`{}`), "yaml"},
+		{"invalid_json", []byte(`{"a":`), "json"},
+		{"invalid_msgpack", []byte{0xc1}, "msgpack"},
+		{"invalid_xml", []byte(`<a><b></a>`), "xml"},
+		{"invalid_protobuf", []byte{0xff}, protoFormat},
+	}
+	for _, tcase := range errorCases {
+		_, err := ExtractAs(context.Background(), tcase.format, tcase.payload, "a")
+		assert.Error(t, err, tcase.name)
+	}
+
+	// Extract 的参数都作为 json 路径, 包括与格式同名的路径
+	result, err := Extract(context.Background(), []byte(`{"uid":12,"json":1}`), "json", "uid")
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	for _, path := range []string{"json", "uid"} {
+		assert.IsType(t, gjson.Result{}, result[path])
+	}
+}
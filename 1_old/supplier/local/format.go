@@ -0,0 +1,266 @@
+package local
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// ExtractFormat ExtractAs 插件支持的 payload 格式.
+//
+// 路径使用 . 分隔, 如 `user.tags.0`. 数字表示数组下标, # 表示数组长度, key 中的 . 使用 \. 转义.
+// 取到的值为 go 原生类型: 对象为 map[string]interface{}, 数组为 []interface{},
+// 整数为 int64(超出 int64 范围的 msgpack 整数为 uint64), 浮点数为 float64, 以及 string, bool, []byte 和 nil.
+type ExtractFormat interface {
+	// Extract 按照 paths 取值, 返回值与 paths 一一对应, 路径不存在时为 nil.
+	Extract(payload []byte, paths []string) ([]interface{}, error)
+	String() string
+}
+
+// 内置的格式, ExtractAs 的 format 参数为 string 时从这里查找.
+var ExtractFormats = map[string]ExtractFormat{
+	"json":    jsonFormat{},
+	"msgpack": newTreeFormat("msgpack", decodeMsgpack),
+	"form":    newTreeFormat("form", decodeForm),
+	"xml":     newTreeFormat("xml", decodeXML),
+}
+
+// format 参数为 ExtractFormat 或内置格式的名称.
+func getExtractFormat(param interface{}) (ExtractFormat, error) {
+	switch format := param.(type) {
+	case ExtractFormat:
+		return format, nil
+	case string:
+		if f, ok := ExtractFormats[format]; ok {
+			return f, nil
+		}
+		return nil, fmt.Errorf("extract format [%s] not found", format)
+	}
+	return nil, fmt.Errorf("extract format must be string or ExtractFormat, got %T", param)
+}
+
+// json 直接使用 gjson 查询, 不需要完整解析 payload. 路径也支持 gjson 的其他语法.
+type jsonFormat struct{}
+
+func (jsonFormat) String() string {
+	return "json"
+}
+
+func (jsonFormat) Extract(payload []byte, paths []string) ([]interface{}, error) {
+	if !gjson.ValidBytes(payload) {
+		return nil, fmt.Errorf("invalid json")
+	}
+	values := make([]interface{}, len(paths))
+	for i, result := range gjson.GetManyBytes(payload, paths...) {
+		values[i] = gjsonValue(result)
+	}
+	return values, nil
+}
+
+func gjsonValue(result gjson.Result) interface{} {
+	switch result.Type {
+	case gjson.False, gjson.True:
+		return result.Bool()
+	case gjson.String:
+		return result.Str
+	case gjson.Number:
+		// 整数不经过 float64, 避免丢失精度
+		if n, err := strconv.ParseInt(result.Raw, 10, 64); err == nil {
+			return n
+		}
+		return result.Num
+	case gjson.JSON:
+		if result.IsArray() {
+			results := result.Array()
+			values := make([]interface{}, len(results))
+			for i := range results {
+				values[i] = gjsonValue(results[i])
+			}
+			return values
+		}
+		m := map[string]interface{}{}
+		result.ForEach(func(key, value gjson.Result) bool {
+			m[key.Str] = gjsonValue(value)
+			return true
+		})
+		return m
+	}
+	return nil
+}
+
+// 先将 payload 完整解析为 map[string]interface{}/[]interface{}, 再按路径查找.
+type treeFormat struct {
+	name   string
+	decode func(payload []byte) (interface{}, error)
+}
+
+func newTreeFormat(name string, decode func(payload []byte) (interface{}, error)) *treeFormat {
+	return &treeFormat{name: name, decode: decode}
+}
+
+func (format *treeFormat) String() string {
+	return format.name
+}
+
+func (format *treeFormat) Extract(payload []byte, paths []string) ([]interface{}, error) {
+	tree, err := format.decode(payload)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(paths))
+	for i, path := range paths {
+		values[i] = lookupPath(tree, splitPath(path))
+	}
+	return values, nil
+}
+
+func splitPath(path string) []string {
+	segments := []string{}
+	segment := strings.Builder{}
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == '.':
+			segment.WriteByte('.')
+			i++
+		case path[i] == '.':
+			segments = append(segments, segment.String())
+			segment.Reset()
+		default:
+			segment.WriteByte(path[i])
+		}
+	}
+	return append(segments, segment.String())
+}
+
+func lookupPath(value interface{}, segments []string) interface{} {
+	for _, segment := range segments {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[segment]
+		case []interface{}:
+			if segment == "#" {
+				value = int64(len(v))
+				continue
+			}
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil
+			}
+			value = v[index]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+func decodeMsgpack(payload []byte) (interface{}, error) {
+	value, _, err := msgp.ReadIntfBytes(payload)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeMsgpack(value), nil
+}
+
+// msgpack 中较小的整数和浮点数会使用更短的编码, 统一转换为 int64/float64.
+func normalizeMsgpack(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = normalizeMsgpack(elem)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = normalizeMsgpack(elem)
+		}
+	case uint64:
+		if v <= 1<<63-1 {
+			return int64(v)
+		}
+	case float32:
+		return float64(v)
+	}
+	return value
+}
+
+// 同名参数只有一个值时为 string, 有多个值时为 []interface{}.
+func decodeForm(payload []byte) (interface{}, error) {
+	query, err := url.ParseQuery(string(payload))
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{}, len(query))
+	for key, values := range query {
+		if len(values) == 1 {
+			m[key] = values[0]
+			continue
+		}
+		list := make([]interface{}, len(values))
+		for i := range values {
+			list[i] = values[i]
+		}
+		m[key] = list
+	}
+	return m, nil
+}
+
+// xml 元素转换为 map, 顶层 map 的 key 为根元素名.
+// 属性的 key 为 @name; 元素只有文本时值为 string, 否则文本的 key 为 #text; 同名子元素转换为 []interface{}.
+func decodeXML(payload []byte) (interface{}, error) {
+	type element struct {
+		name     string
+		children map[string]interface{}
+		text     strings.Builder
+	}
+	root := &element{children: map[string]interface{}{}}
+	stack := []*element{root}
+	decoder := xml.NewDecoder(strings.NewReader(string(payload)))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			elem := &element{name: token.Name.Local, children: map[string]interface{}{}}
+			for _, attr := range token.Attr {
+				elem.children["@"+attr.Name.Local] = attr.Value
+			}
+			stack = append(stack, elem)
+		case xml.CharData:
+			stack[len(stack)-1].text.Write(token)
+		case xml.EndElement:
+			elem := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			var value interface{} = elem.children
+			text := strings.TrimSpace(elem.text.String())
+			if len(elem.children) == 0 {
+				value = text
+			} else if text != "" {
+				elem.children["#text"] = text
+			}
+			parent := stack[len(stack)-1].children
+			switch exist := parent[elem.name].(type) {
+			case nil:
+				parent[elem.name] = value
+			case []interface{}:
+				parent[elem.name] = append(exist, value)
+			default:
+				parent[elem.name] = []interface{}{exist, value}
+			}
+		}
+	}
+	if len(root.children) == 0 {
+		return nil, fmt.Errorf("empty xml")
+	}
+	return root.children, nil
+}
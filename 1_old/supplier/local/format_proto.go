@@ -0,0 +1,73 @@
+package local
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// NewProtoFormat 使用 message 描述解析 protobuf payload, 作为 ExtractAs 的 format 参数.
+// 路径使用字段名(proto 中定义的名字), 未设置的 message 字段为 nil, 枚举为 int64.
+func NewProtoFormat(desc protoreflect.MessageDescriptor) ExtractFormat {
+	return newTreeFormat("protobuf_"+string(desc.FullName()), func(payload []byte) (interface{}, error) {
+		msg := dynamicpb.NewMessage(desc)
+		if err := proto.Unmarshal(payload, msg); err != nil {
+			return nil, err
+		}
+		return protoMessageToMap(msg), nil
+	})
+}
+
+func protoMessageToMap(msg protoreflect.Message) map[string]interface{} {
+	fields := msg.Descriptor().Fields()
+	m := make(map[string]interface{}, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.Message() != nil && !fd.IsList() && !fd.IsMap() && !msg.Has(fd) {
+			continue
+		}
+		m[string(fd.Name())] = protoFieldValue(fd, msg.Get(fd))
+	}
+	return m
+}
+
+func protoFieldValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch {
+	case fd.IsMap():
+		m := make(map[string]interface{}, v.Map().Len())
+		v.Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+			m[key.String()] = protoScalarValue(fd.MapValue(), value)
+			return true
+		})
+		return m
+	case fd.IsList():
+		list := make([]interface{}, v.List().Len())
+		for i := range list {
+			list[i] = protoScalarValue(fd, v.List().Get(i))
+		}
+		return list
+	}
+	return protoScalarValue(fd, v)
+}
+
+func protoScalarValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return v.Int()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return int64(v.Uint())
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if u := v.Uint(); u > 1<<63-1 {
+			return u
+		}
+		return int64(v.Uint())
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float()
+	case protoreflect.EnumKind:
+		return int64(v.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return protoMessageToMap(v.Message())
+	}
+	return v.Interface()
+}
@@ -4,13 +4,21 @@ import (
 	"context"
 	"errors"
 
+	"git.in.zhihu.com/antispam/datasupply/dtype"
 	"git.in.zhihu.com/antispam/datasupply/supplier"
 )
 
 const ForwardPlugin = "Forward"
 
 func NewForwardPlugin() supplier.IPlugin {
-	return supplier.NewDefaultPlugin(ForwardPlugin, Forward)
+	return supplier.NewDescribedPlugin(supplier.NewDefaultPlugin(ForwardPlugin, Forward),
+		&supplier.PluginSchema{
+			Params: []supplier.ParamSchema{
+				{Name: "keys", Type: dtype.ArrayString, Required: true},
+				{Name: "values", Required: true},
+			},
+			Variadic: true,
+		})
 }
 
 func Forward(_ context.Context, params ...interface{}) (map[string]interface{}, error) {
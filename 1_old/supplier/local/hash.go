@@ -0,0 +1,66 @@
+package local
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+	"github.com/twmb/murmur3"
+)
+
+const HashPlugin = "Hash"
+
+// Hash 插件支持的算法
+const (
+	HashMD5     = "md5"
+	HashSHA1    = "sha1"
+	HashMurmur3 = "murmur3" // 32 位, seed 为 0
+)
+
+type HashOutput struct {
+	Hex string `plugin:"hex"` // 摘要的 16 进制表示
+	// 非负整数, 可用于分桶. md5/sha1 取摘要的前 8 个字节, murmur3 为 32 位结果.
+	Sum int64 `plugin:"sum"`
+}
+
+// NewHashPlugin 计算哈希值.
+// 参数: value(string 或 []byte), algo(string, 常量, md5/sha1/murmur3). 返回值见 HashOutput.
+func NewHashPlugin() supplier.IPlugin {
+	return newTypedPlugin(HashPlugin, Hash, "value", "algo")
+}
+
+func Hash(_ context.Context, value interface{}, algo string) (*HashOutput, error) {
+	data, ok := value.([]byte)
+	if !ok {
+		data = []byte(dtype.ToString(value))
+	}
+
+	var sum []byte
+	switch algo {
+	case HashMD5:
+		digest := md5.Sum(data)
+		sum = digest[:]
+	case HashSHA1:
+		digest := sha1.Sum(data)
+		sum = digest[:]
+	case HashMurmur3:
+		sum = make([]byte, 4)
+		binary.BigEndian.PutUint32(sum, murmur3.Sum32(data))
+		return &HashOutput{
+			Hex: hex.EncodeToString(sum),
+			Sum: int64(binary.BigEndian.Uint32(sum)),
+		}, nil
+	default:
+		return nil, fmt.Errorf("hash algo %s not supported", algo)
+	}
+	return &HashOutput{
+		Hex: hex.EncodeToString(sum),
+		Sum: int64(binary.BigEndian.Uint64(sum[:8]) & math.MaxInt64),
+	}, nil
+}
@@ -0,0 +1,31 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHash(t *testing.T) {
+	testCases := []struct {
+		algo      string
+		value     interface{}
+		expectHex string
+		expectSum int64
+	}{
+		{HashMD5, "hello", "5d41402abc4b2a76b9719d911017c592", 0x5d41402abc4b2a76},
+		{HashMD5, []byte("hello"), "5d41402abc4b2a76b9719d911017c592", 0x5d41402abc4b2a76},
+		{HashSHA1, "hello", "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", 0x2af4c61ddcc5e8a2}, // 最高位被清除
+		{HashMurmur3, "hello", "248bfa47", 0x248bfa47},
+	}
+	for _, tcase := range testCases {
+		out, err := Hash(context.Background(), tcase.value, tcase.algo)
+		assert.NoError(t, err, tcase.algo)
+		assert.Equal(t, tcase.expectHex, out.Hex, tcase.algo)
+		assert.Equal(t, tcase.expectSum, out.Sum, tcase.algo)
+	}
+
+	_, err := Hash(context.Background(), "hello", "sha256")
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package local
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+)
+
+const IPPlugin = "IP"
+
+type IPOutput struct {
+	Valid    bool   `plugin:"valid"`    // ip 是否合法, 不合法时其他返回值都为零值
+	Matched  bool   `plugin:"matched"`  // 是否在任一 cidr 中
+	CIDR     string `plugin:"cidr"`     // 第一个匹配的 cidr
+	Private  bool   `plugin:"private"`  // 是否为内网地址
+	Loopback bool   `plugin:"loopback"` // 是否为回环地址
+}
+
+// NewIPPlugin 判断 ip 是否在 cidr 列表中.
+// 参数: ip(string), cidrs([]string, 常量, 如 ["10.0.0.0/8"]). 返回值见 IPOutput.
+func NewIPPlugin() supplier.IPlugin {
+	return newTypedPlugin(IPPlugin, MatchIP, "ip", "cidrs")
+}
+
+// 解析后的 cidr 列表. key: strings.Join(cidrs, ","), value: []*net.IPNet
+var cidrCache = newLRUCache(cidrCacheSize)
+
+// MatchIP ip 不合法时不返回错误, 因为 ip 一般来自用户输入. cidr 不合法时返回错误.
+func MatchIP(_ context.Context, ip string, cidrs []string) (*IPOutput, error) {
+	nets, err := parseCIDRs(cidrs)
+	if err != nil {
+		return nil, err
+	}
+	out := &IPOutput{}
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return out, nil
+	}
+	out.Valid = true
+	out.Private = parsed.IsPrivate()
+	out.Loopback = parsed.IsLoopback()
+	for i, ipnet := range nets {
+		if ipnet.Contains(parsed) {
+			out.Matched = true
+			out.CIDR = cidrs[i]
+			break
+		}
+	}
+	return out, nil
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	key := strings.Join(cidrs, ",")
+	if nets, ok := cidrCache.Load(key); ok {
+		return nets.([]*net.IPNet), nil
+	}
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets[i] = ipnet
+	}
+	cidrCache.Store(key, nets)
+	return nets, nil
+}
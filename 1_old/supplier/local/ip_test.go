@@ -0,0 +1,32 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchIP(t *testing.T) {
+	cidrs := []string{"10.0.0.0/8", "192.168.1.0/24", "2001:db8::/32"}
+	testCases := []struct {
+		ip     string
+		expect *IPOutput
+	}{
+		{"10.1.2.3", &IPOutput{Valid: true, Matched: true, CIDR: "10.0.0.0/8", Private: true}},
+		{"192.168.1.10", &IPOutput{Valid: true, Matched: true, CIDR: "192.168.1.0/24", Private: true}},
+		{"192.168.2.10", &IPOutput{Valid: true, Private: true}},
+		{"2001:db8::1", &IPOutput{Valid: true, Matched: true, CIDR: "2001:db8::/32"}},
+		{"127.0.0.1", &IPOutput{Valid: true, Loopback: true}},
+		{"8.8.8.8", &IPOutput{Valid: true}},
+		{"not_ip", &IPOutput{}},
+	}
+	for _, tcase := range testCases {
+		out, err := MatchIP(context.Background(), tcase.ip, cidrs)
+		assert.NoError(t, err, tcase.ip)
+		assert.Equal(t, tcase.expect, out, tcase.ip)
+	}
+
+	_, err := MatchIP(context.Background(), "10.1.2.3", []string{"10.0.0.0"})
+	assert.Error(t, err)
+}
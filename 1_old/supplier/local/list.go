@@ -0,0 +1,109 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+)
+
+const (
+	ListLenPlugin      = "ListLen"
+	ListContainsPlugin = "ListContains"
+	ListDedupePlugin   = "ListDedupe"
+)
+
+// NewListLenPlugin 数组长度, nil 的长度为 0.
+// 参数: list(任意数组). 返回值: value(int64).
+func NewListLenPlugin() supplier.IPlugin {
+	return newTypedPlugin(ListLenPlugin, ListLen, "list")
+}
+
+// NewListContainsPlugin 数组是否包含 item. []string/[]int64 会先将 item 转换为元素的类型.
+// 参数: list(任意数组), item. 返回值: value(bool).
+func NewListContainsPlugin() supplier.IPlugin {
+	return newTypedPlugin(ListContainsPlugin, ListContains, "list", "item")
+}
+
+// NewListDedupePlugin 数组去重, 保留第一次出现的元素, 返回值与 list 类型相同.
+// 参数: list(任意数组). 返回值: value.
+func NewListDedupePlugin() supplier.IPlugin {
+	return newTypedPlugin(ListDedupePlugin, ListDedupe, "list")
+}
+
+func ListLen(_ context.Context, list interface{}) (*Int64Value, error) {
+	rlist, err := listValue(list)
+	if err != nil {
+		return nil, err
+	}
+	return &Int64Value{Value: int64(rlist.Len())}, nil
+}
+
+func ListContains(_ context.Context, list interface{}, item interface{}) (*BoolValue, error) {
+	var err error
+	switch list.(type) {
+	case []string:
+		item = dtype.ToString(item)
+	case []int64:
+		if item, err = dtype.Convert(item, dtype.Int64); err != nil {
+			return nil, err
+		}
+	}
+	rlist, err := listValue(list)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < rlist.Len(); i++ {
+		if reflect.DeepEqual(rlist.Index(i).Interface(), item) {
+			return &BoolValue{Value: true}, nil
+		}
+	}
+	return &BoolValue{Value: false}, nil
+}
+
+func ListDedupe(_ context.Context, list interface{}) (*AnyValue, error) {
+	rlist, err := listValue(list)
+	if err != nil || list == nil {
+		return &AnyValue{Value: list}, err
+	}
+	out := reflect.MakeSlice(rlist.Type(), 0, rlist.Len())
+	seen := make(map[interface{}]struct{}, rlist.Len())
+	for i := 0; i < rlist.Len(); i++ {
+		elem := rlist.Index(i)
+		value := elem.Interface()
+		if value != nil && !reflect.TypeOf(value).Comparable() {
+			// 不可比较的元素(如 map)逐个比较
+			if !containsValue(out, value) {
+				out = reflect.Append(out, elem)
+			}
+			continue
+		}
+		if _, ok := seen[value]; !ok {
+			seen[value] = struct{}{}
+			out = reflect.Append(out, elem)
+		}
+	}
+	return &AnyValue{Value: out.Interface()}, nil
+}
+
+func listValue(list interface{}) (reflect.Value, error) {
+	if list == nil {
+		return reflect.ValueOf([]interface{}{}), nil
+	}
+	rlist := reflect.ValueOf(list)
+	if rlist.Kind() != reflect.Slice {
+		return reflect.Value{}, fmt.Errorf("list param must be array, got %T", list)
+	}
+	return rlist, nil
+}
+
+func containsValue(rlist reflect.Value, value interface{}) bool {
+	for i := 0; i < rlist.Len(); i++ {
+		if reflect.DeepEqual(rlist.Index(i).Interface(), value) {
+			return true
+		}
+	}
+	return false
+}
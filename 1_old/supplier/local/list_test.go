@@ -0,0 +1,57 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+
+	length, err := ListLen(ctx, []string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), length.Value)
+	length, err = ListLen(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), length.Value)
+	_, err = ListLen(ctx, "abc")
+	assert.Error(t, err)
+
+	containsCases := []struct {
+		list   interface{}
+		item   interface{}
+		expect bool
+	}{
+		{[]string{"a", "1"}, 1, true},
+		{[]string{"a", "1"}, "b", false},
+		{[]int64{1, 2}, "2", true},
+		{[]int64{1, 2}, 3, false},
+		{[]interface{}{"a", int64(1)}, int64(1), true},
+		{nil, "a", false},
+	}
+	for _, tcase := range containsCases {
+		contains, err := ListContains(ctx, tcase.list, tcase.item)
+		assert.NoError(t, err)
+		assert.Equal(t, tcase.expect, contains.Value, tcase.list)
+	}
+	_, err = ListContains(ctx, []int64{1}, "a")
+	assert.Error(t, err)
+
+	dedupeCases := []struct {
+		list   interface{}
+		expect interface{}
+	}{
+		{[]string{"b", "a", "b"}, []string{"b", "a"}},
+		{[]int64{1, 1, 2}, []int64{1, 2}},
+		{[]interface{}{map[string]interface{}{"a": 1}, "a", map[string]interface{}{"a": 1}, nil, nil},
+			[]interface{}{map[string]interface{}{"a": 1}, "a", nil}},
+		{nil, nil},
+	}
+	for _, tcase := range dedupeCases {
+		dedupe, err := ListDedupe(ctx, tcase.list)
+		assert.NoError(t, err)
+		assert.Equal(t, tcase.expect, dedupe.Value)
+	}
+}
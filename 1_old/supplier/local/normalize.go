@@ -0,0 +1,49 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+const NormalizePlugin = "Normalize"
+
+// Normalize 插件支持的操作, 按照传入的顺序执行.
+const (
+	NormalizeWidth = "width" // 全角转半角, 如 "ＡＢＣ１" -> "ABC1"
+	NormalizeNFKC  = "nfkc"  // unicode NFKC 规范化
+	NormalizeLower = "lower"
+	NormalizeUpper = "upper"
+	NormalizeTrim  = "trim"  // 去除首尾空白
+	NormalizeSpace = "space" // 连续空白合并为一个空格, 并去除首尾空白
+)
+
+var normalizers = map[string]func(string) string{
+	NormalizeWidth: width.Fold.String,
+	NormalizeNFKC:  norm.NFKC.String,
+	NormalizeLower: strings.ToLower,
+	NormalizeUpper: strings.ToUpper,
+	NormalizeTrim:  strings.TrimSpace,
+	NormalizeSpace: func(s string) string { return strings.Join(strings.Fields(s), " ") },
+}
+
+// NewNormalizePlugin 字符串规范化.
+// 参数: value(string), ops([]string, 常量, 如 ["width", "lower", "trim"]). 返回值: value(string).
+func NewNormalizePlugin() supplier.IPlugin {
+	return newTypedPlugin(NormalizePlugin, Normalize, "value", "ops")
+}
+
+func Normalize(_ context.Context, value string, ops []string) (*StringValue, error) {
+	for _, op := range ops {
+		normalizer, ok := normalizers[op]
+		if !ok {
+			return nil, fmt.Errorf("normalize op %s not supported", op)
+		}
+		value = normalizer(value)
+	}
+	return &StringValue{Value: value}, nil
+}
@@ -0,0 +1,29 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		value  string
+		ops    []string
+		expect string
+	}{
+		{"  ＡＢＣ １２３ ", []string{NormalizeWidth, NormalizeLower, NormalizeTrim}, "abc 123"},
+		{"ｈｅｌｌｏ", []string{NormalizeNFKC, NormalizeUpper}, "HELLO"},
+		{" a \t b\n\nc ", []string{NormalizeSpace}, "a b c"},
+		{"Abc", []string{}, "Abc"},
+	}
+	for _, tcase := range testCases {
+		out, err := Normalize(context.Background(), tcase.value, tcase.ops)
+		assert.NoError(t, err, tcase.value)
+		assert.Equal(t, tcase.expect, out.Value, tcase.value)
+	}
+
+	_, err := Normalize(context.Background(), "abc", []string{"reverse"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,63 @@
+package local
+
+import (
+	"context"
+	"regexp"
+
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+)
+
+const RegexPlugin = "Regex"
+
+type RegexOutput struct {
+	Matched bool     `plugin:"matched"` // 是否匹配
+	Match   string   `plugin:"match"`   // 第一个匹配的完整文本
+	Groups  []string `plugin:"groups"`  // 第一个匹配的捕获组, 不含完整匹配
+	// 命名捕获组, 如 (?P<uid>\d+). key: 组名
+	Named map[string]interface{} `plugin:"named"`
+}
+
+// NewRegexPlugin 正则匹配和捕获.
+// 参数: text(string), pattern(string, 一般为常量). 返回值见 RegexOutput.
+func NewRegexPlugin() supplier.IPlugin {
+	return newTypedPlugin(RegexPlugin, Regex, "text", "pattern")
+}
+
+// 编译后的正则. key: pattern, value: *regexp.Regexp
+var regexCache = newLRUCache(regexCacheSize)
+
+func Regex(_ context.Context, text string, pattern string) (*RegexOutput, error) {
+	re, err := compileRegex(pattern)
+	if err != nil {
+		return nil, err
+	}
+	out := &RegexOutput{
+		Groups: []string{},
+		Named:  map[string]interface{}{},
+	}
+	submatch := re.FindStringSubmatch(text)
+	if submatch == nil {
+		return out, nil
+	}
+	out.Matched = true
+	out.Match = submatch[0]
+	out.Groups = submatch[1:]
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			out.Named[name] = submatch[i]
+		}
+	}
+	return out, nil
+}
+
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(pattern, re)
+	return re, nil
+}
@@ -0,0 +1,30 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegex(t *testing.T) {
+	out, err := Regex(context.Background(), "uid=123&name=abc", `uid=(?P<uid>\d+)&name=(\w+)`)
+	assert.NoError(t, err)
+	assert.True(t, out.Matched)
+	assert.Equal(t, "uid=123&name=abc", out.Match)
+	assert.Equal(t, []string{"123", "abc"}, out.Groups)
+	assert.Equal(t, map[string]interface{}{"uid": "123"}, out.Named)
+
+	out, err = Regex(context.Background(), "name=abc", `uid=(?P<uid>\d+)`)
+	assert.NoError(t, err)
+	assert.False(t, out.Matched)
+	assert.Equal(t, []string{}, out.Groups)
+
+	_, err = Regex(context.Background(), "abc", `(`)
+	assert.Error(t, err)
+
+	result, err := NewRegexPlugin().Call(context.Background(), "abc", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, true, result["matched"])
+	assert.Equal(t, "b", result["match"])
+}
@@ -7,11 +7,59 @@ import (
 
 const SupplierName = "local"
 
+// 只有一个返回值的插件, 返回值的 key.
+const ValueOutput = "value"
+
 func NewSupplier() *supplier.DefaultSupplier {
 	localSupplier := supplier.NewDefaultSupplier(SupplierName,
 		[]supplier.IPlugin{
 			NewDoSomethingPlugin(),
+			NewExtractPlugin(),
+			NewExtractAsPlugin(),
+			NewForwardPlugin(),
+			NewExprPlugin(),
+			NewRegexPlugin(),
+			NewHashPlugin(),
+			NewURLPlugin(),
+			NewIPPlugin(),
+			NewParseTimePlugin(),
+			NewFormatTimePlugin(),
+			NewTimeSincePlugin(),
+			NewNormalizePlugin(),
+			NewListLenPlugin(),
+			NewListContainsPlugin(),
+			NewListDedupePlugin(),
 		})
 
 	return localSupplier
 }
+
+// 只有一个返回值的插件的返回值, key 为 ValueOutput.
+type (
+	StringValue struct {
+		Value string `plugin:"value"`
+	}
+	Int64Value struct {
+		Value int64 `plugin:"value"`
+	}
+	BoolValue struct {
+		Value bool `plugin:"value"`
+	}
+	AnyValue struct {
+		Value interface{} `plugin:"value"`
+	}
+)
+
+// 使用 supplier.NewTypedPlugin 创建插件, 并按照 paramNames 为参数命名.
+// 本地插件的函数签名在编码时确定, 签名错误时直接 panic.
+func newTypedPlugin(name string, fn interface{}, paramNames ...string) supplier.IPlugin {
+	plugin, err := supplier.NewTypedPlugin(name, fn)
+	if err != nil {
+		panic(err)
+	}
+	schema := plugin.Describe()
+	for i := range schema.Params {
+		schema.Params[i].Name = paramNames[i]
+	}
+	return supplier.NewDescribedPlugin(plugin, schema)
+}
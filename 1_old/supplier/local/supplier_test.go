@@ -0,0 +1,21 @@
+package local
+
+import (
+	"testing"
+
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSupplier(t *testing.T) {
+	for name, schema := range supplier.DescribePlugins(NewSupplier()) {
+		if name == "DoSomething" {
+			continue
+		}
+		if assert.NotNil(t, schema, name) {
+			for _, param := range schema.Params {
+				assert.NotEmpty(t, param.Name, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package local
+
+import (
+	"context"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+)
+
+const (
+	ParseTimePlugin  = "ParseTime"
+	FormatTimePlugin = "FormatTime"
+	TimeSincePlugin  = "TimeSince"
+)
+
+// 常用时间格式的别名, 其他值按照 go 的 layout 处理.
+var timeLayouts = map[string]string{
+	"rfc3339":  time.RFC3339,
+	"datetime": "2006-01-02 15:04:05",
+	"date":     "2006-01-02",
+}
+
+// 测试时替换
+var now = time.Now
+
+type TimeOutput struct {
+	Unix      int64 `plugin:"unix"`       // 秒
+	UnixMilli int64 `plugin:"unix_milli"` // 毫秒
+	Year      int64 `plugin:"year"`
+	Month     int64 `plugin:"month"`   // 1-12
+	Day       int64 `plugin:"day"`     // 1-31
+	Hour      int64 `plugin:"hour"`    // 0-23
+	Weekday   int64 `plugin:"weekday"` // 0-6, 0 为周日
+}
+
+type TimeSinceOutput struct {
+	Seconds int64 `plugin:"seconds"`
+	Minutes int64 `plugin:"minutes"`
+	Hours   int64 `plugin:"hours"`
+	Days    int64 `plugin:"days"`
+}
+
+// NewParseTimePlugin 解析时间字符串.
+// 参数: value(string), layout(string, 常量, go layout 或 rfc3339/datetime/date),
+// location(string, 常量, 如 Asia/Shanghai, 为空时使用本地时区). 返回值见 TimeOutput.
+func NewParseTimePlugin() supplier.IPlugin {
+	return newTypedPlugin(ParseTimePlugin, ParseTime, "value", "layout", "location")
+}
+
+// NewFormatTimePlugin 格式化时间戳.
+// 参数: timestamp(int64, 秒), layout(string, 常量), location(string, 常量). 返回值: value(string).
+func NewFormatTimePlugin() supplier.IPlugin {
+	return newTypedPlugin(FormatTimePlugin, FormatTime, "timestamp", "layout", "location")
+}
+
+// NewTimeSincePlugin 计算时间戳距今的时长, 向下取整, 时间戳在未来时为负数.
+// 参数: timestamp(int64, 秒). 返回值见 TimeSinceOutput.
+func NewTimeSincePlugin() supplier.IPlugin {
+	return newTypedPlugin(TimeSincePlugin, TimeSince, "timestamp")
+}
+
+func ParseTime(_ context.Context, value string, layout string, location string) (*TimeOutput, error) {
+	loc, err := loadLocation(location)
+	if err != nil {
+		return nil, err
+	}
+	t, err := time.ParseInLocation(timeLayout(layout), value, loc)
+	if err != nil {
+		return nil, err
+	}
+	return &TimeOutput{
+		Unix:      t.Unix(),
+		UnixMilli: t.UnixNano() / int64(time.Millisecond),
+		Year:      int64(t.Year()),
+		Month:     int64(t.Month()),
+		Day:       int64(t.Day()),
+		Hour:      int64(t.Hour()),
+		Weekday:   int64(t.Weekday()),
+	}, nil
+}
+
+func FormatTime(_ context.Context, timestamp int64, layout string, location string) (*StringValue, error) {
+	loc, err := loadLocation(location)
+	if err != nil {
+		return nil, err
+	}
+	return &StringValue{Value: time.Unix(timestamp, 0).In(loc).Format(timeLayout(layout))}, nil
+}
+
+func TimeSince(_ context.Context, timestamp int64) (*TimeSinceOutput, error) {
+	seconds := now().Unix() - timestamp
+	return &TimeSinceOutput{
+		Seconds: seconds,
+		Minutes: floorDiv(seconds, 60),
+		Hours:   floorDiv(seconds, 3600),
+		Days:    floorDiv(seconds, 86400),
+	}, nil
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+func timeLayout(layout string) string {
+	if alias, ok := timeLayouts[layout]; ok {
+		return alias
+	}
+	return layout
+}
+
+// key: location name, value: *time.Location
+var locationCache = newLRUCache(locationCacheSize)
+
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locationCache.Store(name, loc)
+	return loc, nil
+}
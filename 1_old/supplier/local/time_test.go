@@ -0,0 +1,51 @@
+package local
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTime(t *testing.T) {
+	out, err := ParseTime(context.Background(), "2022-12-01 08:30:00", "datetime", "UTC")
+	assert.NoError(t, err)
+	assert.Equal(t, &TimeOutput{
+		Unix:      1669883400,
+		UnixMilli: 1669883400000,
+		Year:      2022,
+		Month:     12,
+		Day:       1,
+		Hour:      8,
+		Weekday:   4,
+	}, out)
+
+	out, err = ParseTime(context.Background(), "2022-12-01T08:30:00+08:00", "rfc3339", "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1669854600), out.Unix)
+
+	_, err = ParseTime(context.Background(), "2022/12/01", "date", "UTC")
+	assert.Error(t, err)
+	_, err = ParseTime(context.Background(), "2022-12-01", "date", "Not/Exist")
+	assert.Error(t, err)
+}
+
+func TestFormatTime(t *testing.T) {
+	out, err := FormatTime(context.Background(), 1669883400, "2006-01-02T15:04", "UTC")
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-12-01T08:30", out.Value)
+}
+
+func TestTimeSince(t *testing.T) {
+	defer func(fn func() time.Time) { now = fn }(now)
+	now = func() time.Time { return time.Unix(1669883400, 0) }
+
+	out, err := TimeSince(context.Background(), 1669883400-2*86400-3601)
+	assert.NoError(t, err)
+	assert.Equal(t, &TimeSinceOutput{Seconds: 176401, Minutes: 2940, Hours: 49, Days: 2}, out)
+
+	out, err = TimeSince(context.Background(), 1669883400+30)
+	assert.NoError(t, err)
+	assert.Equal(t, &TimeSinceOutput{Seconds: -30, Minutes: -1, Hours: -1, Days: -1}, out)
+}
@@ -0,0 +1,50 @@
+package local
+
+import (
+	"context"
+	"net/url"
+
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+)
+
+const URLPlugin = "URL"
+
+type URLOutput struct {
+	Scheme   string `plugin:"scheme"`
+	Host     string `plugin:"host"`     // 包含端口
+	Hostname string `plugin:"hostname"` // 不包含端口
+	Port     string `plugin:"port"`
+	Path     string `plugin:"path"`
+	Query    string `plugin:"query"` // 原始的 query string
+	Fragment string `plugin:"fragment"`
+	// query 参数, 同名参数只取第一个值. key: 参数名, value: string
+	Params map[string]interface{} `plugin:"params"`
+}
+
+// NewURLPlugin 解析 URL.
+// 参数: url(string). 返回值见 URLOutput.
+func NewURLPlugin() supplier.IPlugin {
+	return newTypedPlugin(URLPlugin, ParseURL, "url")
+}
+
+func ParseURL(_ context.Context, rawURL string) (*URLOutput, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	params := make(map[string]interface{}, len(query))
+	for key := range query {
+		params[key] = query.Get(key)
+	}
+	return &URLOutput{
+		Scheme:   u.Scheme,
+		Host:     u.Host,
+		Hostname: u.Hostname(),
+		Port:     u.Port(),
+		Path:     u.Path,
+		Query:    u.RawQuery,
+		Fragment: u.Fragment,
+		Params:   params,
+	}, nil
+}
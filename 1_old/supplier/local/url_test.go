@@ -0,0 +1,26 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseURL(t *testing.T) {
+	out, err := ParseURL(context.Background(), "https://www.zhihu.com:8080/question/1?a=1&b=2&a=3#answer")
+	assert.NoError(t, err)
+	assert.Equal(t, &URLOutput{
+		Scheme:   "https",
+		Host:     "www.zhihu.com:8080",
+		Hostname: "www.zhihu.com",
+		Port:     "8080",
+		Path:     "/question/1",
+		Query:    "a=1&b=2&a=3",
+		Fragment: "answer",
+		Params:   map[string]interface{}{"a": "1", "b": "2"},
+	}, out)
+
+	_, err = ParseURL(context.Background(), "http://[::1")
+	assert.Error(t, err)
+}
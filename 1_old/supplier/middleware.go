@@ -0,0 +1,60 @@
+package supplier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/constant"
+	"git.in.zhihu.com/antispam/datasupply/log"
+	"git.in.zhihu.com/antispam/datasupply/statsd"
+	"git.in.zhihu.com/antispam/datasupply/utils"
+)
+
+// 中间件作用于插件调用的外层, 签名与 ISupplier.Supply 一致, 适用于鉴权, 链路追踪, 配额等横切逻辑.
+type Handler = func(ctx context.Context, pluginName string, params []interface{}) (map[string]interface{}, error)
+type Middleware = func(next Handler) Handler
+
+// ScopeMiddleware 使中间件仅作用于指定插件, 其他插件直接调用 next.
+func ScopeMiddleware(mw Middleware, pluginNames ...string) Middleware {
+	selector := newPluginSelector(pluginNames)
+	return func(next Handler) Handler {
+		scoped := mw(next)
+		return func(ctx context.Context, pluginName string, params []interface{}) (map[string]interface{}, error) {
+			if selector.match(pluginName) {
+				return scoped(ctx, pluginName, params)
+			}
+			return next(ctx, pluginName, params)
+		}
+	}
+}
+
+// 打点中间件, 记录插件调用次数, 失败次数和耗时.
+func StatsdMiddleware(statd statsd.IStatsd, supplierName string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, pluginName string, params []interface{}) (map[string]interface{}, error) {
+			name := supplierName + "." + pluginName
+			statd.Increment(fmt.Sprintf(constant.PluginCallCount, name))
+			defer statd.TimingUtilNow(fmt.Sprintf(constant.PluginCallSpeed, name), time.Now())
+			out, err := next(ctx, pluginName, params)
+			if err != nil {
+				statd.Increment(fmt.Sprintf(constant.PluginCallError, name))
+			}
+			return out, err
+		}
+	}
+}
+
+// 日志中间件, 记录 params 和返回值
+func LogMiddleware(logger log.ILog, supplierName string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, pluginName string, params []interface{}) (map[string]interface{}, error) {
+			logger.Infof(ctx, "supplier [%s] plugin [%s] start, params: [%s]",
+				supplierName, pluginName, utils.StructToString(params))
+			out, err := next(ctx, pluginName, params)
+			logger.Infof(ctx, "supplier [%s] plugin [%s] end, result: [%s], error: [%v]",
+				supplierName, pluginName, utils.StructToString(out), err)
+			return out, err
+		}
+	}
+}
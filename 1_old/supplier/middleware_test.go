@@ -0,0 +1,91 @@
+package supplier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"git.in.zhihu.com/antispam/datasupply/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupplierUse(t *testing.T) {
+	echo := func(name string) IPlugin {
+		return NewDefaultPlugin(name, func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"out": args[0]}, nil
+		})
+	}
+	trace := func(tag string, trace *[]string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, pluginName string, params []interface{}) (map[string]interface{}, error) {
+				*trace = append(*trace, tag+":"+pluginName)
+				return next(ctx, pluginName, params)
+			}
+		}
+	}
+
+	t.Run("order", func(t *testing.T) {
+		calls := []string{}
+		supplier := NewDefaultSupplier("tests", []IPlugin{echo("a")})
+		supplier.Use(trace("1", &calls), trace("2", &calls))
+		supplier.Use(trace("3", &calls))
+		out, err := supplier.Supply(context.Background(), "a", []interface{}{"x"})
+		assert.NoError(t, err)
+		assert.Equal(t, "x", out["out"])
+		assert.Equal(t, []string{"1:a", "2:a", "3:a"}, calls)
+	})
+
+	t.Run("scope", func(t *testing.T) {
+		calls := []string{}
+		supplier := NewDefaultSupplier("tests", []IPlugin{echo("a"), echo("b")})
+		supplier.Use(ScopeMiddleware(trace("scoped", &calls), "b"))
+		_, _ = supplier.Supply(context.Background(), "a", []interface{}{"x"})
+		_, _ = supplier.Supply(context.Background(), "b", []interface{}{"x"})
+		assert.Equal(t, []string{"scoped:b"}, calls)
+	})
+
+	t.Run("short_circuit", func(t *testing.T) {
+		denied := errors.New("denied")
+		supplier := NewDefaultSupplier("tests", []IPlugin{echo("a")})
+		supplier.Use(func(next Handler) Handler {
+			return func(ctx context.Context, pluginName string, params []interface{}) (map[string]interface{}, error) {
+				return map[string]interface{}{}, denied
+			}
+		})
+		_, err := supplier.Supply(context.Background(), "a", []interface{}{"x"})
+		assert.Equal(t, denied, err)
+	})
+
+	// 运行中添加中间件不影响并发的 Supply
+	t.Run("concurrent_use", func(t *testing.T) {
+		supplier := NewDefaultSupplier("tests", []IPlugin{echo("a")})
+		wg := sync.WaitGroup{}
+		for i := 0; i < 4; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				supplier.Use(func(next Handler) Handler { return next })
+			}()
+			go func() {
+				defer wg.Done()
+				out, err := supplier.Supply(context.Background(), "a", []interface{}{"x"})
+				assert.NoError(t, err)
+				assert.Equal(t, "x", out["out"])
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("statsd", func(t *testing.T) {
+		counter := newCountStatsd()
+		supplier := NewDefaultSupplier("tests", []IPlugin{echo("a")})
+		supplier.Use(StatsdMiddleware(counter, "tests"))
+		_, _ = supplier.Supply(context.Background(), "a", []interface{}{"x"})
+		_, err := supplier.Supply(context.Background(), "missing", []interface{}{"x"})
+		assert.True(t, errors.Is(err, constant.NotFoundError))
+		assert.Equal(t, int64(1), counter.Get(fmt.Sprintf(constant.PluginCallCount, "tests.a")))
+		assert.Equal(t, int64(1), counter.Get(fmt.Sprintf(constant.PluginCallError, "tests.missing")))
+	})
+}
@@ -104,3 +104,112 @@ func (mr *MockISupplierMockRecorder) Supply(ctx, pluginName, params interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Supply", reflect.TypeOf((*MockISupplier)(nil).Supply), ctx, pluginName, params)
 }
+
+// MockIMiddlewareSupplier is a mock of IMiddlewareSupplier interface.
+type MockIMiddlewareSupplier struct {
+	ctrl     *gomock.Controller
+	recorder *MockIMiddlewareSupplierMockRecorder
+}
+
+// MockIMiddlewareSupplierMockRecorder is the mock recorder for MockIMiddlewareSupplier.
+type MockIMiddlewareSupplierMockRecorder struct {
+	mock *MockIMiddlewareSupplier
+}
+
+// NewMockIMiddlewareSupplier creates a new mock instance.
+func NewMockIMiddlewareSupplier(ctrl *gomock.Controller) *MockIMiddlewareSupplier {
+	mock := &MockIMiddlewareSupplier{ctrl: ctrl}
+	mock.recorder = &MockIMiddlewareSupplierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIMiddlewareSupplier) EXPECT() *MockIMiddlewareSupplierMockRecorder {
+	return m.recorder
+}
+
+// GetAllPlugin mocks base method.
+func (m *MockIMiddlewareSupplier) GetAllPlugin() map[string]supplier.IPlugin {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllPlugin")
+	ret0, _ := ret[0].(map[string]supplier.IPlugin)
+	return ret0
+}
+
+// GetAllPlugin indicates an expected call of GetAllPlugin.
+func (mr *MockIMiddlewareSupplierMockRecorder) GetAllPlugin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPlugin", reflect.TypeOf((*MockIMiddlewareSupplier)(nil).GetAllPlugin))
+}
+
+// GetName mocks base method.
+func (m *MockIMiddlewareSupplier) GetName() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetName")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetName indicates an expected call of GetName.
+func (mr *MockIMiddlewareSupplierMockRecorder) GetName() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetName", reflect.TypeOf((*MockIMiddlewareSupplier)(nil).GetName))
+}
+
+// GetPlugin mocks base method.
+func (m *MockIMiddlewareSupplier) GetPlugin(pluginName string) (supplier.IPlugin, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPlugin", pluginName)
+	ret0, _ := ret[0].(supplier.IPlugin)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetPlugin indicates an expected call of GetPlugin.
+func (mr *MockIMiddlewareSupplierMockRecorder) GetPlugin(pluginName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPlugin", reflect.TypeOf((*MockIMiddlewareSupplier)(nil).GetPlugin), pluginName)
+}
+
+// RegisterPlugin mocks base method.
+func (m *MockIMiddlewareSupplier) RegisterPlugin(arg0 supplier.IPlugin) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RegisterPlugin", arg0)
+}
+
+// RegisterPlugin indicates an expected call of RegisterPlugin.
+func (mr *MockIMiddlewareSupplierMockRecorder) RegisterPlugin(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterPlugin", reflect.TypeOf((*MockIMiddlewareSupplier)(nil).RegisterPlugin), arg0)
+}
+
+// Supply mocks base method.
+func (m *MockIMiddlewareSupplier) Supply(ctx context.Context, pluginName string, params []interface{}) (map[string]interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Supply", ctx, pluginName, params)
+	ret0, _ := ret[0].(map[string]interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Supply indicates an expected call of Supply.
+func (mr *MockIMiddlewareSupplierMockRecorder) Supply(ctx, pluginName, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Supply", reflect.TypeOf((*MockIMiddlewareSupplier)(nil).Supply), ctx, pluginName, params)
+}
+
+// Use mocks base method.
+func (m *MockIMiddlewareSupplier) Use(arg0 ...supplier.Middleware) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range arg0 {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Use", varargs...)
+}
+
+// Use indicates an expected call of Use.
+func (mr *MockIMiddlewareSupplierMockRecorder) Use(arg0 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockIMiddlewareSupplier)(nil).Use), arg0...)
+}
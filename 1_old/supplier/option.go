@@ -1,9 +1,110 @@
 package supplier
 
-type Option func(ISupplier)
+import "time"
 
-// func SetTimeOut(ttl time.Duration) Option {
-// 	return func(o *options) {
-// 		o.ttl = ttl
-// 	}
-// }
+type options struct {
+	pluginWrappers      []pluginWrapper
+	singleflight        *pluginSelector // 为空时不开启
+	singleflightTimeout time.Duration
+}
+
+type Option func(*options)
+
+// 按插件名称选择插件, 名称为空时选择全部插件.
+type pluginSelector struct {
+	pluginNames map[string]struct{}
+}
+
+func newPluginSelector(pluginNames []string) *pluginSelector {
+	names := make(map[string]struct{}, len(pluginNames))
+	for _, name := range pluginNames {
+		names[name] = struct{}{}
+	}
+	return &pluginSelector{
+		pluginNames: names,
+	}
+}
+
+func (selector *pluginSelector) match(pluginName string) bool {
+	if selector == nil {
+		return false
+	}
+	if len(selector.pluginNames) == 0 {
+		return true
+	}
+	_, ok := selector.pluginNames[pluginName]
+	return ok
+}
+
+// pluginWrapper 在插件注册时对插件进行包装, 如对冲, 熔断等.
+type pluginWrapper struct {
+	*pluginSelector
+	wrap func(IPlugin) IPlugin
+}
+
+func newPluginWrapper(wrap func(IPlugin) IPlugin, pluginNames []string) pluginWrapper {
+	return pluginWrapper{
+		pluginSelector: newPluginSelector(pluginNames),
+		wrap:           wrap,
+	}
+}
+
+// 对指定插件开启对冲请求, pluginNames 为空时作用于全部插件.
+func SetHedgePolicy(policy *HedgePolicy, pluginNames ...string) Option {
+	return func(o *options) {
+		o.pluginWrappers = append(o.pluginWrappers, newPluginWrapper(
+			func(plugin IPlugin) IPlugin {
+				return NewHedgedPlugin(plugin, policy)
+			}, pluginNames))
+	}
+}
+
+// 对指定插件开启熔断, pluginNames 为空时作用于全部插件.
+func SetCircuitBreaker(cfg *BreakerConfig, pluginNames ...string) Option {
+	return func(o *options) {
+		o.pluginWrappers = append(o.pluginWrappers, newPluginWrapper(
+			func(plugin IPlugin) IPlugin {
+				return NewCircuitBreakerPlugin(plugin, cfg)
+			}, pluginNames))
+	}
+}
+
+// 对指定插件开启 singleflight, 相同插件+相同参数的并发调用只执行一次. pluginNames 为空时作用于全部插件.
+func SetSingleflight(pluginNames ...string) Option {
+	return func(o *options) {
+		o.singleflight = newPluginSelector(pluginNames)
+	}
+}
+
+// 设置 singleflight 共享调用的超时时间, 默认为 DefaultSingleflightTimeout.
+// 共享调用不受调用方 ctx 的超时和取消影响, 由该超时时间保证调用最终结束.
+func SetSingleflightTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.singleflightTimeout = timeout
+	}
+}
+
+// 对指定插件开启结果缓存, pluginNames 为空时作用于全部插件. 未指定 policy.Cache 时, 这些插件共享一个内存 LRU.
+func SetCachePolicy(policy *CachePolicy, pluginNames ...string) Option {
+	return func(o *options) {
+		_policy := CachePolicy{}
+		if policy != nil {
+			_policy = *policy
+		}
+		_policy.LoadDefault()
+		o.pluginWrappers = append(o.pluginWrappers, newPluginWrapper(
+			func(plugin IPlugin) IPlugin {
+				return NewCachedPlugin(plugin, &_policy)
+			}, pluginNames))
+	}
+}
+
+// 对指定插件开启限流, 每个插件使用独立的令牌桶. pluginNames 为空时作用于全部插件.
+func SetRateLimit(policy *RateLimitPolicy, pluginNames ...string) Option {
+	return func(o *options) {
+		o.pluginWrappers = append(o.pluginWrappers, newPluginWrapper(
+			func(plugin IPlugin) IPlugin {
+				return NewRateLimitedPlugin(plugin, policy)
+			}, pluginNames))
+	}
+}
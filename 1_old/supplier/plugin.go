@@ -8,10 +8,16 @@ import (
 type (
 	IPlugin interface {
 		GetName() string
-		// TODO [optimize] 如果我直接使用 reflect.Method 呢?
+		// 需要按函数签名声明参数类型时, 可以使用 NewTypedPlugin.
 		Call(ctx context.Context, args ...interface{}) (map[string]interface{}, error)
 	}
 	PluginFunc func(ctx context.Context, args ...interface{}) (map[string]interface{}, error)
+
+	// 包装其他插件的插件(如对冲, 熔断), 通过 Unwrap 获取被包装的插件.
+	IWrappedPlugin interface {
+		IPlugin
+		Unwrap() IPlugin
+	}
 )
 
 // type Plugin = plugin.Plugin
@@ -37,3 +43,18 @@ func (p *DefaultPlugin) GetName() string {
 func (p *DefaultPlugin) Call(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
 	return p.fn(ctx, args...)
 }
+
+// FindPlugin 沿包装链由外向内查找第一个满足 match 的插件.
+func FindPlugin(plugin IPlugin, match func(IPlugin) bool) (IPlugin, bool) {
+	for plugin != nil {
+		if match(plugin) {
+			return plugin, true
+		}
+		wrapped, ok := plugin.(IWrappedPlugin)
+		if !ok {
+			break
+		}
+		plugin = wrapped.Unwrap()
+	}
+	return nil, false
+}
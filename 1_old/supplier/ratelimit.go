@@ -0,0 +1,176 @@
+package supplier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/constant"
+)
+
+// 触发限流时的处理方式. wait: 等待令牌, 等待时间不超过 ctx 的 deadline; shed: 直接丢弃.
+type RateLimitMode int
+
+const (
+	RateLimitWait RateLimitMode = iota
+	RateLimitShed
+)
+
+var RateLimitModeNames = []string{
+	RateLimitWait: "wait",
+	RateLimitShed: "shed",
+}
+
+func (s RateLimitMode) String() string {
+	if int(s) < len(RateLimitModeNames) {
+		return RateLimitModeNames[s]
+	}
+	return "rate_limit_mode_" + strconv.Itoa(int(s))
+}
+
+func (s RateLimitMode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *RateLimitMode) UnmarshalJSON(b []byte) error {
+	str := ""
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	for mode, name := range RateLimitModeNames {
+		if name == str {
+			*s = RateLimitMode(mode)
+			return nil
+		}
+	}
+	return errors.New("unknown rate limit mode " + str)
+}
+
+// RateLimitPolicy 令牌桶限流配置.
+type RateLimitPolicy struct {
+	QPS   float64       `json:"qps"`   // 每秒生成的令牌数, <=0 表示不限流
+	Burst int           `json:"burst"` // 令牌桶容量, 默认为 1
+	Mode  RateLimitMode `json:"mode"`  // 令牌不足时的处理方式
+}
+
+func (policy *RateLimitPolicy) LoadDefault() {
+	if policy.Burst <= 0 {
+		policy.Burst = 1
+	}
+}
+
+// RateLimitedPlugin 为插件添加限流能力. 被限流的调用返回 constant.RateLimitedError.
+type RateLimitedPlugin struct {
+	plugin IPlugin
+	policy RateLimitPolicy
+	bucket *tokenBucket
+}
+
+var _ IWrappedPlugin = new(RateLimitedPlugin)
+
+func NewRateLimitedPlugin(plugin IPlugin, _policy *RateLimitPolicy) *RateLimitedPlugin {
+	policy := RateLimitPolicy{}
+	if _policy != nil {
+		policy = *_policy
+	}
+	policy.LoadDefault()
+	return &RateLimitedPlugin{
+		plugin: plugin,
+		policy: policy,
+		bucket: newTokenBucket(policy.QPS, policy.Burst),
+	}
+}
+
+func (p *RateLimitedPlugin) GetName() string {
+	return p.plugin.GetName()
+}
+
+// Unwrap 返回被包装的插件.
+func (p *RateLimitedPlugin) Unwrap() IPlugin {
+	return p.plugin
+}
+
+func (p *RateLimitedPlugin) Call(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+	if p.policy.QPS <= 0 {
+		return p.plugin.Call(ctx, args...)
+	}
+	// 等待时间不能超过 ctx 的 deadline, 否则等到令牌也来不及调用
+	var maxWait time.Duration
+	if p.policy.Mode == RateLimitWait {
+		maxWait = -1
+		if deadline, ok := ctx.Deadline(); ok {
+			maxWait = time.Until(deadline)
+		}
+	}
+	wait, ok := p.bucket.Reserve(maxWait)
+	if !ok {
+		return map[string]interface{}{}, constant.RateLimitedError
+	}
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			p.bucket.Cancel()
+			return map[string]interface{}{}, constant.RateLimitedError
+		case <-timer.C:
+		}
+	}
+	return p.plugin.Call(ctx, args...)
+}
+
+// 令牌桶. 令牌可以透支, 透支时调用方需要等待令牌生成.
+type tokenBucket struct {
+	rate   float64 // 每秒生成的令牌数
+	burst  float64
+	locker sync.Locker
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		locker: &sync.Mutex{},
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Reserve 预定一个令牌, 返回需要等待的时间. 等待时间超过 maxWait 时预定失败, maxWait 为负数表示不限制.
+func (bucket *tokenBucket) Reserve(maxWait time.Duration) (time.Duration, bool) {
+	bucket.locker.Lock()
+	defer bucket.locker.Unlock()
+
+	now := time.Now()
+	bucket.tokens += now.Sub(bucket.last).Seconds() * bucket.rate
+	if bucket.tokens > bucket.burst {
+		bucket.tokens = bucket.burst
+	}
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return 0, true
+	}
+	wait := time.Duration((1 - bucket.tokens) / bucket.rate * float64(time.Second))
+	if maxWait >= 0 && wait > maxWait {
+		return 0, false
+	}
+	bucket.tokens--
+	return wait, true
+}
+
+// Cancel 归还预定的令牌.
+func (bucket *tokenBucket) Cancel() {
+	bucket.locker.Lock()
+	bucket.tokens++
+	if bucket.tokens > bucket.burst {
+		bucket.tokens = bucket.burst
+	}
+	bucket.locker.Unlock()
+}
@@ -0,0 +1,61 @@
+package supplier
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitedPlugin(t *testing.T) {
+	newPlugin := func() IPlugin {
+		return NewDefaultPlugin("quota", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{}, nil
+		})
+	}
+
+	t.Run("shed", func(t *testing.T) {
+		plugin := NewRateLimitedPlugin(newPlugin(), &RateLimitPolicy{QPS: 1, Burst: 2, Mode: RateLimitShed})
+		for i := 0; i < 2; i++ {
+			_, err := plugin.Call(context.Background())
+			assert.NoError(t, err)
+		}
+		_, err := plugin.Call(context.Background())
+		assert.True(t, errors.Is(err, constant.RateLimitedError))
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		plugin := NewRateLimitedPlugin(newPlugin(), &RateLimitPolicy{QPS: 100, Mode: RateLimitWait})
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			_, err := plugin.Call(context.Background())
+			assert.NoError(t, err)
+		}
+		assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*15)
+	})
+
+	t.Run("wait_exceed_deadline", func(t *testing.T) {
+		plugin := NewRateLimitedPlugin(newPlugin(), &RateLimitPolicy{QPS: 1, Mode: RateLimitWait})
+		_, err := plugin.Call(context.Background())
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+		defer cancel()
+		start := time.Now()
+		_, err = plugin.Call(ctx)
+		assert.True(t, errors.Is(err, constant.RateLimitedError))
+		// 等待时间超过 deadline, 应该立即返回
+		assert.Less(t, time.Since(start), time.Millisecond*10)
+	})
+
+	t.Run("no_limit", func(t *testing.T) {
+		plugin := NewRateLimitedPlugin(newPlugin(), &RateLimitPolicy{})
+		for i := 0; i < 10; i++ {
+			_, err := plugin.Call(context.Background())
+			assert.NoError(t, err)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package redis
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// 值的解码方式. raw: 原样返回字符串; json: JSON 解码, 数字解码为 json.Number 以保留精度; msgpack: msgpack 解码.
+type DecodeMode int
+
+const (
+	DecodeRaw DecodeMode = iota
+	DecodeJSON
+	DecodeMsgpack
+)
+
+var DecodeModeNames = []string{
+	DecodeRaw:     "raw",
+	DecodeJSON:    "json",
+	DecodeMsgpack: "msgpack",
+}
+
+func (s DecodeMode) String() string {
+	if int(s) < len(DecodeModeNames) {
+		return DecodeModeNames[s]
+	}
+	return "decode_mode_" + strconv.Itoa(int(s))
+}
+
+func (s DecodeMode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *DecodeMode) UnmarshalJSON(b []byte) error {
+	str := ""
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	for mode, name := range DecodeModeNames {
+		if name == str {
+			*s = DecodeMode(mode)
+			return nil
+		}
+	}
+	return errors.New("unknown decode mode " + str)
+}
+
+func (s DecodeMode) decode(value string) (interface{}, error) {
+	switch s {
+	case DecodeJSON:
+		var v interface{}
+		decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+		decoder.UseNumber()
+		err := decoder.Decode(&v)
+		return v, err
+	case DecodeMsgpack:
+		v, _, err := msgp.ReadIntfBytes([]byte(value))
+		return v, err
+	}
+	return value, nil
+}
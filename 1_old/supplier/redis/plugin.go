@@ -0,0 +1,310 @@
+// package redis 是 Redis 供应商, 用于查询计数器, 黑名单等 key-value 特征.
+package redis
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// 支持的命令
+const (
+	CommandGet       = "GET"
+	CommandMGet      = "MGET"
+	CommandHGet      = "HGET"
+	CommandHGetAll   = "HGETALL"
+	CommandZScore    = "ZSCORE"
+	CommandSIsMember = "SISMEMBER"
+	CommandExists    = "EXISTS"
+)
+
+// CommandConfig 插件中的一条命令. Keys/Field 为 text/template 模板, 模板数据为 参数名->参数值.
+//
+// 输出值:
+//   - GET/HGET: 解码后的值, key 不存在时为 nil
+//   - MGET: 解码后的值数组, 不存在的 key 对应 nil
+//   - HGETALL: field->解码后的值
+//   - ZSCORE: float64, member 不存在时为 nil
+//   - SISMEMBER/EXISTS: bool
+type CommandConfig struct {
+	Command string     `json:"command"`
+	Keys    []string   `json:"keys"`   // key 模板. MGET 可以有多个, 其他命令只有一个
+	Field   string     `json:"field"`  // HGET 的 field, ZSCORE/SISMEMBER 的 member 模板
+	Output  string     `json:"output"` // 输出的 key
+	Decode  DecodeMode `json:"decode"` // GET/MGET/HGET/HGETALL 的值解码方式
+}
+
+func (cfg *CommandConfig) Validate() error {
+	switch cfg.Command {
+	case CommandGet, CommandHGetAll, CommandExists:
+		if len(cfg.Keys) != 1 {
+			return fmt.Errorf("redis command %s must have one key", cfg.Command)
+		}
+	case CommandHGet, CommandZScore, CommandSIsMember:
+		if len(cfg.Keys) != 1 || cfg.Field == "" {
+			return fmt.Errorf("redis command %s must have one key and field", cfg.Command)
+		}
+	case CommandMGet:
+		if len(cfg.Keys) == 0 {
+			return fmt.Errorf("redis command %s must have keys", cfg.Command)
+		}
+	default:
+		return fmt.Errorf("redis command %s is not supported", cfg.Command)
+	}
+	if cfg.Output == "" {
+		return fmt.Errorf("redis command %s must have output", cfg.Command)
+	}
+	if int(cfg.Decode) >= len(DecodeModeNames) {
+		return fmt.Errorf("redis command %s decode validate error: %s", cfg.Command, cfg.Decode)
+	}
+	return nil
+}
+
+// PluginConfig Redis 插件配置. 一次调用中的所有命令通过 pipeline 一次发送.
+type PluginConfig struct {
+	Name     string           `json:"name"`
+	Params   []string         `json:"params"` // 参数名称, 与调用时的参数按顺序对应
+	Commands []*CommandConfig `json:"commands"`
+}
+
+func (cfg *PluginConfig) Validate() error {
+	if cfg.Name == "" {
+		return errors.New("redis plugin must have name")
+	}
+	if len(cfg.Commands) == 0 {
+		return fmt.Errorf("redis plugin %s must have commands", cfg.Name)
+	}
+	outputs := make(map[string]struct{}, len(cfg.Commands))
+	for _, command := range cfg.Commands {
+		command.Command = strings.ToUpper(command.Command)
+		if err := command.Validate(); err != nil {
+			return fmt.Errorf("redis plugin %s: %s", cfg.Name, err.Error())
+		}
+		if _, ok := outputs[command.Output]; ok {
+			return fmt.Errorf("redis plugin %s output repeat: %s", cfg.Name, command.Output)
+		}
+		outputs[command.Output] = struct{}{}
+	}
+	return nil
+}
+
+type command struct {
+	cfg   CommandConfig
+	keys  []*template.Template
+	field *template.Template
+}
+
+// Plugin 执行一组 Redis 命令的插件.
+type Plugin struct {
+	cfg      PluginConfig
+	client   goredis.Cmdable
+	commands []*command
+}
+
+var _ supplier.IDescribedPlugin = new(Plugin)
+
+func NewPlugin(_cfg *PluginConfig, client goredis.Cmdable) (*Plugin, error) {
+	cfg := *_cfg
+	cfg.Commands = make([]*CommandConfig, len(_cfg.Commands))
+	for i, command := range _cfg.Commands {
+		c := *command
+		cfg.Commands[i] = &c
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	plugin := &Plugin{
+		cfg:      cfg,
+		client:   client,
+		commands: make([]*command, len(cfg.Commands)),
+	}
+	for i, commandCfg := range cfg.Commands {
+		c := &command{cfg: *commandCfg, keys: make([]*template.Template, len(commandCfg.Keys))}
+		var err error
+		for j, key := range commandCfg.Keys {
+			if c.keys[j], err = parseTemplate(cfg.Name, key); err != nil {
+				return nil, err
+			}
+		}
+		if commandCfg.Field != "" {
+			if c.field, err = parseTemplate(cfg.Name, commandCfg.Field); err != nil {
+				return nil, err
+			}
+		}
+		plugin.commands[i] = c
+	}
+	return plugin, nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("redis plugin %s template parse error: %s", name, err.Error())
+	}
+	return tmpl, nil
+}
+
+func execute(tmpl *template.Template, data map[string]interface{}) (string, error) {
+	buf := bytes.Buffer{}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (p *Plugin) GetName() string {
+	return p.cfg.Name
+}
+
+func (p *Plugin) Describe() *supplier.PluginSchema {
+	schema := &supplier.PluginSchema{
+		Name:    p.cfg.Name,
+		Params:  make([]supplier.ParamSchema, len(p.cfg.Params)),
+		Outputs: make([]supplier.OutputSchema, len(p.commands)),
+	}
+	for i, param := range p.cfg.Params {
+		schema.Params[i] = supplier.ParamSchema{Name: param, Required: true}
+	}
+	for i, c := range p.commands {
+		schema.Outputs[i] = supplier.OutputSchema{Name: c.cfg.Output, Type: c.dtype()}
+	}
+	return schema
+}
+
+func (p *Plugin) Call(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+	if len(args) != len(p.cfg.Params) {
+		return map[string]interface{}{}, fmt.Errorf("redis plugin %s expects %d params, got %d",
+			p.cfg.Name, len(p.cfg.Params), len(args))
+	}
+	data := make(map[string]interface{}, len(args))
+	for i, param := range p.cfg.Params {
+		data[param] = args[i]
+	}
+
+	pipe := p.client.Pipeline()
+	cmds := make([]goredis.Cmder, len(p.commands))
+	for i, c := range p.commands {
+		cmd, err := c.queue(ctx, pipe, data)
+		if err != nil {
+			return map[string]interface{}{}, fmt.Errorf("redis plugin %s: %s", p.cfg.Name, err.Error())
+		}
+		cmds[i] = cmd
+	}
+	// 单条命令的错误在下面逐条处理, redis.Nil 表示 key 不存在, 不是错误
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, goredis.Nil) {
+		return map[string]interface{}{}, err
+	}
+
+	out := make(map[string]interface{}, len(p.commands))
+	for i, c := range p.commands {
+		value, err := c.result(cmds[i])
+		if err != nil {
+			return map[string]interface{}{}, fmt.Errorf("redis plugin %s output %s: %s",
+				p.cfg.Name, c.cfg.Output, err.Error())
+		}
+		out[c.cfg.Output] = value
+	}
+	return out, nil
+}
+
+func (c *command) queue(ctx context.Context, pipe goredis.Pipeliner, data map[string]interface{}) (goredis.Cmder, error) {
+	keys := make([]string, len(c.keys))
+	for i, tmpl := range c.keys {
+		key, err := execute(tmpl, data)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	var field string
+	if c.field != nil {
+		var err error
+		if field, err = execute(c.field, data); err != nil {
+			return nil, err
+		}
+	}
+
+	switch c.cfg.Command {
+	case CommandGet:
+		return pipe.Get(ctx, keys[0]), nil
+	case CommandMGet:
+		return pipe.MGet(ctx, keys...), nil
+	case CommandHGet:
+		return pipe.HGet(ctx, keys[0], field), nil
+	case CommandHGetAll:
+		return pipe.HGetAll(ctx, keys[0]), nil
+	case CommandZScore:
+		return pipe.ZScore(ctx, keys[0], field), nil
+	case CommandSIsMember:
+		return pipe.SIsMember(ctx, keys[0], field), nil
+	case CommandExists:
+		return pipe.Exists(ctx, keys[0]), nil
+	}
+	return nil, fmt.Errorf("redis command %s is not supported", c.cfg.Command)
+}
+
+func (c *command) result(cmd goredis.Cmder) (interface{}, error) {
+	if errors.Is(cmd.Err(), goredis.Nil) {
+		return nil, nil
+	}
+	if cmd.Err() != nil {
+		return nil, cmd.Err()
+	}
+
+	switch cmd := cmd.(type) {
+	case *goredis.StringCmd:
+		return c.cfg.Decode.decode(cmd.Val())
+	case *goredis.SliceCmd:
+		values := make([]interface{}, len(cmd.Val()))
+		for i, v := range cmd.Val() {
+			if s, ok := v.(string); ok {
+				var err error
+				if values[i], err = c.cfg.Decode.decode(s); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return values, nil
+	case *goredis.StringStringMapCmd:
+		values := make(map[string]interface{}, len(cmd.Val()))
+		for k, v := range cmd.Val() {
+			var err error
+			if values[k], err = c.cfg.Decode.decode(v); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	case *goredis.FloatCmd:
+		return cmd.Val(), nil
+	case *goredis.BoolCmd:
+		return cmd.Val(), nil
+	case *goredis.IntCmd:
+		return cmd.Val() > 0, nil
+	}
+	return nil, fmt.Errorf("unexpected redis cmd %T", cmd)
+}
+
+// 命令输出值对应的 dtype, 值需要解码时类型由数据决定, 返回零值.
+func (c *command) dtype() dtype.DType {
+	switch c.cfg.Command {
+	case CommandHGetAll:
+		return dtype.Map
+	case CommandZScore:
+		return dtype.Float64
+	case CommandSIsMember, CommandExists:
+		return dtype.Bool
+	case CommandGet, CommandHGet:
+		if c.cfg.Decode == DecodeRaw {
+			return dtype.String
+		}
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestPlugin(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	profile, _ := json.Marshal(map[string]interface{}{"level": 1234567890123456789, "name": "alice"})
+	packed, _ := msgp.AppendIntf(nil, map[string]interface{}{"score": 1.5})
+	assert.NoError(t, server.Set("counter:42", "7"))
+	assert.NoError(t, server.Set("profile:42", string(profile)))
+	assert.NoError(t, server.Set("packed:42", string(packed)))
+	server.HSet("hash:42", "a", "1", "b", "2")
+	_, _ = server.ZAdd("rank", 3.5, "42")
+	_, _ = server.SetAdd("blocklist", "42")
+
+	redisSupplier, err := NewSupplier(client, []*PluginConfig{
+		{
+			Name:   "UserFeatures",
+			Params: []string{"user_id"},
+			Commands: []*CommandConfig{
+				{Command: "get", Keys: []string{"counter:{{.user_id}}"}, Output: "counter"},
+				{Command: CommandGet, Keys: []string{"missing:{{.user_id}}"}, Output: "missing"},
+				{Command: CommandGet, Keys: []string{"profile:{{.user_id}}"}, Output: "profile", Decode: DecodeJSON},
+				{Command: CommandGet, Keys: []string{"packed:{{.user_id}}"}, Output: "packed", Decode: DecodeMsgpack},
+				{Command: CommandMGet, Keys: []string{"counter:{{.user_id}}", "missing:{{.user_id}}"}, Output: "mget"},
+				{Command: CommandHGet, Keys: []string{"hash:{{.user_id}}"}, Field: "a", Output: "hget"},
+				{Command: CommandHGetAll, Keys: []string{"hash:{{.user_id}}"}, Output: "hgetall"},
+				{Command: CommandZScore, Keys: []string{"rank"}, Field: "{{.user_id}}", Output: "rank"},
+				{Command: CommandZScore, Keys: []string{"rank"}, Field: "none", Output: "rank_missing"},
+				{Command: CommandSIsMember, Keys: []string{"blocklist"}, Field: "{{.user_id}}", Output: "blocked"},
+				{Command: CommandExists, Keys: []string{"counter:{{.user_id}}"}, Output: "exists"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	out, err := redisSupplier.Supply(context.Background(), "UserFeatures", []interface{}{int64(42)})
+	assert.NoError(t, err)
+	assert.Equal(t, "7", out["counter"])
+	assert.Nil(t, out["missing"])
+	level, err := dtype.Convert(out["profile"].(map[string]interface{})["level"], dtype.Int64)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1234567890123456789), level)
+	assert.Equal(t, map[string]interface{}{"score": 1.5}, out["packed"])
+	assert.Equal(t, []interface{}{"7", nil}, out["mget"])
+	assert.Equal(t, "1", out["hget"])
+	assert.Equal(t, map[string]interface{}{"a": "1", "b": "2"}, out["hgetall"])
+	assert.Equal(t, 3.5, out["rank"])
+	assert.Nil(t, out["rank_missing"])
+	assert.Equal(t, true, out["blocked"])
+	assert.Equal(t, true, out["exists"])
+
+	t.Run("decode_error", func(t *testing.T) {
+		badSupplier, err := NewSupplier(client, []*PluginConfig{{
+			Name:     "Bad",
+			Params:   []string{"user_id"},
+			Commands: []*CommandConfig{{Command: CommandGet, Keys: []string{"counter:{{.user_id}}x"}, Output: "v", Decode: DecodeJSON}},
+		}})
+		assert.NoError(t, err)
+		assert.NoError(t, server.Set("counter:42x", "{bad"))
+		_, err = badSupplier.Supply(context.Background(), "Bad", []interface{}{42})
+		assert.Error(t, err)
+	})
+
+	t.Run("server_error", func(t *testing.T) {
+		server.SetError("server down")
+		defer server.SetError("")
+		_, err := redisSupplier.Supply(context.Background(), "UserFeatures", []interface{}{42})
+		assert.Error(t, err)
+	})
+
+	t.Run("describe", func(t *testing.T) {
+		schemas := supplier.DescribePlugins(redisSupplier)
+		output, ok := schemas["UserFeatures"].GetOutput("blocked")
+		assert.True(t, ok)
+		assert.Equal(t, dtype.Bool, output.Type)
+	})
+}
+
+func TestNewPlugin(t *testing.T) {
+	cases := []struct {
+		name  string
+		cfg   *PluginConfig
+		isErr bool
+	}{
+		{"ok", &PluginConfig{Name: "ok", Commands: []*CommandConfig{
+			{Command: CommandGet, Keys: []string{"k"}, Output: "v"}}}, false},
+		{"no_commands", &PluginConfig{Name: "no_commands"}, true},
+		{"unsupported", &PluginConfig{Name: "del", Commands: []*CommandConfig{
+			{Command: "DEL", Keys: []string{"k"}, Output: "v"}}}, true},
+		{"no_field", &PluginConfig{Name: "hget", Commands: []*CommandConfig{
+			{Command: CommandHGet, Keys: []string{"k"}, Output: "v"}}}, true},
+		{"output_repeat", &PluginConfig{Name: "repeat", Commands: []*CommandConfig{
+			{Command: CommandGet, Keys: []string{"a"}, Output: "v"},
+			{Command: CommandGet, Keys: []string{"b"}, Output: "v"}}}, true},
+		{"bad_template", &PluginConfig{Name: "tmpl", Commands: []*CommandConfig{
+			{Command: CommandGet, Keys: []string{"{{.id"}, Output: "v"}}}, true},
+	}
+	for _, c := range cases {
+		_, err := NewPlugin(c.cfg, nil)
+		assert.Equal(t, c.isErr, err != nil, c.name)
+	}
+}
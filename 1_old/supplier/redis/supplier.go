@@ -0,0 +1,21 @@
+package redis
+
+import (
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+	goredis "github.com/go-redis/redis/v8"
+)
+
+const SupplierName = "redis"
+
+// NewSupplier 根据插件配置创建 Redis 供应商, 所有插件共享 client. client 可以是单机, 哨兵或集群客户端.
+func NewSupplier(client goredis.Cmdable, cfgs []*PluginConfig, options ...supplier.Option) (*supplier.DefaultSupplier, error) {
+	plugins := make([]supplier.IPlugin, len(cfgs))
+	for i, cfg := range cfgs {
+		plugin, err := NewPlugin(cfg, client)
+		if err != nil {
+			return nil, err
+		}
+		plugins[i] = plugin
+	}
+	return supplier.NewDefaultSupplier(SupplierName, plugins, options...), nil
+}
@@ -0,0 +1,152 @@
+package supplier
+
+import (
+	"context"
+	"fmt"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+)
+
+// ParamSchema 描述插件的一个参数. Type 为零值时表示任意类型.
+type ParamSchema struct {
+	Name     string      `json:"name"`
+	Type     dtype.DType `json:"type"`
+	Required bool        `json:"required"`
+	// 插件会通过 dtype.Convert 将参数转换为 Type, 配置的类型可以转换(dtype.CanConvert)时也通过校验.
+	Convertible bool `json:"convertible"`
+}
+
+// Accept 判断配置的参数类型是否满足描述. 未标记 Convertible 时要求类型一致.
+func (param ParamSchema) Accept(paramType dtype.DType) bool {
+	if param.Type == 0 || paramType == 0 || param.Type == paramType {
+		return true
+	}
+	return param.Convertible && dtype.CanConvert(paramType, param.Type)
+}
+
+// OutputSchema 描述插件返回值中的一个 key. Type 为零值时表示任意类型.
+type OutputSchema struct {
+	Name string      `json:"name"`
+	Type dtype.DType `json:"type"`
+}
+
+// PluginSchema 描述插件的参数和返回值, 用于构建 node/dag 时校验配置.
+type PluginSchema struct {
+	Name   string        `json:"name"`
+	Params []ParamSchema `json:"params"`
+	// 最后一个参数可以重复多次, 如 Extract(payload, paths...)
+	Variadic bool `json:"variadic"`
+	// 为空时表示返回值的 key 是动态的, 不校验.
+	Outputs []OutputSchema `json:"outputs"`
+	// 可选, 参数数量和类型校验通过后进一步校验参数, 如常量参数的取值. values 中变量参数的值为 nil.
+	CheckParams func(values []interface{}, paramTypes []dtype.DType) error `json:"-"`
+}
+
+// GetParam 返回第 i 个参数的描述, 可变参数插件的超出部分使用最后一个参数的描述.
+func (schema *PluginSchema) GetParam(i int) (ParamSchema, bool) {
+	if i < len(schema.Params) {
+		return schema.Params[i], true
+	}
+	if schema.Variadic && len(schema.Params) > 0 {
+		return schema.Params[len(schema.Params)-1], true
+	}
+	return ParamSchema{}, false
+}
+
+// GetOutput 返回名为 name 的返回值描述. Outputs 为空时总是返回 true.
+func (schema *PluginSchema) GetOutput(name string) (OutputSchema, bool) {
+	if len(schema.Outputs) == 0 {
+		return OutputSchema{Name: name}, true
+	}
+	for _, output := range schema.Outputs {
+		if output.Name == name {
+			return output, true
+		}
+	}
+	return OutputSchema{}, false
+}
+
+// ValidateParams 校验参数数量和类型. paramTypes 中的零值表示类型未知, 跳过类型校验.
+// 类型默认需要一致, 标记为 Convertible 的参数接受可以转换的类型.
+func (schema *PluginSchema) ValidateParams(paramTypes []dtype.DType) error {
+	for i, paramType := range paramTypes {
+		param, ok := schema.GetParam(i)
+		if !ok {
+			return fmt.Errorf("plugin %s accepts at most %d params, got %d",
+				schema.Name, len(schema.Params), len(paramTypes))
+		}
+		if !param.Accept(paramType) {
+			return fmt.Errorf("plugin %s param %d [%s] type must be %s, got %s",
+				schema.Name, i+1, param.Name, param.Type, paramType)
+		}
+	}
+	for i := len(paramTypes); i < len(schema.Params); i++ {
+		if schema.Params[i].Required {
+			return fmt.Errorf("plugin %s missing required param %d [%s]",
+				schema.Name, i+1, schema.Params[i].Name)
+		}
+	}
+	return nil
+}
+
+// 可以描述自身参数和返回值的插件.
+type IDescribedPlugin interface {
+	IPlugin
+	Describe() *PluginSchema
+}
+
+// DescribedPlugin 为已有插件附加描述信息.
+type DescribedPlugin struct {
+	plugin IPlugin
+	schema *PluginSchema
+}
+
+var _ IDescribedPlugin = new(DescribedPlugin)
+var _ IWrappedPlugin = new(DescribedPlugin)
+
+func NewDescribedPlugin(plugin IPlugin, schema *PluginSchema) *DescribedPlugin {
+	schema.Name = plugin.GetName()
+	return &DescribedPlugin{
+		plugin: plugin,
+		schema: schema,
+	}
+}
+
+func (p *DescribedPlugin) GetName() string {
+	return p.plugin.GetName()
+}
+
+// Unwrap 返回被包装的插件.
+func (p *DescribedPlugin) Unwrap() IPlugin {
+	return p.plugin
+}
+
+func (p *DescribedPlugin) Call(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+	return p.plugin.Call(ctx, args...)
+}
+
+func (p *DescribedPlugin) Describe() *PluginSchema {
+	return p.schema
+}
+
+// DescribePlugin 沿包装链查找插件的描述信息.
+func DescribePlugin(plugin IPlugin) (*PluginSchema, bool) {
+	described, ok := FindPlugin(plugin, func(p IPlugin) bool {
+		_, ok := p.(IDescribedPlugin)
+		return ok
+	})
+	if !ok {
+		return nil, false
+	}
+	return described.(IDescribedPlugin).Describe(), true
+}
+
+// DescribePlugins 返回 supplier 中所有插件的描述信息, 供配置人员查看. 未提供描述的插件值为 nil.
+// key: plugin_name
+func DescribePlugins(supplier ISupplier) map[string]*PluginSchema {
+	schemas := map[string]*PluginSchema{}
+	for name, plugin := range supplier.GetAllPlugin() {
+		schemas[name], _ = DescribePlugin(plugin)
+	}
+	return schemas
+}
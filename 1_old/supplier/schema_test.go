@@ -0,0 +1,84 @@
+package supplier
+
+import (
+	"context"
+	"testing"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluginSchemaValidateParams(t *testing.T) {
+	schema := &PluginSchema{
+		Name: "tests",
+		Params: []ParamSchema{
+			{Name: "id", Type: dtype.Int64, Required: true},
+			{Name: "any"},
+			{Name: "opt", Type: dtype.String},
+		},
+	}
+	variadic := &PluginSchema{
+		Name:     "variadic",
+		Params:   []ParamSchema{{Name: "payload", Type: dtype.ArrayByte, Required: true}, {Name: "paths", Type: dtype.String}},
+		Variadic: true,
+	}
+	convertible := &PluginSchema{
+		Name:   "convertible",
+		Params: []ParamSchema{{Name: "id", Type: dtype.Int64, Required: true, Convertible: true}},
+	}
+	cases := []struct {
+		name   string
+		schema *PluginSchema
+		types  []dtype.DType
+		isErr  bool
+	}{
+		{"required_only", schema, []dtype.DType{dtype.Int64}, false},
+		{"all", schema, []dtype.DType{dtype.Int64, dtype.Map, dtype.String}, false},
+		{"unknown_type", schema, []dtype.DType{0}, false},
+		{"missing_required", schema, []dtype.DType{}, true},
+		{"type_mismatch", schema, []dtype.DType{dtype.String}, true},
+		{"too_many", schema, []dtype.DType{dtype.Int64, dtype.Map, dtype.String, dtype.String}, true},
+		{"variadic", variadic, []dtype.DType{dtype.ArrayByte, dtype.String, dtype.String}, false},
+		{"variadic_type_mismatch", variadic, []dtype.DType{dtype.ArrayByte, dtype.String, dtype.Int64}, true},
+		{"convertible", convertible, []dtype.DType{dtype.String}, false},
+		{"not_convertible", convertible, []dtype.DType{dtype.Map}, true},
+	}
+	for _, c := range cases {
+		err := c.schema.ValidateParams(c.types)
+		assert.Equal(t, c.isErr, err != nil, c.name)
+	}
+}
+
+func TestDescribePlugins(t *testing.T) {
+	fn := func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{}, nil
+	}
+	typed, err := NewTypedPlugin("typed", func(ctx context.Context, id int32) (*typedOut, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	supplier := NewDefaultSupplier("tests", []IPlugin{
+		NewDescribedPlugin(NewDefaultPlugin("described", fn), &PluginSchema{
+			Outputs: []OutputSchema{{Name: "out", Type: dtype.String}},
+		}),
+		NewDefaultPlugin("plain", fn),
+		typed,
+	}, SetCircuitBreaker(&BreakerConfig{}))
+
+	schemas := DescribePlugins(supplier)
+	assert.Len(t, schemas, 3)
+	assert.Nil(t, schemas["plain"])
+	// 被熔断包装后仍能获取描述信息
+	assert.Equal(t, "described", schemas["described"].Name)
+	_, ok := schemas["described"].GetOutput("out")
+	assert.True(t, ok)
+	_, ok = schemas["described"].GetOutput("missing")
+	assert.False(t, ok)
+
+	assert.Equal(t, []ParamSchema{{Name: "arg1", Type: dtype.Int64, Required: true, Convertible: true}}, schemas["typed"].Params)
+	assert.Equal(t, []OutputSchema{
+		{Name: "user_id", Type: dtype.Int64},
+		{Name: "tags", Type: dtype.ArrayString},
+		{Name: "Score", Type: dtype.Float64},
+	}, schemas["typed"].Outputs)
+}
@@ -0,0 +1,108 @@
+package supplier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/deepcopy"
+	"git.in.zhihu.com/antispam/datasupply/utils"
+)
+
+// 共享调用的超时时间, 可以通过 SetSingleflightTimeout 修改.
+const DefaultSingleflightTimeout = time.Second * 10
+
+// 相同插件+相同参数的并发调用只执行一次, 其他调用方共享结果.
+// 共享的调用不使用任何调用方的取消信号, 只保留 ctx 中的值, 并使用独立的超时时间,
+// 某个调用方超时或取消时只有它自己提前返回, 不影响其他调用方.
+func (supplier *DefaultSupplier) supplyShared(ctx context.Context, plugin IPlugin,
+	params []interface{}) (map[string]interface{}, error) {
+	key, err := paramsKey(plugin.GetName(), params)
+	if err != nil {
+		// 参数无法编码时不共享
+		return plugin.Call(ctx, params...)
+	}
+
+	resultCh := supplier.flight.DoChan(key, func() (interface{}, error) {
+		sharedCtx, cancel := context.WithTimeout(detachedContext{ctx}, supplier.options.singleflightTimeout)
+		defer cancel()
+		// 共享调用使用独立的 CallInfo, 调用信息随结果返回给每个调用方
+		sharedCtx, info := WithCallInfo(sharedCtx)
+		var out map[string]interface{}
+		var err error
+		// singleflight 会在新的 goroutine 中重新抛出 panic, 调用方无法 recover, 所以在这里转为错误
+		if panicErr := utils.SafelyRun(func() {
+			out, err = plugin.Call(sharedCtx, params...)
+		}); panicErr != nil {
+			out, err = nil, fmt.Errorf("panic: %v", panicErr)
+		}
+		return &sharedResult{out: out, info: info}, err
+	})
+	select {
+	case <-ctx.Done():
+		return map[string]interface{}{}, ctx.Err()
+	case r := <-resultCh:
+		result, _ := r.Val.(*sharedResult)
+		if info, ok := GetCallInfo(ctx); ok {
+			info.copyFrom(result.info)
+		}
+		out := result.out
+		// 共享的结果需要深拷贝, 防止调用方修改 map 影响其他调用方
+		if r.Shared && out != nil {
+			out = deepcopy.Copy(out).(map[string]interface{})
+		}
+		return out, r.Err
+	}
+}
+
+type sharedResult struct {
+	out  map[string]interface{}
+	info *CallInfo
+}
+
+// detachedContext 保留 parent 中的值(如 trace 信息), 但不继承 parent 的超时和取消.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (ctx detachedContext) Deadline() (time.Time, bool)       { return time.Time{}, false }
+func (ctx detachedContext) Done() <-chan struct{}             { return nil }
+func (ctx detachedContext) Err() error                        { return nil }
+func (ctx detachedContext) Value(key interface{}) interface{} { return ctx.parent.Value(key) }
+
+// key == plugin_name + 参数的规范化编码. json 编码 map 时 key 有序, 可以作为规范化编码.
+// 编码中包含参数的类型, 如 int64(1), float64(1) 和 json.Number("1") 的 key 不同.
+func paramsKey(pluginName string, params []interface{}) (string, error) {
+	encoded, err := json.Marshal(typedParams(params))
+	if err != nil {
+		return "", err
+	}
+	builder := strings.Builder{}
+	builder.Grow(len(pluginName) + len(encoded) + 1)
+	builder.WriteString(pluginName)
+	builder.WriteString("_")
+	builder.WriteString(utils.Bytes2String(encoded))
+	return builder.String(), nil
+}
+
+// 为参数值加上类型, 递归处理 json 对象和数组中的元素.
+func typedParams(value interface{}) interface{} {
+	switch value := value.(type) {
+	case []interface{}:
+		typed := make([]interface{}, len(value))
+		for i, v := range value {
+			typed[i] = typedParams(v)
+		}
+		return typed
+	case map[string]interface{}:
+		typed := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			typed[k] = typedParams(v)
+		}
+		return typed
+	default:
+		return [2]interface{}{fmt.Sprintf("%T", value), value}
+	}
+}
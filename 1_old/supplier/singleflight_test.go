@@ -0,0 +1,147 @@
+package supplier
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSingleflight(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	newPlugin := func(name string) IPlugin {
+		return NewDefaultPlugin(name, func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return map[string]interface{}{
+				"user_id": args[0],
+				"tags":    []string{"a", "b"},
+			}, nil
+		})
+	}
+
+	t.Run("shared", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		release = make(chan struct{})
+		supplier := NewDefaultSupplier("tests", []IPlugin{newPlugin("user_info")},
+			SetSingleflight("user_info"))
+
+		n := 5
+		results := make([]map[string]interface{}, n)
+		wg := sync.WaitGroup{}
+		for i := 0; i < n; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], _ = supplier.Supply(context.Background(), "user_info", []interface{}{int64(1)})
+			}()
+		}
+		// 等待所有调用方进入 singleflight 后再返回结果
+		time.Sleep(time.Millisecond * 20)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		// 修改其中一个结果, 不影响其他调用方
+		results[0]["user_id"] = int64(2)
+		results[0]["tags"].([]string)[0] = "c"
+		for _, result := range results[1:] {
+			assert.Equal(t, int64(1), result["user_id"])
+			assert.Equal(t, []string{"a", "b"}, result["tags"])
+		}
+	})
+
+	t.Run("different_params", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		release = make(chan struct{})
+		close(release)
+		supplier := NewDefaultSupplier("tests", []IPlugin{newPlugin("user_info")},
+			SetSingleflight())
+		for i := 0; i < 2; i++ {
+			result, err := supplier.Supply(context.Background(), "user_info", []interface{}{int64(i)})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(i), result["user_id"])
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("caller_canceled", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		release = make(chan struct{})
+		supplier := NewDefaultSupplier("tests", []IPlugin{newPlugin("user_info")},
+			SetSingleflight("user_info"))
+
+		// 第一个调用方超时不影响共享同一次调用的其他调用方
+		firstCtx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+		defer cancel()
+		var firstErr error
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, firstErr = supplier.Supply(firstCtx, "user_info", []interface{}{int64(1)})
+		}()
+		time.Sleep(time.Millisecond * 5)
+		var result map[string]interface{}
+		var err error
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err = supplier.Supply(context.Background(), "user_info", []interface{}{int64(1)})
+		}()
+		time.Sleep(time.Millisecond * 20)
+		close(release)
+		wg.Wait()
+
+		assert.ErrorIs(t, firstErr, context.DeadlineExceeded)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), result["user_id"])
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("shared_timeout", func(t *testing.T) {
+		supplier := NewDefaultSupplier("tests", []IPlugin{
+			NewDefaultPlugin("block", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}),
+		}, SetSingleflight(), SetSingleflightTimeout(time.Millisecond*10))
+		_, err := supplier.Supply(context.Background(), "block", []interface{}{int64(1)})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		supplier := NewDefaultSupplier("tests", []IPlugin{
+			NewDefaultPlugin("panic", func(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+				panic("boom")
+			}),
+		}, SetSingleflight())
+		_, err := supplier.Supply(context.Background(), "panic", []interface{}{int64(1)})
+		assert.ErrorContains(t, err, "panic")
+	})
+
+	t.Run("key", func(t *testing.T) {
+		key1, err := paramsKey("f", []interface{}{map[string]interface{}{"a": 1, "b": 2}})
+		assert.NoError(t, err)
+		key2, err := paramsKey("f", []interface{}{map[string]interface{}{"b": 2, "a": 1}})
+		assert.NoError(t, err)
+		assert.Equal(t, key1, key2)
+		// 类型不同的参数不共享调用
+		keys := map[string]struct{}{}
+		for _, param := range []interface{}{int64(1), float64(1), json.Number("1"), "1",
+			map[string]interface{}{"a": int64(1)}, map[string]interface{}{"a": float64(1)}} {
+			key, err := paramsKey("f", []interface{}{param})
+			assert.NoError(t, err)
+			keys[key] = struct{}{}
+		}
+		assert.Len(t, keys, 6)
+		_, err = paramsKey("f", []interface{}{func() {}})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,191 @@
+// package sql 是 database/sql 供应商, 插件为参数化查询, 可以使用任意 database/sql 驱动.
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+)
+
+// 查询结果的处理方式. single: 只取第一行, 每列输出一个值; multi: 取所有行, 每列输出一个数组.
+type RowMode int
+
+const (
+	RowModeSingle RowMode = iota
+	RowModeMulti
+)
+
+var RowModeNames = []string{
+	RowModeSingle: "single",
+	RowModeMulti:  "multi",
+}
+
+func (s RowMode) String() string {
+	if int(s) < len(RowModeNames) {
+		return RowModeNames[s]
+	}
+	return "row_mode_" + strconv.Itoa(int(s))
+}
+
+func (s RowMode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *RowMode) UnmarshalJSON(b []byte) error {
+	str := ""
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	for mode, name := range RowModeNames {
+		if name == str {
+			*s = RowMode(mode)
+			return nil
+		}
+	}
+	return errors.New("unknown row mode " + str)
+}
+
+// PluginConfig SQL 插件配置.
+type PluginConfig struct {
+	Name string `json:"name"`
+	// 参数化查询, 占位符格式由驱动决定(如 ?, $1). 参数按顺序作为占位符的值, 不会拼接到 SQL 中.
+	Query  string   `json:"query"`
+	Params []string `json:"params"` // 参数名称, 仅用于插件描述
+	Mode   RowMode  `json:"mode"`
+	// 列名->输出 key. 为空时输出所有列, key 为列名; 不为空时只输出指定的列.
+	Columns map[string]string `json:"columns"`
+	// 插件同时占用的最大连接数, 避免单个慢查询占满连接池. <=0 表示不限制.
+	MaxConns int `json:"max_conns"`
+}
+
+func (cfg *PluginConfig) Validate() error {
+	if cfg.Name == "" {
+		return errors.New("sql plugin must have name")
+	}
+	if cfg.Query == "" {
+		return fmt.Errorf("sql plugin %s must have query", cfg.Name)
+	}
+	if int(cfg.Mode) >= len(RowModeNames) {
+		return fmt.Errorf("sql plugin %s mode validate error: %s", cfg.Name, cfg.Mode)
+	}
+	return nil
+}
+
+// Plugin 执行参数化查询的插件.
+type Plugin struct {
+	cfg   PluginConfig
+	stmts *StmtCache
+	conns chan struct{} // 为空时不限制连接数
+}
+
+var _ supplier.IDescribedPlugin = new(Plugin)
+
+// NewPlugin 创建 SQL 插件, 相同查询的插件共享 stmts 中的预处理语句.
+func NewPlugin(_cfg *PluginConfig, stmts *StmtCache) (*Plugin, error) {
+	cfg := *_cfg
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	plugin := &Plugin{
+		cfg:   cfg,
+		stmts: stmts,
+	}
+	if cfg.MaxConns > 0 {
+		plugin.conns = make(chan struct{}, cfg.MaxConns)
+	}
+	return plugin, nil
+}
+
+func (p *Plugin) GetName() string {
+	return p.cfg.Name
+}
+
+func (p *Plugin) Describe() *supplier.PluginSchema {
+	schema := &supplier.PluginSchema{
+		Name:    p.cfg.Name,
+		Params:  make([]supplier.ParamSchema, len(p.cfg.Params)),
+		Outputs: make([]supplier.OutputSchema, 0, len(p.cfg.Columns)),
+	}
+	for i, param := range p.cfg.Params {
+		schema.Params[i] = supplier.ParamSchema{Name: param, Required: true}
+	}
+	for _, key := range p.cfg.Columns {
+		schema.Outputs = append(schema.Outputs, supplier.OutputSchema{Name: key})
+	}
+	return schema
+}
+
+func (p *Plugin) Call(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+	if p.conns != nil {
+		select {
+		case p.conns <- struct{}{}:
+			defer func() { <-p.conns }()
+		case <-ctx.Done():
+			return map[string]interface{}{}, ctx.Err()
+		}
+	}
+
+	stmt, err := p.stmts.Get(ctx, p.cfg.Query)
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+	keys := make([]string, len(columns)) // 列对应的输出 key, 为空时不输出
+	for i, column := range columns {
+		if len(p.cfg.Columns) == 0 {
+			keys[i] = column
+		} else {
+			keys[i] = p.cfg.Columns[column]
+		}
+	}
+
+	out := make(map[string]interface{}, len(keys))
+	if p.cfg.Mode == RowModeMulti {
+		for _, key := range keys {
+			if key != "" {
+				out[key] = []interface{}{}
+			}
+		}
+	}
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return map[string]interface{}{}, err
+		}
+		for i, key := range keys {
+			if key == "" {
+				continue
+			}
+			if p.cfg.Mode == RowModeMulti {
+				out[key] = append(out[key].([]interface{}), values[i])
+			} else {
+				out[key] = values[i]
+			}
+		}
+		// single 模式只取第一行. 没有结果时不输出任何 key, 由 node 处理为 field_not_found_in_supply_response
+		if p.cfg.Mode == RowModeSingle {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return map[string]interface{}{}, err
+	}
+	return out, nil
+}
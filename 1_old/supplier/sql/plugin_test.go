@@ -0,0 +1,168 @@
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"testing"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func newTestDB(t *testing.T) *dbsql.DB {
+	db, err := dbsql.Open("sqlite", ":memory:")
+	assert.NoError(t, err)
+	// 内存数据库每个连接是独立的, 测试只使用一个连接
+	db.SetMaxOpenConns(1)
+	for _, query := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, score REAL)`,
+		`CREATE TABLE tags (user_id INTEGER, tag TEXT)`,
+		`INSERT INTO users VALUES (1, 'alice', 9.5), (2, 'bob', 7)`,
+		`INSERT INTO tags VALUES (1, 'a'), (1, 'b'), (2, 'c')`,
+	} {
+		_, err := db.Exec(query)
+		assert.NoError(t, err)
+	}
+	return db
+}
+
+func TestPlugin(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	sqlSupplier, stmts, err := NewSupplier(db, []*PluginConfig{
+		{
+			Name:   "GetUser",
+			Query:  `SELECT id, name, score FROM users WHERE id = ?`,
+			Params: []string{"user_id"},
+		},
+		{
+			Name:    "GetUserName",
+			Query:   `SELECT id, name, score FROM users WHERE id = ?`,
+			Params:  []string{"user_id"},
+			Columns: map[string]string{"name": "user_name"},
+		},
+		{
+			Name:     "GetTags",
+			Query:    `SELECT tag FROM tags WHERE user_id = ? ORDER BY tag`,
+			Params:   []string{"user_id"},
+			Mode:     RowModeMulti,
+			MaxConns: 1,
+		},
+	})
+	assert.NoError(t, err)
+	defer stmts.Close()
+
+	t.Run("single", func(t *testing.T) {
+		out, err := sqlSupplier.Supply(context.Background(), "GetUser", []interface{}{int64(1)})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), out["id"])
+		assert.Equal(t, "alice", dtype.ToString(out["name"]))
+		assert.Equal(t, 9.5, out["score"])
+	})
+
+	t.Run("columns", func(t *testing.T) {
+		out, err := sqlSupplier.Supply(context.Background(), "GetUserName", []interface{}{int64(2)})
+		assert.NoError(t, err)
+		assert.Len(t, out, 1)
+		assert.Equal(t, "bob", dtype.ToString(out["user_name"]))
+		// 相同查询共享预处理语句
+		assert.Equal(t, 1, stmts.Len())
+	})
+
+	t.Run("no_rows", func(t *testing.T) {
+		out, err := sqlSupplier.Supply(context.Background(), "GetUser", []interface{}{int64(3)})
+		assert.NoError(t, err)
+		assert.Empty(t, out)
+	})
+
+	t.Run("multi", func(t *testing.T) {
+		out, err := sqlSupplier.Supply(context.Background(), "GetTags", []interface{}{int64(1)})
+		assert.NoError(t, err)
+		tags, err := dtype.Convert(out["tag"], dtype.ArrayString)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, tags)
+
+		out, err = sqlSupplier.Supply(context.Background(), "GetTags", []interface{}{int64(3)})
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{}, out["tag"])
+	})
+
+	t.Run("max_conns", func(t *testing.T) {
+		plugin, _ := sqlSupplier.GetPlugin("GetTags")
+		conns := plugin.(*Plugin).conns
+		conns <- struct{}{}
+		defer func() { <-conns }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+		defer cancel()
+		_, err := sqlSupplier.Supply(ctx, "GetTags", []interface{}{int64(1)})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("describe", func(t *testing.T) {
+		schemas := supplier.DescribePlugins(sqlSupplier)
+		assert.Equal(t, []supplier.OutputSchema{{Name: "user_name"}}, schemas["GetUserName"].Outputs)
+		assert.Empty(t, schemas["GetUser"].Outputs)
+	})
+}
+
+func TestNewPlugin(t *testing.T) {
+	cases := []struct {
+		name  string
+		cfg   *PluginConfig
+		isErr bool
+	}{
+		{"ok", &PluginConfig{Name: "ok", Query: "SELECT 1"}, false},
+		{"no_name", &PluginConfig{Query: "SELECT 1"}, true},
+		{"no_query", &PluginConfig{Name: "no_query"}, true},
+		{"bad_mode", &PluginConfig{Name: "bad_mode", Query: "SELECT 1", Mode: RowMode(5)}, true},
+	}
+	for _, c := range cases {
+		_, err := NewPlugin(c.cfg, nil)
+		assert.Equal(t, c.isErr, err != nil, c.name)
+	}
+}
+
+func TestStmtCache(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+	stmts := NewStmtCache(db)
+	defer stmts.Close()
+
+	cached := `SELECT name FROM users WHERE id = ?`
+	_, err := stmts.Get(context.Background(), cached)
+	assert.NoError(t, err)
+
+	// 占用唯一的连接, 新语句的预处理会阻塞, 但不影响获取已缓存的语句
+	conn, err := db.Conn(context.Background())
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := stmts.Get(ctx, `SELECT tag FROM tags WHERE user_id = ?`)
+		done <- err
+	}()
+	time.Sleep(time.Millisecond * 10)
+
+	getDone := make(chan struct{})
+	go func() {
+		_, err := stmts.Get(context.Background(), cached)
+		assert.NoError(t, err)
+		close(getDone)
+	}()
+	select {
+	case <-getDone:
+	case <-time.After(time.Second):
+		t.Error("get cached stmt blocked by prepare")
+	}
+
+	cancel()
+	assert.Error(t, <-done)
+	assert.NoError(t, conn.Close())
+	<-getDone
+	assert.Equal(t, 1, stmts.Len())
+}
@@ -0,0 +1,70 @@
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"sync"
+)
+
+// StmtCache 按查询语句缓存预处理语句. *sql.Stmt 是并发安全的, 会在需要时自动在其他连接上重新预处理.
+type StmtCache struct {
+	db     *dbsql.DB
+	locker sync.Locker
+	stmts  map[string]*dbsql.Stmt
+}
+
+func NewStmtCache(db *dbsql.DB) *StmtCache {
+	return &StmtCache{
+		db:     db,
+		locker: &sync.Mutex{},
+		stmts:  map[string]*dbsql.Stmt{},
+	}
+}
+
+// Get 返回 query 对应的预处理语句, 不存在时创建. 预处理失败时不缓存, 下次调用重新预处理.
+// 预处理在锁外进行, 不会阻塞其他语句的获取; 并发预处理同一语句时保留先写入的, 关闭多余的.
+func (cache *StmtCache) Get(ctx context.Context, query string) (*dbsql.Stmt, error) {
+	if stmt, ok := cache.get(query); ok {
+		return stmt, nil
+	}
+	stmt, err := cache.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	cache.locker.Lock()
+	defer cache.locker.Unlock()
+	if cached, ok := cache.stmts[query]; ok {
+		_ = stmt.Close()
+		return cached, nil
+	}
+	cache.stmts[query] = stmt
+	return stmt, nil
+}
+
+func (cache *StmtCache) get(query string) (*dbsql.Stmt, bool) {
+	cache.locker.Lock()
+	defer cache.locker.Unlock()
+	stmt, ok := cache.stmts[query]
+	return stmt, ok
+}
+
+// Len 返回缓存的预处理语句数量.
+func (cache *StmtCache) Len() int {
+	cache.locker.Lock()
+	defer cache.locker.Unlock()
+	return len(cache.stmts)
+}
+
+// Close 关闭所有预处理语句, 不会关闭 db.
+func (cache *StmtCache) Close() error {
+	cache.locker.Lock()
+	defer cache.locker.Unlock()
+	var lastErr error
+	for query, stmt := range cache.stmts {
+		if err := stmt.Close(); err != nil {
+			lastErr = err
+		}
+		delete(cache.stmts, query)
+	}
+	return lastErr
+}
@@ -0,0 +1,24 @@
+package sql
+
+import (
+	dbsql "database/sql"
+
+	"git.in.zhihu.com/antispam/datasupply/supplier"
+)
+
+const SupplierName = "sql"
+
+// NewSupplier 根据插件配置创建 SQL 供应商, 所有插件共享 db 和预处理语句缓存.
+// 返回的 StmtCache 需要在 db 关闭前关闭.
+func NewSupplier(db *dbsql.DB, cfgs []*PluginConfig, options ...supplier.Option) (*supplier.DefaultSupplier, *StmtCache, error) {
+	stmts := NewStmtCache(db)
+	plugins := make([]supplier.IPlugin, len(cfgs))
+	for i, cfg := range cfgs {
+		plugin, err := NewPlugin(cfg, stmts)
+		if err != nil {
+			return nil, nil, err
+		}
+		plugins[i] = plugin
+	}
+	return supplier.NewDefaultSupplier(SupplierName, plugins, options...), stmts, nil
+}
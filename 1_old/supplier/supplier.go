@@ -18,8 +18,10 @@ package supplier
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 
 	"git.in.zhihu.com/antispam/datasupply/constant"
+	"golang.org/x/sync/singleflight"
 )
 
 //go:generate mockgen -package mock -destination ./mock/supplier.go -source=supplier.go
@@ -33,24 +35,51 @@ type ISupplier interface {
 	Supply(ctx context.Context, pluginName string, params []interface{}) (map[string]interface{}, error)
 }
 
+// IMiddlewareSupplier 支持中间件的 supplier, 是可选接口, 外部的 ISupplier 实现不需要实现 Use.
+type IMiddlewareSupplier interface {
+	ISupplier
+	// 添加插件调用的中间件, 按照 Use 的顺序执行. 可以通过 ScopeMiddleware 指定作用的插件.
+	Use(...Middleware)
+}
+
 type DefaultSupplier struct {
 	name      string
 	pluginMap map[string]IPlugin
 	locker    sync.Locker
+	options   *options
+	flight    *singleflight.Group
+
+	mwchain     atomic.Value // middleware chain, Handler. Use 可能与 Supply 并发调用
+	middlewares []Middleware
+	mwChainLock sync.Locker
 }
 
-var _ ISupplier = new(DefaultSupplier)
+var _ IMiddlewareSupplier = new(DefaultSupplier)
 
-func NewDefaultSupplier(name string, plugins []IPlugin, options ...Option) *DefaultSupplier {
-	pluginMap := make(map[string]IPlugin)
-	for _, plugin := range plugins {
-		pluginMap[plugin.GetName()] = plugin
+func NewDefaultSupplier(name string, plugins []IPlugin, _options ...Option) *DefaultSupplier {
+	options := &options{}
+	for _, option := range _options {
+		option(options)
+	}
+	if options.singleflightTimeout <= 0 {
+		options.singleflightTimeout = DefaultSingleflightTimeout
 	}
-	return &DefaultSupplier{
+
+	supplier := &DefaultSupplier{
 		name:      name,
-		pluginMap: pluginMap,
+		pluginMap: make(map[string]IPlugin, len(plugins)),
 		locker:    &sync.Mutex{},
+		options:   options,
+		flight:    &singleflight.Group{},
+
+		middlewares: []Middleware{},
+		mwChainLock: &sync.Mutex{},
 	}
+	supplier.mwchain.Store(Handler(supplier.handler))
+	for _, plugin := range plugins {
+		supplier.pluginMap[plugin.GetName()] = supplier.wrapPlugin(plugin)
+	}
+	return supplier
 }
 
 func (supplier *DefaultSupplier) GetName() string {
@@ -71,16 +100,48 @@ func (supplier *DefaultSupplier) GetAllPlugin() map[string]IPlugin {
 }
 
 func (supplier *DefaultSupplier) RegisterPlugin(plugin IPlugin) {
+	plugin = supplier.wrapPlugin(plugin)
 	supplier.locker.Lock()
 	supplier.pluginMap[plugin.GetName()] = plugin
 	supplier.locker.Unlock()
 }
 
+// 按照 option 的顺序包装插件, 先配置的在内层.
+func (supplier *DefaultSupplier) wrapPlugin(plugin IPlugin) IPlugin {
+	for _, wrapper := range supplier.options.pluginWrappers {
+		if wrapper.match(plugin.GetName()) {
+			plugin = wrapper.wrap(plugin)
+		}
+	}
+	return plugin
+}
+
+// 中间件调用链, 与 dag.Use 相同, 每次添加 middleware 需要重新构建.
+func (supplier *DefaultSupplier) Use(middlewares ...Middleware) {
+	supplier.mwChainLock.Lock()
+	defer supplier.mwChainLock.Unlock()
+
+	supplier.middlewares = append(supplier.middlewares, middlewares...)
+	var mwchain Handler = supplier.handler
+	for i := len(supplier.middlewares) - 1; i >= 0; i-- {
+		mwchain = supplier.middlewares[i](mwchain)
+	}
+	supplier.mwchain.Store(mwchain)
+}
+
 func (supplier *DefaultSupplier) Supply(ctx context.Context, pluginName string,
+	params []interface{}) (map[string]interface{}, error) {
+	return supplier.mwchain.Load().(Handler)(ctx, pluginName, params)
+}
+
+func (supplier *DefaultSupplier) handler(ctx context.Context, pluginName string,
 	params []interface{}) (map[string]interface{}, error) {
 	plugin, isExist := supplier.getPlugin(pluginName)
 	if !isExist {
 		return map[string]interface{}{}, constant.NotFoundError
 	}
+	if supplier.options.singleflight.match(pluginName) {
+		return supplier.supplyShared(ctx, plugin, params)
+	}
 	return plugin.Call(ctx, params...)
 }
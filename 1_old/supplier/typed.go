@@ -0,0 +1,268 @@
+package supplier
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.in.zhihu.com/antispam/datasupply/dtype"
+)
+
+// 结构体返回值的字段名 tag, 如 `plugin:"user_name"`. 未设置时使用字段名, "-" 表示忽略该字段.
+const TypedPluginTag = "plugin"
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// 参数类型到 dtype 的映射. 不在表中的整数/浮点类型先转换为 Int64/Uint64/Float64, 再通过 reflect 转换, 超出范围时返回错误.
+var typedParamDTypes = map[reflect.Type]dtype.DType{
+	reflect.TypeOf(""):                         dtype.String,
+	reflect.TypeOf(int64(0)):                   dtype.Int64,
+	reflect.TypeOf(float64(0)):                 dtype.Float64,
+	reflect.TypeOf(false):                      dtype.Bool,
+	reflect.TypeOf(map[string]interface{}{}):   dtype.Map,
+	reflect.TypeOf([]int64{}):                  dtype.ArrayInt64,
+	reflect.TypeOf([]string{}):                 dtype.ArrayString,
+	reflect.TypeOf([]byte{}):                   dtype.ArrayByte,
+	reflect.TypeOf(uint64(0)):                  dtype.Uint64,
+	reflect.TypeOf([]uint64{}):                 dtype.ArrayUint64,
+	reflect.TypeOf([]float64{}):                dtype.ArrayFloat64,
+	reflect.TypeOf([]int{}):                    dtype.ArrayInt,
+	reflect.TypeOf([][]byte{}):                 dtype.ArrayBytes,
+	reflect.TypeOf(time.Time{}):                dtype.Time,
+	reflect.TypeOf([]bool{}):                   dtype.ArrayBool,
+	reflect.TypeOf(map[string]string{}):        dtype.MapString,
+	reflect.TypeOf([]map[string]interface{}{}): dtype.ArrayMap,
+}
+
+type typedParam struct {
+	rtype reflect.Type
+	dtype dtype.DType
+	any   bool // interface{} 参数, 不做转换
+}
+
+type typedOutput struct {
+	name  string
+	index int
+	dtype dtype.DType
+}
+
+// TypedPlugin 通过反射调用任意签名的函数, 插件内部不再需要手动转换参数类型.
+// 函数签名需满足 func(ctx context.Context, args...) (Out, error), Out 可以是结构体, 结构体指针或 map[string]T.
+type TypedPlugin struct {
+	name    string
+	fn      reflect.Value
+	params  []typedParam
+	outputs []typedOutput // Out 为结构体时有效
+	isMap   bool
+}
+
+var _ IDescribedPlugin = new(TypedPlugin)
+
+// NewTypedPlugin 使用任意函数创建插件, 函数签名不合法时返回错误.
+//
+//	func(ctx context.Context, userID int64, tags []string) (*Out, error)
+func NewTypedPlugin(name string, fn interface{}) (*TypedPlugin, error) {
+	fnValue := reflect.ValueOf(fn)
+	fnType := fnValue.Type()
+	if fnValue.Kind() != reflect.Func {
+		return nil, fmt.Errorf("typed plugin %s: fn must be func, got %s", name, fnType)
+	}
+	if fnType.IsVariadic() {
+		return nil, fmt.Errorf("typed plugin %s: variadic func is not supported", name)
+	}
+	if fnType.NumIn() == 0 || fnType.In(0) != contextType {
+		return nil, fmt.Errorf("typed plugin %s: first param must be context.Context", name)
+	}
+	if fnType.NumOut() != 2 || fnType.Out(1) != errorType {
+		return nil, fmt.Errorf("typed plugin %s: results must be (Out, error)", name)
+	}
+
+	plugin := &TypedPlugin{
+		name:   name,
+		fn:     fnValue,
+		params: make([]typedParam, 0, fnType.NumIn()-1),
+	}
+	for i := 1; i < fnType.NumIn(); i++ {
+		param, err := newTypedParam(fnType.In(i))
+		if err != nil {
+			return nil, fmt.Errorf("typed plugin %s: param %d %s", name, i, err.Error())
+		}
+		plugin.params = append(plugin.params, param)
+	}
+
+	out := fnType.Out(0)
+	if out.Kind() == reflect.Ptr {
+		out = out.Elem()
+	}
+	switch {
+	case out.Kind() == reflect.Map && out.Key().Kind() == reflect.String:
+		plugin.isMap = true
+	case out.Kind() == reflect.Struct:
+		plugin.outputs = structOutputs(out)
+	default:
+		return nil, fmt.Errorf("typed plugin %s: Out must be struct, *struct or map[string]T, got %s",
+			name, fnType.Out(0))
+	}
+	return plugin, nil
+}
+
+func newTypedParam(rtype reflect.Type) (typedParam, error) {
+	if rtype.Kind() == reflect.Interface && rtype.NumMethod() == 0 {
+		return typedParam{rtype: rtype, any: true}, nil
+	}
+	if dt, ok := typedParamDTypes[rtype]; ok {
+		return typedParam{rtype: rtype, dtype: dt}, nil
+	}
+	switch rtype.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return typedParam{rtype: rtype, dtype: dtype.Int64}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return typedParam{rtype: rtype, dtype: dtype.Uint64}, nil
+	case reflect.Float32, reflect.Float64:
+		return typedParam{rtype: rtype, dtype: dtype.Float64}, nil
+	case reflect.String:
+		return typedParam{rtype: rtype, dtype: dtype.String}, nil
+	case reflect.Bool:
+		return typedParam{rtype: rtype, dtype: dtype.Bool}, nil
+	}
+	return typedParam{}, fmt.Errorf("type %s is not supported", rtype)
+}
+
+func structOutputs(rtype reflect.Type) []typedOutput {
+	outputs := make([]typedOutput, 0, rtype.NumField())
+	for i := 0; i < rtype.NumField(); i++ {
+		field := rtype.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup(TypedPluginTag); ok {
+			if tag = strings.Split(tag, ",")[0]; tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+		}
+		output := typedOutput{name: name, index: i}
+		if param, err := newTypedParam(field.Type); err == nil {
+			output.dtype = param.dtype
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs
+}
+
+func (p *TypedPlugin) GetName() string {
+	return p.name
+}
+
+// Describe 根据函数签名生成描述, 参数依次命名为 arg1, arg2... 参数在调用时会转换类型, 都标记为 Convertible.
+func (p *TypedPlugin) Describe() *PluginSchema {
+	schema := &PluginSchema{
+		Name:   p.name,
+		Params: make([]ParamSchema, len(p.params)),
+	}
+	for i, param := range p.params {
+		schema.Params[i] = ParamSchema{
+			Name:        "arg" + strconv.Itoa(i+1),
+			Type:        param.dtype,
+			Required:    true,
+			Convertible: true,
+		}
+	}
+	if !p.isMap {
+		schema.Outputs = make([]OutputSchema, len(p.outputs))
+		for i, output := range p.outputs {
+			schema.Outputs[i] = OutputSchema{Name: output.name, Type: output.dtype}
+		}
+	}
+	return schema
+}
+
+func (p *TypedPlugin) Call(ctx context.Context, args ...interface{}) (map[string]interface{}, error) {
+	if len(args) != len(p.params) {
+		return map[string]interface{}{}, fmt.Errorf("plugin %s expects %d params, got %d",
+			p.name, len(p.params), len(args))
+	}
+	in := make([]reflect.Value, len(args)+1)
+	in[0] = reflect.ValueOf(ctx)
+	for i, arg := range args {
+		value, err := p.params[i].convert(arg)
+		if err != nil {
+			return map[string]interface{}{}, fmt.Errorf("plugin %s param %d: %s", p.name, i+1, err.Error())
+		}
+		in[i+1] = value
+	}
+
+	results := p.fn.Call(in)
+	if err, _ := results[1].Interface().(error); err != nil {
+		return map[string]interface{}{}, err
+	}
+	return p.toMap(results[0]), nil
+}
+
+func (param typedParam) convert(arg interface{}) (reflect.Value, error) {
+	if param.any {
+		if arg == nil {
+			return reflect.Zero(param.rtype), nil
+		}
+		return reflect.ValueOf(arg), nil
+	}
+	value, err := dtype.Convert(arg, param.dtype)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	rvalue := reflect.ValueOf(value)
+	if !rvalue.IsValid() {
+		return reflect.Zero(param.rtype), nil
+	}
+	if rvalue.Type() != param.rtype {
+		if param.overflow(rvalue) {
+			return reflect.Value{}, fmt.Errorf("value %v overflows %s", value, param.rtype)
+		}
+		rvalue = rvalue.Convert(param.rtype)
+	}
+	return rvalue, nil
+}
+
+// 转换为较窄的整数/浮点类型时检查是否超出范围.
+func (param typedParam) overflow(rvalue reflect.Value) bool {
+	zero := reflect.Zero(param.rtype)
+	switch param.rtype.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return zero.OverflowInt(rvalue.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return zero.OverflowUint(rvalue.Uint())
+	case reflect.Float32:
+		return zero.OverflowFloat(rvalue.Float())
+	}
+	return false
+}
+
+func (p *TypedPlugin) toMap(out reflect.Value) map[string]interface{} {
+	if out.Kind() == reflect.Ptr {
+		if out.IsNil() {
+			return map[string]interface{}{}
+		}
+		out = out.Elem()
+	}
+	if p.isMap {
+		m := make(map[string]interface{}, out.Len())
+		iter := out.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return m
+	}
+	m := make(map[string]interface{}, len(p.outputs))
+	for _, output := range p.outputs {
+		m[output.name] = out.Field(output.index).Interface()
+	}
+	return m
+}
@@ -0,0 +1,109 @@
+package supplier
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedOut struct {
+	UserID  int64    `plugin:"user_id"`
+	Tags    []string `plugin:"tags"`
+	Score   float64
+	Ignored string `plugin:"-"`
+	private string
+}
+
+func TestNewTypedPlugin(t *testing.T) {
+	cases := []struct {
+		name  string
+		fn    interface{}
+		isErr bool
+	}{
+		{"struct_ptr", func(ctx context.Context, id int64) (*typedOut, error) { return nil, nil }, false},
+		{"struct", func(ctx context.Context, id int, name string) (typedOut, error) { return typedOut{}, nil }, false},
+		{"map", func(ctx context.Context, v interface{}) (map[string]int, error) { return nil, nil }, false},
+		{"builtin_types", func(ctx context.Context, id uint64, ts time.Time, attrs map[string]string) (*typedOut, error) {
+			return nil, nil
+		}, false},
+		{"uint_types", func(ctx context.Context, id uint, level uint8, count uint32) (*typedOut, error) {
+			return nil, nil
+		}, false},
+		{"not_func", 1, true},
+		{"no_ctx", func(id int64) (*typedOut, error) { return nil, nil }, true},
+		{"variadic", func(ctx context.Context, ids ...int64) (*typedOut, error) { return nil, nil }, true},
+		{"no_error", func(ctx context.Context) *typedOut { return nil }, true},
+		{"bad_out", func(ctx context.Context) (int, error) { return 0, nil }, true},
+		{"bad_param", func(ctx context.Context, ch chan int) (*typedOut, error) { return nil, nil }, true},
+	}
+	for _, c := range cases {
+		_, err := NewTypedPlugin(c.name, c.fn)
+		assert.Equal(t, c.isErr, err != nil, c.name)
+	}
+}
+
+func TestTypedPluginCall(t *testing.T) {
+	plugin, err := NewTypedPlugin("typed", func(ctx context.Context, userID int64, tags []string, level int32) (*typedOut, error) {
+		if userID == 0 {
+			return nil, errors.New("empty user")
+		}
+		return &typedOut{UserID: userID, Tags: tags, Score: float64(level), Ignored: "x"}, nil
+	})
+	assert.NoError(t, err)
+
+	// 参数会被转换为函数声明的类型
+	out, err := plugin.Call(context.Background(), "42", []interface{}{"a", "b"}, 3.0)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"user_id": int64(42),
+		"tags":    []string{"a", "b"},
+		"Score":   float64(3),
+	}, out)
+
+	_, err = plugin.Call(context.Background(), int64(0), []string{}, 1)
+	assert.EqualError(t, err, "empty user")
+
+	_, err = plugin.Call(context.Background(), int64(1))
+	assert.Error(t, err)
+
+	_, err = plugin.Call(context.Background(), "not_number", []string{}, 1)
+	assert.Error(t, err)
+
+	// 转换为较窄的类型时超出范围返回错误
+	_, err = plugin.Call(context.Background(), int64(1), []string{}, int64(1)<<40)
+	assert.ErrorContains(t, err, "overflows int32")
+
+	narrowPlugin, err := NewTypedPlugin("narrow", func(ctx context.Context, level int8, count uint32, score float32) (map[string]interface{}, error) {
+		return map[string]interface{}{"level": level, "count": count, "score": score}, nil
+	})
+	assert.NoError(t, err)
+	cases := []struct {
+		name  string
+		args  []interface{}
+		isErr bool
+	}{
+		{"in_range", []interface{}{int64(-128), "4294967295", 1.5}, false},
+		{"int8_overflow", []interface{}{int64(128), 1, 1.5}, true},
+		{"uint32_overflow", []interface{}{1, uint64(1) << 32, 1.5}, true},
+		{"uint32_negative", []interface{}{1, -1, 1.5}, true},
+		{"float32_overflow", []interface{}{1, 1, 1e39}, true},
+	}
+	for _, c := range cases {
+		_, err := narrowPlugin.Call(context.Background(), c.args...)
+		assert.Equal(t, c.isErr, err != nil, c.name)
+	}
+	out, err = narrowPlugin.Call(context.Background(), int64(-128), "4294967295", 1.5)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"level": int8(-128), "count": uint32(4294967295), "score": float32(1.5)}, out)
+
+	mapPlugin, err := NewTypedPlugin("map", func(ctx context.Context, v interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"v": v}, nil
+	})
+	assert.NoError(t, err)
+	out, err = mapPlugin.Call(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"v": nil}, out)
+}